@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// assetDatabaseID recovers the numeric REST ID of a release asset from its
+// GraphQL node ID, which GitHub does not expose as a separate field. Legacy
+// IDs are base64 of "012:ReleaseAsset<id>"; current IDs are "RA_" followed by
+// base64 of a MessagePack array whose last element is the ID.
+func assetDatabaseID(nodeID string) (int64, error) {
+	if rest, ok := strings.CutPrefix(nodeID, "RA_"); ok {
+		data, err := decodeNodeIDBase64(rest)
+		if err != nil {
+			return 0, fmt.Errorf("invalid asset ID %q: %w", nodeID, err)
+		}
+		id, err := lastMsgpackUint(data)
+		if err != nil {
+			return 0, fmt.Errorf("invalid asset ID %q: %w", nodeID, err)
+		}
+		return int64(id), nil
+	}
+
+	data, err := decodeNodeIDBase64(nodeID)
+	if err != nil {
+		return 0, fmt.Errorf("invalid asset ID %q: %w", nodeID, err)
+	}
+	_, typed, ok := strings.Cut(string(data), ":")
+	digits, isAsset := strings.CutPrefix(typed, "ReleaseAsset")
+	if !ok || !isAsset {
+		return 0, fmt.Errorf("asset ID %q is not a release asset", nodeID)
+	}
+	id, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid asset ID %q: %w", nodeID, err)
+	}
+	return id, nil
+}
+
+func decodeNodeIDBase64(s string) ([]byte, error) {
+	s = strings.TrimRight(s, "=")
+	if data, err := base64.RawURLEncoding.DecodeString(s); err == nil {
+		return data, nil
+	}
+	return base64.RawStdEncoding.DecodeString(s)
+}
+
+// lastMsgpackUint returns the last element of a MessagePack array of
+// unsigned integers.
+func lastMsgpackUint(data []byte) (uint64, error) {
+	if len(data) == 0 || data[0]&0xf0 != 0x90 {
+		return 0, errors.New("expected a MessagePack array")
+	}
+	n := int(data[0] & 0x0f)
+	data = data[1:]
+
+	var v uint64
+	for i := 0; i < n; i++ {
+		if len(data) == 0 {
+			return 0, errors.New("truncated MessagePack array")
+		}
+		size := map[byte]int{0xcc: 1, 0xcd: 2, 0xce: 4, 0xcf: 8}[data[0]]
+		switch {
+		case data[0] < 0x80:
+			v, data = uint64(data[0]), data[1:]
+			continue
+		case size == 0:
+			return 0, fmt.Errorf("unsupported MessagePack type 0x%02x", data[0])
+		case len(data) < 1+size:
+			return 0, errors.New("truncated MessagePack integer")
+		}
+		buf := make([]byte, 8)
+		copy(buf[8-size:], data[1:1+size])
+		v, data = binary.BigEndian.Uint64(buf), data[1+size:]
+	}
+	if n == 0 {
+		return 0, errors.New("empty MessagePack array")
+	}
+	return v, nil
+}
+
+// assetAPIURL is the REST endpoint that serves an asset's content to
+// clients sending "Accept: application/octet-stream" with their token.
+func assetAPIURL(owner, repo string, id int64) string {
+	return fmt.Sprintf("%s/repos/%s/%s/releases/assets/%d", restAPIURL, owner, repo, id)
+}
+
+// addAPIURLs fills in APIDownloadURL for every asset whose node ID can be
+// decoded. Releases merged in from another repository use their Source.
+func addAPIURLs(releases []NormalizedRelease, owner, repo string) {
+	for _, r := range releases {
+		o, n := owner, repo
+		if ref, err := parseRepoSlug(r.Source); err == nil {
+			o, n = ref.Owner, ref.Repo
+		}
+		for j := range r.Assets {
+			if id, err := assetDatabaseID(r.Assets[j].ID); err == nil {
+				r.Assets[j].APIDownloadURL = assetAPIURL(o, n, id)
+			}
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import "testing"
+
+func TestAssetDatabaseID(t *testing.T) {
+	testCases := []struct {
+		name    string
+		nodeID  string
+		want    int64
+		wantErr bool
+	}{
+		{"Legacy ID", "MDEyOlJlbGVhc2VBc3NldDEyMzQ1", 12345, false},
+		{"Current ID", "RA_kwDOCrze8M4HW80V", 123456789, false},
+		{"Not an asset", "MDc6UmVsZWFzZTEyMzQ1", 0, true},
+		{"Garbage", "RA_!!!", 0, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := assetDatabaseID(tc.nodeID)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("assetDatabaseID(%q) error = %v, wantErr %v", tc.nodeID, err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("assetDatabaseID(%q) = %d, want %d", tc.nodeID, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAddAPIURLs(t *testing.T) {
+	releases := []NormalizedRelease{
+		{Assets: []NormalizedAsset{{ID: "RA_kwDOCrze8M4HW80V"}}},
+		{Source: "mirror/gale", Assets: []NormalizedAsset{{ID: "MDEyOlJlbGVhc2VBc3NldDEyMzQ1"}}},
+	}
+	addAPIURLs(releases, "Typeflu", "gale")
+
+	want := []string{
+		"https://api.github.com/repos/Typeflu/gale/releases/assets/123456789",
+		"https://api.github.com/repos/mirror/gale/releases/assets/12345",
+	}
+	for i, r := range releases {
+		if got := r.Assets[0].APIDownloadURL; got != want[i] {
+			t.Errorf("release %d APIDownloadURL = %q, want %q", i, got, want[i])
+		}
+	}
+}
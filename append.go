@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"sort"
+)
+
+// appendKey identifies a release for --append. Releases always have a node
+// ID when fetched, but files written by hand may not, so the tag stands in.
+func appendKey(r NormalizedRelease) string {
+	if r.ID != "" {
+		return r.ID
+	}
+	return "tag:" + r.Version
+}
+
+// appendReleases merges fresh releases into existing ones. A fresh release
+// replaces the existing release with the same appendKey, so updated assets
+// and download counts win; existing releases that were not fetched again are
+// kept. The result is sorted newest first.
+func appendReleases(existing, fresh []NormalizedRelease) []NormalizedRelease {
+	seen := make(map[string]bool, len(fresh))
+	merged := make([]NormalizedRelease, 0, len(existing)+len(fresh))
+	for _, r := range fresh {
+		seen[appendKey(r)] = true
+		merged = append(merged, r)
+	}
+	for _, r := range existing {
+		if !seen[appendKey(r)] {
+			seen[appendKey(r)] = true
+			merged = append(merged, r)
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].PublishedAt.After(merged[j].PublishedAt)
+	})
+	return merged
+}
+
+// appendToExisting merges the releases of the output file at path, if there
+// is one, into output for --append. The metadata stays that of this run.
+func appendToExisting(output *OutputFile, path string) error {
+	existing, err := loadOutputFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	output.Releases = appendReleases(existing.Releases, output.Releases)
+	output.Repository.FetchedReleases = len(output.Releases)
+	output.Repository.TotalSize = totalAssetSize(output.Releases)
+	return nil
+}
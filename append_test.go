@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAppendToExisting(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	path := filepath.Join(t.TempDir(), "releases.json")
+
+	fresh := newOutputFile("cli", "gh", 3, []NormalizedRelease{
+		{ID: "R3", Version: "v1.2.0", PublishedAt: day(3), Assets: []NormalizedAsset{{Size: 10}}},
+		{ID: "R2", Version: "v1.1.0", PublishedAt: day(2), DownloadCount: 7, Assets: []NormalizedAsset{{Size: 5}}},
+	})
+	if err := appendToExisting(&fresh, path); err != nil {
+		t.Fatalf("appendToExisting() without a file error = %v", err)
+	}
+	if len(fresh.Releases) != 2 {
+		t.Fatalf("appendToExisting() without a file = %d releases, want 2", len(fresh.Releases))
+	}
+
+	old := newOutputFile("cli", "gh", 2, []NormalizedRelease{
+		{ID: "R2", Version: "v1.1.0", PublishedAt: day(2), DownloadCount: 1},
+		{ID: "R1", Version: "v1.0.0", PublishedAt: day(1), Assets: []NormalizedAsset{{Size: 1}}},
+	})
+	old.Metadata.FetchedAt = "2024-01-02T00:00:00Z"
+	data, err := json.Marshal(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := appendToExisting(&fresh, path); err != nil {
+		t.Fatalf("appendToExisting() error = %v", err)
+	}
+	var got []string
+	for _, r := range fresh.Releases {
+		got = append(got, r.ID)
+	}
+	if want := []string{"R3", "R2", "R1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("appendToExisting() releases = %v, want %v", got, want)
+	}
+	if fresh.Releases[1].DownloadCount != 7 {
+		t.Errorf("release R2 DownloadCount = %d, want the fetched 7", fresh.Releases[1].DownloadCount)
+	}
+	if fresh.Repository.FetchedReleases != 3 || fresh.Repository.TotalSize != 16 {
+		t.Errorf("repository = %+v, want 3 releases of 16 bytes", fresh.Repository)
+	}
+	if fresh.Metadata.FetchedAt == old.Metadata.FetchedAt {
+		t.Errorf("FetchedAt = %s, want the time of this run", fresh.Metadata.FetchedAt)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import "sort"
+
+const (
+	sortAssetsName = "name"
+	sortAssetsSize = "size"
+	sortAssetsType = "type"
+)
+
+// assetLess orders two assets for each --sort-assets key. Ties fall back to
+// the name so the order does not depend on what the API returned.
+var assetLess = map[string]func(a, b NormalizedAsset) bool{
+	sortAssetsName: func(a, b NormalizedAsset) bool { return a.Name < b.Name },
+	sortAssetsSize: func(a, b NormalizedAsset) bool {
+		if a.Size != b.Size {
+			return a.Size < b.Size
+		}
+		return a.Name < b.Name
+	},
+	sortAssetsType: func(a, b NormalizedAsset) bool {
+		if a.ContentType != b.ContentType {
+			return a.ContentType < b.ContentType
+		}
+		return a.Name < b.Name
+	},
+}
+
+// sortAssets orders the assets of every release by key, ascending unless
+// desc is set. An empty key keeps the API order.
+func sortAssets(releases []NormalizedRelease, key string, desc bool) {
+	less, ok := assetLess[key]
+	if !ok {
+		return
+	}
+	for _, r := range releases {
+		assets := r.Assets
+		sort.SliceStable(assets, func(i, j int) bool {
+			if desc {
+				return less(assets[j], assets[i])
+			}
+			return less(assets[i], assets[j])
+		})
+	}
+}
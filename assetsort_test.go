@@ -0,0 +1,44 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSortAssets(t *testing.T) {
+	newReleases := func() []NormalizedRelease {
+		return []NormalizedRelease{{Assets: []NormalizedAsset{
+			{Name: "gh.tar.gz", Size: 300, ContentType: "application/gzip"},
+			{Name: "checksums.txt", Size: 100, ContentType: "text/plain"},
+			{Name: "gh.deb", Size: 300, ContentType: "application/vnd.debian.binary-package"},
+			{Name: "gh.zip", Size: 200, ContentType: "application/gzip"},
+		}}}
+	}
+	names := func(r NormalizedRelease) string {
+		var names []string
+		for _, a := range r.Assets {
+			names = append(names, a.Name)
+		}
+		return strings.Join(names, " ")
+	}
+
+	testCases := []struct {
+		key  string
+		desc bool
+		want string
+	}{
+		{"", false, "gh.tar.gz checksums.txt gh.deb gh.zip"},
+		{"name", false, "checksums.txt gh.deb gh.tar.gz gh.zip"},
+		{"name", true, "gh.zip gh.tar.gz gh.deb checksums.txt"},
+		{"size", false, "checksums.txt gh.zip gh.deb gh.tar.gz"},
+		{"size", true, "gh.tar.gz gh.deb gh.zip checksums.txt"},
+		{"type", false, "gh.tar.gz gh.zip gh.deb checksums.txt"},
+	}
+	for _, tc := range testCases {
+		releases := newReleases()
+		sortAssets(releases, tc.key, tc.desc)
+		if got := names(releases[0]); got != tc.want {
+			t.Errorf("sortAssets(%q, desc %v) = %s, want %s", tc.key, tc.desc, got, tc.want)
+		}
+	}
+}
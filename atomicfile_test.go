@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "releases.json")
+
+	if err := writeFileAtomic(path, []byte("first"), 0o644); err != nil {
+		t.Fatalf("writeFileAtomic() of a new file error = %v", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o644 {
+		t.Errorf("new file mode = %v, %v, want 0644", info.Mode().Perm(), err)
+	}
+
+	if err := os.Chmod(path, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFileAtomic(path, []byte("new"), 0o644); err != nil {
+		t.Fatalf("writeFileAtomic() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "new" {
+		t.Errorf("file holds %q, %v, want new", data, err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("replaced file mode = %v, %v, want its previous 0600", info.Mode().Perm(), err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("directory holds %d entries, want only the output file", len(entries))
+	}
+
+	if err := writeFileAtomic(filepath.Join(dir, "missing", "releases.json"), []byte("new"), 0o644); err == nil {
+		t.Error("writeFileAtomic() into a missing directory: want an error")
+	}
+}
+
+func TestWriteFileAtomicSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "shared", "releases.json")
+	if err := os.Mkdir(filepath.Dir(target), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(target, []byte("old"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "releases.json")
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeFileAtomic(link, []byte("new"), 0o644); err != nil {
+		t.Fatalf("writeFileAtomic() through a symlink error = %v", err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("symlink was replaced: %v, %v", info.Mode(), err)
+	}
+	if data, err := os.ReadFile(target); err != nil || string(data) != "new" {
+		t.Errorf("symlink target holds %q, %v, want new", data, err)
+	}
+
+	if err := writeFileAtomic(os.DevNull, []byte("new"), 0o644); err != nil {
+		t.Errorf("writeFileAtomic(%s) error = %v", os.DevNull, err)
+	}
+}
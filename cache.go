@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// cacheDir returns the directory fetched releases are cached in:
+// $XDG_CACHE_HOME/gale, or the platform's user cache directory.
+func cacheDir() (string, error) {
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return filepath.Join(dir, "gale"), nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("could not locate a cache directory: %w", err)
+	}
+	return filepath.Join(dir, "gale"), nil
+}
+
+// cacheEntry is what is stored on disk for one fetch.
+// TokenExpiresAt is kept apart from Response, which does not encode it, so
+// the token expiry warning still fires on a cache hit.
+type cacheEntry struct {
+	FetchedAt      time.Time        `json:"fetchedAt"`
+	TokenExpiresAt time.Time        `json:"tokenExpiresAt,omitzero"`
+	Response       *GraphQLResponse `json:"response"`
+}
+
+// responseCache keeps fetch responses on disk so repeated runs within ttl
+// do not spend rate limit. With refresh set it never reads, only writes.
+type responseCache struct {
+	dir     string
+	ttl     time.Duration
+	refresh bool
+	now     func() time.Time
+	hits    atomic.Int32
+}
+
+// newResponseCache returns the cache for cfg, or nil when caching is off.
+// A dry run writes nothing, so it does not use the cache either.
+func newResponseCache(cfg *Config) (*responseCache, error) {
+	// Cached responses are decoded, so --raw needs them fetched again.
+	if cfg.NoCache || cfg.CacheTTL == 0 || cfg.DryRun || cfg.Raw {
+		return nil, nil
+	}
+	dir, err := cacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return &responseCache{dir: dir, ttl: cfg.CacheTTL, refresh: cfg.Refresh, now: time.Now}, nil
+}
+
+// path names the cache file for a fetch. The API host keeps Enterprise and
+// github.com apart; mode tells GraphQL, REST and --latest fetches apart. A
+// hash of the token keeps what one token may see from being replayed to
+// another token or to an unauthenticated run.
+func (c *responseCache) path(mode, owner, repo string, count int, token string) string {
+	host := "api.github.com"
+	if u, err := url.Parse(graphqlEndpoint); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	sum := sha256.Sum256([]byte(token))
+	name := fmt.Sprintf("%s_%s_%d_%s_%s.json", sanitizePathComponent(owner), sanitizePathComponent(repo), count, mode, hex.EncodeToString(sum[:8]))
+	return filepath.Join(c.dir, sanitizePathComponent(host), name)
+}
+
+// load returns the cached response at path if it is younger than ttl.
+func (c *responseCache) load(path string) (*GraphQLResponse, bool) {
+	if c.refresh {
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Response == nil || entry.Response.Data == nil {
+		return nil, false
+	}
+	if c.now().Sub(entry.FetchedAt) >= c.ttl {
+		return nil, false
+	}
+	entry.Response.TokenExpiresAt = entry.TokenExpiresAt
+	return entry.Response, true
+}
+
+// store writes res to path atomically, so a concurrent run never reads a
+// half-written entry.
+func (c *responseCache) store(path string, res *GraphQLResponse) error {
+	data, err := json.Marshal(cacheEntry{FetchedAt: c.now(), TokenExpiresAt: res.TokenExpiresAt, Response: res})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0o600)
+}
+
+// wrap returns fetch with the cache in front of it. A failure to write the
+// cache is only a warning; the fetched response is still returned.
+func (c *responseCache) wrap(mode string, fetch func(ctx context.Context, owner, repo string, count int, token string) (*GraphQLResponse, error)) func(ctx context.Context, owner, repo string, count int, token string) (*GraphQLResponse, error) {
+	return func(ctx context.Context, owner, repo string, count int, token string) (*GraphQLResponse, error) {
+		path := c.path(mode, owner, repo, count, token)
+		if res, ok := c.load(path); ok {
+			c.hits.Add(1)
+			return res, nil
+		}
+		res, err := fetch(ctx, owner, repo, count, token)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.store(path, res); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to cache releases of %s/%s: %v\n", owner, repo, err)
+		}
+		return res, nil
+	}
+}
+
+// etagEntry is a stored REST response: its ETag and what is needed to
+// replay it when GitHub answers 304 Not Modified.
+type etagEntry struct {
+	ETag   string      `json:"etag"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body"`
+}
+
+// etagTransport makes GET requests to the REST API conditional. It stores
+// each response's ETag and body in dir and sends If-None-Match next time,
+// replaying the stored body when GitHub answers 304 Not Modified, which does
+// not count against the rate limit. GitHub's GraphQL API sends no ETags, so
+// its POST requests, like asset downloads, pass through unchanged.
+type etagTransport struct {
+	next    http.RoundTripper
+	dir     string
+	refresh bool
+}
+
+// newETagTransport wraps next when cfg enables the cache.
+func newETagTransport(next http.RoundTripper, cfg *Config) http.RoundTripper {
+	if cfg.NoCache || cfg.CacheTTL == 0 || cfg.DryRun {
+		return next
+	}
+	dir, err := cacheDir()
+	if err != nil {
+		return next
+	}
+	return &etagTransport{next: next, dir: filepath.Join(dir, "etags"), refresh: cfg.Refresh}
+}
+
+// path names the entry for req. The credentials are part of the key so a
+// token never replays what another token was allowed to see.
+func (t *etagTransport) path(req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.URL.String() + "\n" + req.Header.Get("Authorization")))
+	return filepath.Join(t.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (t *etagTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || !strings.HasPrefix(req.URL.String(), restAPIURL) {
+		return t.next.RoundTrip(req)
+	}
+
+	path := t.path(req)
+	var entry *etagEntry
+	if !t.refresh {
+		if data, err := os.ReadFile(path); err == nil {
+			var e etagEntry
+			if json.Unmarshal(data, &e) == nil && e.ETag != "" {
+				entry = &e
+				req = req.Clone(req.Context())
+				req.Header.Set("If-None-Match", e.ETag)
+			}
+		}
+	}
+
+	res, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == http.StatusNotModified && entry != nil {
+		res.Body.Close()
+		header := res.Header.Clone()
+		for name, values := range entry.Header {
+			if header.Get(name) == "" {
+				header[name] = values
+			}
+		}
+		return &http.Response{
+			Status:        "200 OK",
+			StatusCode:    http.StatusOK,
+			Proto:         res.Proto,
+			ProtoMajor:    res.ProtoMajor,
+			ProtoMinor:    res.ProtoMinor,
+			Header:        header,
+			Body:          io.NopCloser(strings.NewReader(entry.Body)),
+			ContentLength: int64(len(entry.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	if res.StatusCode == http.StatusOK && res.Header.Get("ETag") != "" {
+		body, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		res.Body = io.NopCloser(bytes.NewReader(body))
+		stored := etagEntry{
+			ETag:   res.Header.Get("ETag"),
+			Header: http.Header{"Link": res.Header.Values("Link"), "Content-Type": res.Header.Values("Content-Type")},
+			Body:   string(body),
+		}
+		if data, err := json.Marshal(stored); err == nil {
+			if err := os.MkdirAll(t.dir, 0o700); err == nil {
+				_ = writeFileAtomic(path, data, 0o600)
+			}
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestResponseCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	fetches := 0
+	fetch := func(ctx context.Context, owner, repo string, count int, token string) (*GraphQLResponse, error) {
+		fetches++
+		return &GraphQLResponse{Data: &GraphQLData{Repository: &Repository{Releases: Releases{TotalCount: fetches}}}}, nil
+	}
+
+	testCases := []struct {
+		name      string
+		cfg       Config
+		at        time.Duration
+		wantTotal int
+	}{
+		{"First run fetches", Config{CacheTTL: time.Minute}, 0, 1},
+		{"Fresh entry is reused", Config{CacheTTL: time.Minute}, 30 * time.Second, 1},
+		{"Stale entry is refetched", Config{CacheTTL: time.Minute}, 2 * time.Minute, 2},
+		{"Refresh skips the entry", Config{CacheTTL: time.Minute, Refresh: true}, 150 * time.Second, 3},
+		{"Refreshed entry is reused", Config{CacheTTL: time.Minute}, 3 * time.Minute, 3},
+	}
+
+	for _, tc := range testCases {
+		cache, err := newResponseCache(&tc.cfg)
+		if err != nil {
+			t.Fatalf("%s: newResponseCache() error = %v", tc.name, err)
+		}
+		cache.now = func() time.Time { return now.Add(tc.at) }
+
+		res, err := cache.wrap("graphql", fetch)(context.Background(), "cli", "gh", 10, "")
+		if err != nil {
+			t.Fatalf("%s: fetch error = %v", tc.name, err)
+		}
+		if got := res.Data.Repository.Releases.TotalCount; got != tc.wantTotal {
+			t.Errorf("%s: got response %d, want %d", tc.name, got, tc.wantTotal)
+		}
+	}
+}
+
+func TestResponseCacheToken(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	expires := time.Date(2024, 3, 8, 12, 0, 0, 0, time.UTC)
+
+	fetches := 0
+	fetch := func(ctx context.Context, owner, repo string, count int, token string) (*GraphQLResponse, error) {
+		fetches++
+		return &GraphQLResponse{
+			Data:           &GraphQLData{Repository: &Repository{Releases: Releases{TotalCount: fetches}}},
+			TokenExpiresAt: expires,
+		}, nil
+	}
+	cache, err := newResponseCache(&Config{CacheTTL: time.Minute})
+	if err != nil {
+		t.Fatalf("newResponseCache() error = %v", err)
+	}
+	cached := cache.wrap("graphql", fetch)
+
+	testCases := []struct {
+		name      string
+		token     string
+		wantTotal int
+	}{
+		{"First token fetches", "ghp_one", 1},
+		{"Same token is served from the cache", "ghp_one", 1},
+		{"Other token fetches", "ghp_two", 2},
+		{"No token fetches", "", 3},
+	}
+	for _, tc := range testCases {
+		res, err := cached(context.Background(), "cli", "gh", 10, tc.token)
+		if err != nil {
+			t.Fatalf("%s: fetch error = %v", tc.name, err)
+		}
+		if got := res.Data.Repository.Releases.TotalCount; got != tc.wantTotal {
+			t.Errorf("%s: got response %d, want %d", tc.name, got, tc.wantTotal)
+		}
+		if !res.TokenExpiresAt.Equal(expires) {
+			t.Errorf("%s: TokenExpiresAt = %v, want %v", tc.name, res.TokenExpiresAt, expires)
+		}
+	}
+}
+
+func TestNewResponseCacheDisabled(t *testing.T) {
+	for _, cfg := range []Config{{CacheTTL: 0}, {CacheTTL: time.Minute, NoCache: true}} {
+		if cache, err := newResponseCache(&cfg); cache != nil || err != nil {
+			t.Errorf("newResponseCache(%+v) = %v, %v, want nil", cfg, cache, err)
+		}
+	}
+
+	// Caching is opt-in: a default run always fetches.
+	cfg := &Config{}
+	registerFlags(flag.NewFlagSet("gale", flag.ContinueOnError), cfg)
+	if cache, err := newResponseCache(cfg); cache != nil || err != nil {
+		t.Errorf("newResponseCache() with the default flags = %v, %v, want nil", cache, err)
+	}
+}
+
+func TestETagTransport(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	var conditional []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conditional = append(conditional, r.Header.Get("If-None-Match"))
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Link", `<https://example.com?page=3>; rel="last"`)
+		_, _ = io.WriteString(w, `[{"tag_name":"v1.0.0"}]`)
+	}))
+	defer srv.Close()
+
+	oldREST := restAPIURL
+	restAPIURL = srv.URL
+	defer func() { restAPIURL = oldREST }()
+
+	client := &http.Client{Transport: newETagTransport(http.DefaultTransport, &Config{CacheTTL: time.Minute})}
+	for i := 0; i < 2; i++ {
+		res, err := client.Get(srv.URL + "/repos/cli/gh/releases")
+		if err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+		body, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		if res.StatusCode != http.StatusOK || string(body) != `[{"tag_name":"v1.0.0"}]` {
+			t.Errorf("request %d = %d %q, want 200 with the release list", i, res.StatusCode, body)
+		}
+		if lastPage(res.Header.Get("Link")) != 3 {
+			t.Errorf("request %d Link = %q, want the stored rel=last link", i, res.Header.Get("Link"))
+		}
+	}
+	if want := []string{"", `"v1"`}; len(conditional) != 2 || conditional[0] != want[0] || conditional[1] != want[1] {
+		t.Errorf("If-None-Match headers = %q, want %q", conditional, want)
+	}
+}
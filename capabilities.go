@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// providers lists the release sources gale can read from.
+var providers = []string{"github"}
+
+func init() {
+	// Registered here because runCapabilities reads the subcommands map.
+	subcommands["capabilities"] = runCapabilities
+}
+
+// Capabilities describes what this gale binary supports, so wrappers can
+// detect features instead of parsing --help or comparing versions.
+type Capabilities struct {
+	Version   string   `json:"version"`
+	Commands  []string `json:"commands"`
+	Formats   []string `json:"formats"`
+	Providers []string `json:"providers"`
+	Flags     []string `json:"flags"`
+}
+
+// capabilities is derived from the same registries that drive parsing: the
+// subcommands map, outputFormats and registerFlags.
+func capabilities() Capabilities {
+	caps := Capabilities{
+		Version:   version,
+		Formats:   formatNames(),
+		Providers: providers,
+	}
+	for name := range subcommands {
+		caps.Commands = append(caps.Commands, name)
+	}
+	sort.Strings(caps.Commands)
+
+	fs := flag.NewFlagSet("gale", flag.ContinueOnError)
+	registerFlags(fs, &Config{})
+	fs.VisitAll(func(f *flag.Flag) {
+		if _, short := flagShorthands[f.Name]; !short {
+			caps.Flags = append(caps.Flags, f.Name)
+		}
+	})
+	return caps
+}
+
+// runCapabilities implements `gale capabilities [--json]`.
+func runCapabilities(args []string) error {
+	fs := flag.NewFlagSet("capabilities", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "Print capabilities as JSON")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	caps := capabilities()
+	if *asJSON {
+		data, err := json.Marshal(caps)
+		if err != nil {
+			return fmt.Errorf("failed to marshal capabilities: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	fmt.Printf("%s v%s\n", bright("gale"), caps.Version)
+	fmt.Printf("%s: %s\n", bright("Commands"), strings.Join(caps.Commands, ", "))
+	fmt.Printf("%s: %s\n", bright("Formats"), strings.Join(caps.Formats, ", "))
+	fmt.Printf("%s: %s\n", bright("Providers"), strings.Join(caps.Providers, ", "))
+	fmt.Printf("%s: --%s\n", bright("Flags"), strings.Join(caps.Flags, ", --"))
+	return nil
+}
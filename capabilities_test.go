@@ -0,0 +1,27 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCapabilities(t *testing.T) {
+	caps := capabilities()
+
+	if caps.Version != version {
+		t.Errorf("capabilities().Version = %q, want %q", caps.Version, version)
+	}
+	for _, want := range []string{"capabilities", "serve", "wait"} {
+		if !slices.Contains(caps.Commands, want) {
+			t.Errorf("capabilities().Commands = %v, missing %q", caps.Commands, want)
+		}
+	}
+	for _, want := range []string{formatJSON, formatTimeseries} {
+		if !slices.Contains(caps.Formats, want) {
+			t.Errorf("capabilities().Formats = %v, missing %q", caps.Formats, want)
+		}
+	}
+	if !slices.Contains(caps.Flags, "count") || slices.Contains(caps.Flags, "c") {
+		t.Errorf("capabilities().Flags = %v, want long names only", caps.Flags)
+	}
+}
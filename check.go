@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/fatih/color"
+)
+
+// AssetCheck is the result of probing one asset's download URL.
+type AssetCheck struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Status int    `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+func (c AssetCheck) ok() bool {
+	return c.Error == "" && c.Status < 400
+}
+
+// checkTarget is one repository whose assets should be verified.
+type checkTarget struct {
+	Repo     string
+	Releases []NormalizedRelease
+}
+
+// CheckReport groups unavailable assets by repository and release.
+type CheckReport struct {
+	Checked int               `json:"checked"`
+	Broken  int               `json:"broken"`
+	Repos   []CheckReportRepo `json:"repos"`
+}
+
+type CheckReportRepo struct {
+	Repo     string               `json:"repo"`
+	Releases []CheckReportRelease `json:"releases"`
+}
+
+type CheckReportRelease struct {
+	Tag    string       `json:"tag"`
+	Assets []AssetCheck `json:"assets"`
+}
+
+// checkAssets sends a HEAD request for every asset. Repositories are
+// checked one after another while the assets of each repository are probed
+// by at most concurrency workers at a time.
+func checkAssets(ctx context.Context, client *http.Client, targets []checkTarget, concurrency int) CheckReport {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var report CheckReport
+	for _, target := range targets {
+		type job struct {
+			release int
+			asset   int
+		}
+		results := make([][]AssetCheck, len(target.Releases))
+		jobs := make(chan job)
+		var wg sync.WaitGroup
+
+		for i, r := range target.Releases {
+			results[i] = make([]AssetCheck, len(r.Assets))
+		}
+		for w := 0; w < concurrency; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := range jobs {
+					a := target.Releases[j.release].Assets[j.asset]
+					results[j.release][j.asset] = headAsset(ctx, client, a)
+				}
+			}()
+		}
+		for i, r := range target.Releases {
+			for k := range r.Assets {
+				jobs <- job{release: i, asset: k}
+			}
+		}
+		close(jobs)
+		wg.Wait()
+
+		repo := CheckReportRepo{Repo: target.Repo}
+		for i, r := range target.Releases {
+			var broken []AssetCheck
+			for _, c := range results[i] {
+				report.Checked++
+				if !c.ok() {
+					broken = append(broken, c)
+				}
+			}
+			if len(broken) > 0 {
+				report.Broken += len(broken)
+				repo.Releases = append(repo.Releases, CheckReportRelease{Tag: r.Version, Assets: broken})
+			}
+		}
+		if len(repo.Releases) > 0 {
+			report.Repos = append(report.Repos, repo)
+		}
+	}
+	return report
+}
+
+func headAsset(ctx context.Context, client *http.Client, asset NormalizedAsset) AssetCheck {
+	result := AssetCheck{Name: asset.Name, URL: asset.DownloadURL}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, asset.DownloadURL, nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	res, err := client.Do(req)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if closeErr := res.Body.Close(); closeErr != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to close response body: %v\n", closeErr)
+	}
+	result.Status = res.StatusCode
+	return result
+}
+
+// printCheckReport prints the unavailable assets as an indented table, or
+// the whole report as JSON when asJSON is set. Both go to color.Output, which
+// is stderr when stdout carries the release document.
+func printCheckReport(report CheckReport, asJSON bool) error {
+	if asJSON {
+		data, err := json.Marshal(report)
+		if err != nil {
+			return fmt.Errorf("failed to marshal check report: %w", err)
+		}
+		fmt.Fprintln(color.Output, string(data))
+		return nil
+	}
+
+	if report.Broken == 0 {
+		successLog("%s All %s assets are available\n", icons["check"], bright(report.Checked))
+		return nil
+	}
+
+	errorLog("%s %s of %s assets are unavailable\n", icons["error"], bright(report.Broken), bright(report.Checked))
+	for _, repo := range report.Repos {
+		fmt.Fprintf(color.Output, "  %s\n", bright(repo.Repo))
+		for _, r := range repo.Releases {
+			fmt.Fprintf(color.Output, "    %s\n", magenta(r.Tag))
+			for _, a := range r.Assets {
+				reason := a.Error
+				if reason == "" {
+					reason = fmt.Sprintf("%d %s", a.Status, http.StatusText(a.Status))
+				}
+				fmt.Fprintf(color.Output, "      %-40s %s\n", a.Name, reason)
+			}
+		}
+	}
+	return nil
+}
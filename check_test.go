@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckAssets(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			t.Errorf("unexpected method %s", r.Method)
+		}
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	targets := []checkTarget{
+		{
+			Repo: "acme/app",
+			Releases: []NormalizedRelease{
+				{Version: "v2.0.0", Assets: []NormalizedAsset{
+					{Name: "ok.zip", DownloadURL: srv.URL + "/ok"},
+					{Name: "gone.zip", DownloadURL: srv.URL + "/missing"},
+				}},
+				{Version: "v1.0.0", Assets: []NormalizedAsset{
+					{Name: "ok.tar.gz", DownloadURL: srv.URL + "/ok"},
+				}},
+			},
+		},
+		{
+			Repo: "acme/lib",
+			Releases: []NormalizedRelease{
+				{Version: "v0.1.0", Assets: []NormalizedAsset{
+					{Name: "lib.zip", DownloadURL: srv.URL + "/ok"},
+				}},
+			},
+		},
+	}
+
+	report := checkAssets(context.Background(), srv.Client(), targets, 2)
+
+	if report.Checked != 4 || report.Broken != 1 {
+		t.Fatalf("checkAssets() checked %d, broken %d; want 4 and 1", report.Checked, report.Broken)
+	}
+	if len(report.Repos) != 1 || report.Repos[0].Repo != "acme/app" {
+		t.Fatalf("checkAssets() repos = %+v, want only acme/app", report.Repos)
+	}
+	releases := report.Repos[0].Releases
+	if len(releases) != 1 || releases[0].Tag != "v2.0.0" || releases[0].Assets[0].Name != "gone.zip" {
+		t.Errorf("checkAssets() releases = %+v, want gone.zip under v2.0.0", releases)
+	}
+	if got := releases[0].Assets[0].Status; got != http.StatusNotFound {
+		t.Errorf("broken asset status = %d, want %d", got, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// isChecksumAsset reports whether an asset publishes SHA-256 sums of the
+// other assets: a checksums.txt (or foo_checksums.txt) or a *.sha256 file.
+func isChecksumAsset(name string) bool {
+	lower := strings.ToLower(name)
+	return strings.HasSuffix(lower, "checksums.txt") || strings.HasSuffix(lower, ".sha256")
+}
+
+// parseChecksums reads sha256sum-style lines, "<hex>  <file>" with an
+// optional "*" before binary file names, into a map of file name to digest.
+// A name.sha256 file holding nothing but a digest is taken to describe name.
+func parseChecksums(name string, data []byte) (map[string]string, error) {
+	sums := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		digest := strings.ToLower(fields[0])
+		if _, err := hex.DecodeString(digest); err != nil || len(digest) != sha256.Size*2 {
+			return nil, fmt.Errorf("%s: %q is not a SHA-256 digest", name, fields[0])
+		}
+		file := strings.TrimSuffix(name, filepath.Ext(name))
+		if len(fields) > 1 {
+			// Assets are matched by base name, so drop any directory.
+			file = strings.TrimPrefix(fields[1], "*")
+			file = file[strings.LastIndex(file, "/")+1:]
+		}
+		sums[file] = digest
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return sums, nil
+}
+
+// fetchChecksums downloads every checksum asset of r and merges their sums.
+// Checksum files are small, so they are read into memory.
+func fetchChecksums(ctx context.Context, client *http.Client, r NormalizedRelease) (map[string]string, error) {
+	sums := make(map[string]string)
+	for _, a := range r.Assets {
+		if !isChecksumAsset(a.Name) {
+			continue
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.DownloadURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create download request: %w", err)
+		}
+		res, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to download %s: %w", a.Name, err)
+		}
+		data, err := io.ReadAll(res.Body)
+		_ = res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("failed to download %s: server responded with status %d", a.Name, res.StatusCode)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to download %s: %w", a.Name, err)
+		}
+		parsed, err := parseChecksums(a.Name, data)
+		if err != nil {
+			return nil, err
+		}
+		for file, digest := range parsed {
+			sums[file] = digest
+		}
+	}
+	return sums, nil
+}
+
+// fileSHA256 returns the hex SHA-256 digest of the file at path.
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+const (
+	checksumOK       = "ok"
+	checksumMismatch = "mismatch"
+	checksumMissing  = "missing"
+)
+
+// checksumResult is the outcome of verifying one downloaded file.
+type checksumResult struct {
+	Path   string
+	Status string
+	Want   string
+	Got    string
+}
+
+// verifyChecksums checks every file of plan that exists under dir against
+// the sums published by its release. Files whose release publishes no sum
+// for them are reported as missing rather than failed.
+func verifyChecksums(ctx context.Context, client *http.Client, dir string, releases []NormalizedRelease, plan []plannedDownload) ([]checksumResult, error) {
+	sums := make(map[string]map[string]string)
+	for _, r := range releases {
+		s, err := fetchChecksums(ctx, client, r)
+		if err != nil {
+			return nil, err
+		}
+		sums[r.Source+"@"+r.Version] = s
+	}
+
+	var results []checksumResult
+	for _, d := range plan {
+		if isChecksumAsset(d.Asset.Name) {
+			continue
+		}
+		dest := filepath.Join(dir, d.Path)
+		got, err := fileSHA256(dest)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash %s: %w", dest, err)
+		}
+		result := checksumResult{Path: dest, Got: got, Status: checksumMissing}
+		if want, ok := sums[d.Source+"@"+d.Tag][d.Asset.Name]; ok {
+			result.Want = want
+			result.Status = checksumOK
+			if want != got {
+				result.Status = checksumMismatch
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// printChecksumReport prints one line per verified file and a summary, and
+// returns the number of mismatches.
+func printChecksumReport(results []checksumResult) int {
+	var passed, failed, missing int
+	for _, r := range results {
+		switch r.Status {
+		case checksumOK:
+			passed++
+			fmt.Fprintf(color.Output, "  %s %s\n", color.GreenString(icons["check"]), r.Path)
+		case checksumMismatch:
+			failed++
+			fmt.Fprintf(color.Output, "  %s %s: expected sha256 %s, got %s\n", color.RedString(icons["error"]), r.Path, r.Want, r.Got)
+		default:
+			missing++
+			fmt.Fprintf(color.Output, "  %s %s: no published checksum\n", color.YellowString(icons["warning"]), r.Path)
+		}
+	}
+	summary := fmt.Sprintf("%s Checksums: %s passed, %s failed, %s without a published checksum\n", icons["info"], bright(passed), bright(failed), bright(missing))
+	if failed > 0 {
+		errorLog("%s", summary)
+	} else {
+		successLog("%s", summary)
+	}
+	return failed
+}
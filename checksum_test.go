@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestParseChecksums(t *testing.T) {
+	a, b := sha256Hex("a"), sha256Hex("b")
+
+	testCases := []struct {
+		name     string
+		data     string
+		expected map[string]string
+		wantErr  bool
+	}{
+		{"checksums.txt", a + "  gale_linux.tar.gz\n" + b + " *dist/gale.zip\n\n", map[string]string{"gale_linux.tar.gz": a, "gale.zip": b}, false},
+		{"gale.tar.gz.sha256", a + "\n", map[string]string{"gale.tar.gz": a}, false},
+		{"checksums.txt", "not-a-digest  gale.zip\n", nil, true},
+	}
+
+	for _, tc := range testCases {
+		got, err := parseChecksums(tc.name, []byte(tc.data))
+		if (err != nil) != tc.wantErr {
+			t.Errorf("parseChecksums(%q) error = %v, wantErr %v", tc.name, err, tc.wantErr)
+			continue
+		}
+		if !tc.wantErr && !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("parseChecksums(%q) = %v, want %v", tc.name, got, tc.expected)
+		}
+	}
+}
+
+func TestVerifyChecksums(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s  good.bin\n%s  bad.bin\n", sha256Hex("good"), sha256Hex("expected"))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	files := map[string]string{"good.bin": "good", "bad.bin": "corrupt", "extra.bin": "extra"}
+	var plan []plannedDownload
+	for _, name := range []string{"good.bin", "bad.bin", "extra.bin", "checksums.txt", "absent.bin"} {
+		if content, ok := files[name]; ok {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		plan = append(plan, plannedDownload{Tag: "v1", Asset: NormalizedAsset{Name: name}, Path: name})
+	}
+	releases := []NormalizedRelease{{Version: "v1", Assets: []NormalizedAsset{{Name: "checksums.txt", DownloadURL: srv.URL}}}}
+
+	results, err := verifyChecksums(context.Background(), srv.Client(), dir, releases, plan)
+	if err != nil {
+		t.Fatalf("verifyChecksums() error = %v", err)
+	}
+	got := make(map[string]string)
+	for _, r := range results {
+		got[filepath.Base(r.Path)] = r.Status
+	}
+	want := map[string]string{"good.bin": checksumOK, "bad.bin": checksumMismatch, "extra.bin": checksumMissing}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("verifyChecksums() statuses = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// clipboardCommands lists the commands that can take the clipboard's new
+// contents on stdin, in order of preference for goos.
+func clipboardCommands(goos string, wayland bool) [][]string {
+	switch goos {
+	case "darwin":
+		return [][]string{{"pbcopy"}}
+	case "windows":
+		return [][]string{{"clip.exe"}}
+	}
+	cmds := [][]string{{"xclip", "-selection", "clipboard"}, {"xsel", "--clipboard", "--input"}}
+	if wayland {
+		cmds = append([][]string{{"wl-copy"}}, cmds...)
+	}
+	return cmds
+}
+
+// lookPath is exec.LookPath, replaced in tests.
+var lookPath = exec.LookPath
+
+// copyToClipboard puts data on the system clipboard with the first
+// clipboard command that is installed.
+func copyToClipboard(data []byte) error {
+	for _, args := range clipboardCommands(runtime.GOOS, os.Getenv("WAYLAND_DISPLAY") != "") {
+		path, err := lookPath(args[0])
+		if err != nil {
+			continue
+		}
+		cmd := exec.Command(path, args[1:]...)
+		cmd.Stdin = bytes.NewReader(data)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%s failed: %w: %s", args[0], err, bytes.TrimSpace(stderr.Bytes()))
+		}
+		return nil
+	}
+	return errors.New("no clipboard tool found (install wl-clipboard, xclip or xsel)")
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestClipboardCommands(t *testing.T) {
+	testCases := []struct {
+		goos     string
+		wayland  bool
+		expected []string
+	}{
+		{"darwin", false, []string{"pbcopy"}},
+		{"windows", false, []string{"clip.exe"}},
+		{"linux", false, []string{"xclip", "xsel"}},
+		{"linux", true, []string{"wl-copy", "xclip", "xsel"}},
+	}
+
+	for _, tc := range testCases {
+		var got []string
+		for _, args := range clipboardCommands(tc.goos, tc.wayland) {
+			got = append(got, args[0])
+		}
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("clipboardCommands(%q, %v) = %v, want %v", tc.goos, tc.wayland, got, tc.expected)
+		}
+	}
+}
+
+func TestCopyToClipboardWithoutTool(t *testing.T) {
+	oldLookPath := lookPath
+	lookPath = func(string) (string, error) { return "", errors.New("not found") }
+	defer func() { lookPath = oldLookPath }()
+
+	if err := copyToClipboard([]byte("data")); err == nil {
+		t.Error("copyToClipboard() error = nil, want an error when no clipboard tool is installed")
+	}
+}
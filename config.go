@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// configFileNames are looked for, in order, in the working directory and
+// then in the home directory when --config is not given.
+var configFileNames = []string{"gale.toml", ".galerc"}
+
+// configFileExcluded lists flags a config file cannot set because they are
+// not defaults for a fetch.
+var configFileExcluded = map[string]bool{
+	"help":         true,
+	"version":      true,
+	"print-schema": true,
+	"config":       true,
+	"query-file":   true,
+	"explain":      true,
+	"check-update": true,
+	"self-update":  true,
+}
+
+// configFileWorkdirExcluded lists flags that a config file found in the
+// working directory cannot set. A checked-out repository could otherwise
+// send the token to its own API URL or proxy, or turn off TLS verification;
+// these belong in the home directory's config file or a --config file.
+var configFileWorkdirExcluded = map[string]bool{
+	"token":                true,
+	"token-file":           true,
+	"app-id":               true,
+	"app-private-key":      true,
+	"installation-id":      true,
+	"api-url":              true,
+	"proxy":                true,
+	"ca-cert":              true,
+	"insecure-skip-verify": true,
+}
+
+// findConfigFile returns the first config file that exists, or "" if there
+// is none, and whether it was found in the working directory.
+func findConfigFile() (string, bool) {
+	dirs := []string{"."}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, home)
+	}
+	for _, dir := range dirs {
+		for _, name := range configFileNames {
+			path := filepath.Join(dir, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path, dir == "."
+			}
+		}
+	}
+	return "", false
+}
+
+// parseConfigFile reads the subset of TOML gale's config uses: top-level
+// "key = value" pairs whose values are quoted strings, integers, floats or
+// booleans, with # comments. Durations are written as strings, e.g.
+// timeout = "2m". Tables and arrays are not supported.
+func parseConfigFile(path string, data []byte) (map[string]string, error) {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, raw, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("config file %s:%d: expected key = value", path, n)
+		}
+		key = strings.Trim(strings.TrimSpace(key), `"`)
+		value, err := parseConfigValue(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("config file %s:%d: %q: %w", path, n, key, err)
+		}
+		if _, dup := values[key]; dup {
+			return nil, fmt.Errorf("config file %s:%d: %q is set twice", path, n, key)
+		}
+		values[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// parseConfigValue parses a TOML scalar, dropping a trailing comment.
+func parseConfigValue(raw string) (string, error) {
+	switch {
+	case strings.HasPrefix(raw, `"`):
+		end := closingQuote(raw)
+		if end < 0 {
+			return "", errors.New("unterminated string")
+		}
+		if rest := strings.TrimSpace(raw[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+			return "", fmt.Errorf("unexpected %q after string", rest)
+		}
+		return strconv.Unquote(raw[:end+1])
+	case strings.HasPrefix(raw, "'"):
+		end := strings.Index(raw[1:], "'")
+		if end < 0 {
+			return "", errors.New("unterminated string")
+		}
+		if rest := strings.TrimSpace(raw[end+2:]); rest != "" && !strings.HasPrefix(rest, "#") {
+			return "", fmt.Errorf("unexpected %q after string", rest)
+		}
+		return raw[1 : end+1], nil
+	}
+
+	value, _, _ := strings.Cut(raw, "#")
+	value = strings.TrimSpace(value)
+	if value == "true" || value == "false" {
+		return value, nil
+	}
+	if _, err := strconv.ParseFloat(strings.ReplaceAll(value, "_", ""), 64); err == nil {
+		return strings.ReplaceAll(value, "_", ""), nil
+	}
+	return "", fmt.Errorf("unsupported value %q (quote strings)", value)
+}
+
+// closingQuote returns the index of the quote ending the basic string that
+// starts s, skipping escaped quotes, or -1.
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// applyConfigFile sets every flag in the config file at path that is still
+// unset, so command-line flags and query files take precedence over it.
+// workdir marks a file found in the working directory, which cannot set
+// the flags in configFileWorkdirExcluded.
+func applyConfigFile(fs *flag.FlagSet, path string, workdir bool) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	values, err := parseConfigFile(path, data)
+	if err != nil {
+		return err
+	}
+
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[longFlagName(f.Name)] = true
+	})
+	for key, value := range values {
+		if fs.Lookup(key) == nil || flagShorthands[key] != "" {
+			return fmt.Errorf("config file %s: unknown option %q", path, key)
+		}
+		if configFileExcluded[key] {
+			return fmt.Errorf("config file %s: %q cannot be set in a config file", path, key)
+		}
+		if workdir && configFileWorkdirExcluded[key] {
+			return fmt.Errorf("config file %s: %q cannot be set in the working directory's config file; use the home directory's or --config", path, key)
+		}
+		if explicit[key] {
+			continue
+		}
+		if err := fs.Set(key, value); err != nil {
+			return fmt.Errorf("config file %s: invalid value %q for %q: %w", path, value, key, err)
+		}
+	}
+	return nil
+}
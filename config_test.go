@@ -0,0 +1,134 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseConfigFile(t *testing.T) {
+	testCases := []struct {
+		name     string
+		data     string
+		expected map[string]string
+		wantErr  bool
+	}{
+		{
+			name: "Scalars",
+			data: `# defaults for gale
+count = 20
+output = "out/releases.json"   # relative to the working directory
+format = 'yaml'
+http2 = false
+timeout = "2m"
+tag-filter = "v\"1*"
+`,
+			expected: map[string]string{"count": "20", "output": "out/releases.json", "format": "yaml", "http2": "false", "timeout": "2m", "tag-filter": `v"1*`},
+		},
+		{name: "Unquoted string", data: "format = yaml\n", wantErr: true},
+		{name: "Table", data: "[defaults]\n", wantErr: true},
+		{name: "Duplicate", data: "count = 1\ncount = 2\n", wantErr: true},
+		{name: "Unterminated", data: "output = \"out.json\n", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseConfigFile("gale.toml", []byte(tc.data))
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("parseConfigFile() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if !tc.wantErr && !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("parseConfigFile() = %v, want %v", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestApplyConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gale.toml")
+	if err := os.WriteFile(path, []byte("count = 25\nformat = \"yaml\"\ntimeout = \"1m\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	cfg := &Config{}
+	registerFlags(fs, cfg)
+	if err := fs.Parse([]string{"-f", "csv"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyConfigFile(fs, path, false); err != nil {
+		t.Fatalf("applyConfigFile() error = %v", err)
+	}
+	if cfg.Count != 25 || cfg.Format != formatCSV || cfg.Timeout != time.Minute {
+		t.Errorf("count, format, timeout = %d, %q, %s, want 25, csv (from the flag), 1m", cfg.Count, cfg.Format, cfg.Timeout)
+	}
+
+	for _, data := range []string{"verbosity = 2\n", "c = 5\n", "query-file = \"q.json\"\n", "count = \"many\"\n"} {
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		registerFlags(fs, &Config{})
+		if err := applyConfigFile(fs, path, false); err == nil {
+			t.Errorf("applyConfigFile(%q) succeeded, want an error", data)
+		}
+	}
+}
+
+func TestFindConfigFile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Chdir(t.TempDir())
+
+	if got, _ := findConfigFile(); got != "" {
+		t.Errorf("findConfigFile() = %q, want none", got)
+	}
+
+	homeRC := filepath.Join(home, ".galerc")
+	if err := os.WriteFile(homeRC, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got, workdir := findConfigFile(); got != homeRC || workdir {
+		t.Errorf("findConfigFile() = %q, %t, want %q, false", got, workdir, homeRC)
+	}
+
+	if err := os.WriteFile("gale.toml", nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got, workdir := findConfigFile(); got != "gale.toml" || !workdir {
+		t.Errorf("findConfigFile() = %q, %t, want gale.toml in the working directory", got, workdir)
+	}
+}
+
+func TestApplyConfigFileWorkdir(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gale.toml")
+	for _, data := range []string{"api-url = \"https://evil.example.com\"\n", "token = \"ghp_x\"\n", "proxy = \"http://proxy:3128\"\n", "insecure-skip-verify = true\n"} {
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		registerFlags(fs, &Config{})
+		if err := applyConfigFile(fs, path, true); err == nil {
+			t.Errorf("applyConfigFile(%q) from the working directory succeeded, want an error", data)
+		}
+
+		fs = flag.NewFlagSet("test", flag.ContinueOnError)
+		registerFlags(fs, &Config{})
+		if err := applyConfigFile(fs, path, false); err != nil {
+			t.Errorf("applyConfigFile(%q) from the home directory error = %v", data, err)
+		}
+	}
+
+	if err := os.WriteFile(path, []byte("count = 5\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	cfg := &Config{}
+	registerFlags(fs, cfg)
+	if err := applyConfigFile(fs, path, true); err != nil || cfg.Count != 5 {
+		t.Errorf("applyConfigFile() from the working directory = %v, count %d, want nil, 5", err, cfg.Count)
+	}
+}
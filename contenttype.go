@@ -0,0 +1,81 @@
+package main
+
+import "strings"
+
+// stringList is a flag that can be repeated, or given a comma-separated
+// list, to collect several values. Its string form joins them with commas,
+// so save-query files read back the same list.
+type stringList []string
+
+func (l *stringList) String() string {
+	if l == nil {
+		return ""
+	}
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
+
+func (l *stringList) Get() interface{} {
+	return l.String()
+}
+
+// filterContentTypes keeps only the assets whose content type is one of
+// types, compared case-insensitively, and recomputes each release's asset
+// and download counts from what is left. With no types releases are
+// returned unchanged.
+func filterContentTypes(releases []NormalizedRelease, types []string) []NormalizedRelease {
+	if len(types) == 0 {
+		return releases
+	}
+	for i := range releases {
+		r := &releases[i]
+		kept := make([]NormalizedAsset, 0, len(r.Assets))
+		downloads := 0
+		for _, a := range r.Assets {
+			for _, t := range types {
+				if strings.EqualFold(a.ContentType, t) {
+					kept = append(kept, a)
+					downloads += a.DownloadCount
+					break
+				}
+			}
+		}
+		r.Assets, r.AssetCount, r.DownloadCount = kept, len(kept), downloads
+	}
+	return releases
+}
+
+// dropEmptyReleases removes releases that have no assets, for --drop-empty.
+func dropEmptyReleases(releases []NormalizedRelease) []NormalizedRelease {
+	kept := releases[:0]
+	for _, r := range releases {
+		if len(r.Assets) > 0 {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+// dropFewAssets removes releases with fewer than min assets, for
+// --min-assets. AssetCount is the asset total GitHub reports, or what is
+// left after --content-type. A min of 0 keeps every release.
+func dropFewAssets(releases []NormalizedRelease, min int) []NormalizedRelease {
+	if min <= 0 {
+		return releases
+	}
+	kept := releases[:0]
+	for _, r := range releases {
+		if r.AssetCount >= min {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+)
+
+func TestFilterContentTypes(t *testing.T) {
+	releases := []NormalizedRelease{
+		{Version: "v2.0.0", AssetCount: 3, DownloadCount: 60, Assets: []NormalizedAsset{
+			{Name: "gale_2.0.0_amd64.deb", ContentType: "application/vnd.debian.binary-package", DownloadCount: 10},
+			{Name: "gale-2.0.0.x86_64.rpm", ContentType: "application/x-rpm", DownloadCount: 20},
+			{Name: "gale_2.0.0_arm64.deb", ContentType: "Application/VND.Debian.Binary-Package", DownloadCount: 30},
+		}},
+		{Version: "v1.0.0", AssetCount: 1, DownloadCount: 5, Assets: []NormalizedAsset{
+			{Name: "gale.tar.gz", ContentType: "application/gzip", DownloadCount: 5},
+		}},
+	}
+
+	got := filterContentTypes(releases, []string{"application/vnd.debian.binary-package"})
+	var names []string
+	for _, a := range got[0].Assets {
+		names = append(names, a.Name)
+	}
+	if want := []string{"gale_2.0.0_amd64.deb", "gale_2.0.0_arm64.deb"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("filterContentTypes() kept %v, want %v", names, want)
+	}
+	if got[0].AssetCount != 2 || got[0].DownloadCount != 40 {
+		t.Errorf("counts = %d assets, %d downloads, want 2 and 40", got[0].AssetCount, got[0].DownloadCount)
+	}
+	if len(got[1].Assets) != 0 {
+		t.Errorf("v1.0.0 kept %v, want no assets", got[1].Assets)
+	}
+
+	kept := dropEmptyReleases(got)
+	if len(kept) != 1 || kept[0].Version != "v2.0.0" {
+		t.Errorf("dropEmptyReleases() = %v, want only v2.0.0", kept)
+	}
+}
+
+func TestFilterContentTypesMultiple(t *testing.T) {
+	releases := []NormalizedRelease{{Assets: []NormalizedAsset{
+		{Name: "a.deb", ContentType: "application/vnd.debian.binary-package"},
+		{Name: "a.rpm", ContentType: "application/x-rpm"},
+		{Name: "a.zip", ContentType: "application/zip"},
+	}}}
+
+	got := filterContentTypes(releases, []string{"application/x-rpm", "application/zip"})
+	if n := len(got[0].Assets); n != 2 {
+		t.Errorf("filterContentTypes() kept %d assets, want 2", n)
+	}
+	if unchanged := filterContentTypes([]NormalizedRelease{{AssetCount: 7}}, nil); unchanged[0].AssetCount != 7 {
+		t.Error("filterContentTypes() without types changed the release")
+	}
+}
+
+func TestStringListFlag(t *testing.T) {
+	var types stringList
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(&types, "content-type", "")
+	if err := fs.Parse([]string{"--content-type", "application/zip", "--content-type", "application/x-rpm, application/gzip"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := (stringList{"application/zip", "application/x-rpm", "application/gzip"}); !reflect.DeepEqual(types, want) {
+		t.Errorf("stringList = %q, want %q", types, want)
+	}
+	if got := types.Get(); got != "application/zip,application/x-rpm,application/gzip" {
+		t.Errorf("Get() = %q", got)
+	}
+}
+
+func TestDropFewAssets(t *testing.T) {
+	newReleases := func() []NormalizedRelease {
+		return []NormalizedRelease{{Version: "v3", AssetCount: 5}, {Version: "v2", AssetCount: 0}, {Version: "v1", AssetCount: 2}}
+	}
+	testCases := []struct {
+		min  int
+		want []string
+	}{
+		{0, []string{"v3", "v2", "v1"}},
+		{1, []string{"v3", "v1"}},
+		{3, []string{"v3"}},
+		{6, nil},
+	}
+	for _, tc := range testCases {
+		var got []string
+		for _, r := range dropFewAssets(newReleases(), tc.min) {
+			got = append(got, r.Version)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("dropFewAssets(%d) = %v, want %v", tc.min, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ReleaseDiff is the document printed by --diff: the assets that differ
+// between two releases, matched by name.
+type ReleaseDiff struct {
+	Owner    string            `json:"owner"`
+	Repo     string            `json:"repo"`
+	From     string            `json:"from"`
+	To       string            `json:"to"`
+	Added    []NormalizedAsset `json:"added"`
+	Removed  []NormalizedAsset `json:"removed"`
+	Modified []AssetChange     `json:"modified"`
+}
+
+// AssetChange is an asset present in both releases whose size changed.
+type AssetChange struct {
+	Name     string `json:"name"`
+	FromSize int64  `json:"fromSize"`
+	ToSize   int64  `json:"toSize"`
+}
+
+// splitDiffArgs finds the second tag of --diff. It is either part of the
+// flag value, as in "--diff v1.0.0..v1.1.0", or the last positional
+// argument, as in "gale cli gh --diff v1.0.0 v1.1.0". The remaining
+// arguments name the repository.
+func splitDiffArgs(cfg *Config, args []string) ([]string, error) {
+	if from, to, ok := strings.Cut(cfg.Diff, ".."); ok {
+		if from == "" || to == "" {
+			return nil, fmt.Errorf("invalid --diff %q (expected FROM..TO)", cfg.Diff)
+		}
+		cfg.Diff, cfg.DiffTo = from, to
+		return args, nil
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("--diff %s needs a second tag to compare with", cfg.Diff)
+	}
+	cfg.DiffTo = args[len(args)-1]
+	return args[:len(args)-1], nil
+}
+
+// diffAssets compares the assets of from and to by name. Each list is
+// sorted by name so the output is stable.
+func diffAssets(from, to NormalizedRelease) ReleaseDiff {
+	diff := ReleaseDiff{
+		From:     from.Version,
+		To:       to.Version,
+		Added:    []NormalizedAsset{},
+		Removed:  []NormalizedAsset{},
+		Modified: []AssetChange{},
+	}
+	old := make(map[string]NormalizedAsset, len(from.Assets))
+	for _, a := range from.Assets {
+		old[a.Name] = a
+	}
+	seen := make(map[string]bool, len(to.Assets))
+	for _, a := range to.Assets {
+		seen[a.Name] = true
+		prev, ok := old[a.Name]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, a)
+		case prev.Size != a.Size:
+			diff.Modified = append(diff.Modified, AssetChange{Name: a.Name, FromSize: prev.Size, ToSize: a.Size})
+		}
+	}
+	for _, a := range from.Assets {
+		if !seen[a.Name] {
+			diff.Removed = append(diff.Removed, a)
+		}
+	}
+	sort.Slice(diff.Added, func(i, j int) bool { return diff.Added[i].Name < diff.Added[j].Name })
+	sort.Slice(diff.Removed, func(i, j int) bool { return diff.Removed[i].Name < diff.Removed[j].Name })
+	sort.Slice(diff.Modified, func(i, j int) bool { return diff.Modified[i].Name < diff.Modified[j].Name })
+	return diff
+}
+
+// printReleaseDiff fetches the two releases named by --diff and prints how
+// their assets differ as JSON. It fails if either tag has no release.
+func printReleaseDiff(ctx context.Context, cfg *Config) error {
+	var releases [2]NormalizedRelease
+	for i, tag := range []string{cfg.Diff, cfg.DiffTo} {
+		node, err := fetchReleaseByTag(ctx, cfg.Owner, cfg.Repo, tag, cfg.Token)
+		if err != nil {
+			return err
+		}
+		if node == nil {
+			return fmt.Errorf("%s/%s has no release tagged %s", cfg.Owner, cfg.Repo, tag)
+		}
+		releases[i] = normalizeData([]ReleaseNode{*node})[0]
+	}
+
+	diff := diffAssets(releases[0], releases[1])
+	diff.Owner, diff.Repo = cfg.Owner, cfg.Repo
+	data, err := json.MarshalIndent(diff, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode diff: %w", err)
+	}
+	_, err = fmt.Fprintln(os.Stdout, string(data))
+	return err
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitDiffArgs(t *testing.T) {
+	testCases := []struct {
+		diff     string
+		args     []string
+		wantFrom string
+		wantTo   string
+		wantArgs []string
+		wantErr  bool
+	}{
+		{"v1.0.0", []string{"cli", "gh", "v1.1.0"}, "v1.0.0", "v1.1.0", []string{"cli", "gh"}, false},
+		{"v1.0.0", []string{"cli/gh", "v1.1.0"}, "v1.0.0", "v1.1.0", []string{"cli/gh"}, false},
+		{"v1.0.0", []string{"v1.1.0"}, "v1.0.0", "v1.1.0", []string{}, false},
+		{"v1.0.0..v1.1.0", []string{"cli", "gh"}, "v1.0.0", "v1.1.0", []string{"cli", "gh"}, false},
+		{"v1.0.0", nil, "", "", nil, true},
+		{"v1.0.0..", []string{"cli", "gh"}, "", "", nil, true},
+	}
+
+	for _, tc := range testCases {
+		cfg := &Config{Diff: tc.diff}
+		args, err := splitDiffArgs(cfg, tc.args)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("splitDiffArgs(%q, %v) error = %v, wantErr %v", tc.diff, tc.args, err, tc.wantErr)
+			continue
+		}
+		if tc.wantErr {
+			continue
+		}
+		if cfg.Diff != tc.wantFrom || cfg.DiffTo != tc.wantTo || !reflect.DeepEqual(args, tc.wantArgs) {
+			t.Errorf("splitDiffArgs(%q, %v) = %q..%q %v, want %q..%q %v", tc.diff, tc.args, cfg.Diff, cfg.DiffTo, args, tc.wantFrom, tc.wantTo, tc.wantArgs)
+		}
+	}
+}
+
+func TestDiffAssets(t *testing.T) {
+	from := NormalizedRelease{Version: "v1.0.0", Assets: []NormalizedAsset{
+		{Name: "gh_linux.tar.gz", Size: 100},
+		{Name: "gh_macos.zip", Size: 200},
+		{Name: "gh_windows.zip", Size: 300},
+	}}
+	to := NormalizedRelease{Version: "v1.1.0", Assets: []NormalizedAsset{
+		{Name: "gh_windows.zip", Size: 300},
+		{Name: "gh_linux.tar.gz", Size: 150},
+		{Name: "gh_linux.deb", Size: 50},
+		{Name: "checksums.txt", Size: 10},
+	}}
+
+	diff := diffAssets(from, to)
+	if diff.From != "v1.0.0" || diff.To != "v1.1.0" {
+		t.Errorf("diffAssets() compared %s..%s, want v1.0.0..v1.1.0", diff.From, diff.To)
+	}
+	names := func(assets []NormalizedAsset) []string {
+		var out []string
+		for _, a := range assets {
+			out = append(out, a.Name)
+		}
+		return out
+	}
+	if got, want := names(diff.Added), []string{"checksums.txt", "gh_linux.deb"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("diffAssets() added = %v, want %v", got, want)
+	}
+	if got, want := names(diff.Removed), []string{"gh_macos.zip"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("diffAssets() removed = %v, want %v", got, want)
+	}
+	if want := []AssetChange{{Name: "gh_linux.tar.gz", FromSize: 100, ToSize: 150}}; !reflect.DeepEqual(diff.Modified, want) {
+		t.Errorf("diffAssets() modified = %v, want %v", diff.Modified, want)
+	}
+
+	empty, err := json.Marshal(diffAssets(from, from))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(empty), `"added":[],"removed":[],"modified":[]`) {
+		t.Errorf("diff of identical releases = %s, want empty lists", empty)
+	}
+}
+
+func TestPrintReleaseDiffMissingTag(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Variables struct {
+				Tag string `json:"tag"`
+			} `json:"variables"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		repo := &Repository{}
+		if payload.Variables.Tag == "v1.0.0" {
+			repo.Release = &ReleaseNode{TagName: "v1.0.0"}
+		}
+		_ = json.NewEncoder(w).Encode(GraphQLResponse{Data: &GraphQLData{Repository: repo}})
+	}))
+	defer srv.Close()
+
+	oldEndpoint := graphqlEndpoint
+	graphqlEndpoint = srv.URL
+	defer func() { graphqlEndpoint = oldEndpoint }()
+
+	cfg := &Config{Owner: "cli", Repo: "gh", Diff: "v1.0.0", DiffTo: "v9.9.9"}
+	err := printReleaseDiff(context.Background(), cfg)
+	if err == nil || !strings.Contains(err.Error(), "v9.9.9") {
+		t.Errorf("printReleaseDiff() error = %v, want one naming v9.9.9", err)
+	}
+}
@@ -0,0 +1,455 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+)
+
+const defaultDownloadNameTemplate = "{tag}/{asset}"
+
+var placeholderPattern = regexp.MustCompile(`\{([a-zA-Z]+)\}`)
+
+// expandPlaceholders substitutes {name} placeholders in tmpl with values.
+// Placeholders that have no value are reported as an error rather than left
+// in the result.
+func expandPlaceholders(tmpl string, values map[string]string) (string, error) {
+	var missing []string
+	out := placeholderPattern.ReplaceAllStringFunc(tmpl, func(m string) string {
+		name := m[1 : len(m)-1]
+		v, ok := values[name]
+		if !ok {
+			missing = append(missing, m)
+			return m
+		}
+		return v
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("unknown placeholder %s in %q", strings.Join(missing, ", "), tmpl)
+	}
+	return out, nil
+}
+
+// sanitizePathComponent keeps a substituted value from introducing extra
+// directories or escaping the download directory.
+func sanitizePathComponent(s string) string {
+	s = strings.NewReplacer("/", "_", "\\", "_").Replace(s)
+	if s == "." || s == ".." {
+		return "_"
+	}
+	return s
+}
+
+// downloadPathValues returns the placeholder values available to
+// --download-name-template for a single asset.
+func downloadPathValues(owner, repo, tag, asset string) map[string]string {
+	return map[string]string{
+		"owner": sanitizePathComponent(owner),
+		"repo":  sanitizePathComponent(repo),
+		"tag":   sanitizePathComponent(tag),
+		"asset": sanitizePathComponent(asset),
+	}
+}
+
+// validateDownloadNameTemplate checks that tmpl only uses known placeholders
+// and always produces a relative path inside the download directory.
+func validateDownloadNameTemplate(tmpl string) error {
+	p, err := expandPlaceholders(tmpl, downloadPathValues("owner", "repo", "tag", "asset"))
+	if err != nil {
+		return fmt.Errorf("invalid --download-name-template: %w", err)
+	}
+	if filepath.IsAbs(p) || !filepath.IsLocal(filepath.FromSlash(p)) {
+		return fmt.Errorf("invalid --download-name-template %q: must be a relative path inside the download directory", tmpl)
+	}
+	return nil
+}
+
+// plannedDownload is a single asset and the path, relative to the download
+// directory, it will be saved to.
+type plannedDownload struct {
+	Source string
+	Tag    string
+	Asset  NormalizedAsset
+	Path   string
+}
+
+// planDownloads maps every asset to its local path using tmpl. Releases
+// merged in from another repository use their Source for {owner} and
+// {repo}. Paths that
+// more than one asset would be written to are returned as collisions; only
+// the first asset for such a path is kept in the plan.
+func planDownloads(owner, repo string, releases []NormalizedRelease, tmpl string) ([]plannedDownload, []string, error) {
+	var plan []plannedDownload
+	var collisions []string
+	seen := make(map[string]bool)
+
+	for _, r := range releases {
+		o, n := owner, repo
+		if ref, err := parseRepoSlug(r.Source); err == nil {
+			o, n = ref.Owner, ref.Repo
+		}
+		for _, a := range r.Assets {
+			p, err := expandPlaceholders(tmpl, downloadPathValues(o, n, r.Version, a.Name))
+			if err != nil {
+				return nil, nil, err
+			}
+			p = filepath.Clean(filepath.FromSlash(p))
+			if seen[p] {
+				collisions = append(collisions, p)
+				continue
+			}
+			seen[p] = true
+			plan = append(plan, plannedDownload{Source: r.Source, Tag: r.Version, Asset: a, Path: p})
+		}
+	}
+	return plan, collisions, nil
+}
+
+// sizeMismatchError reports a download whose length differs from the size
+// GitHub recorded for the asset.
+type sizeMismatchError struct {
+	Asset string
+	Want  int64
+	Got   int64
+}
+
+func (e *sizeMismatchError) Error() string {
+	return fmt.Sprintf("%s: expected %d bytes, got %d", e.Asset, e.Want, e.Got)
+}
+
+// downloadAsset saves asset to path. A transfer that announces or delivers a
+// different number of bytes than asset.Size is treated as corrupt and retried
+// up to retries more times before the last mismatch is returned. The file is
+// written next to path and only renamed into place once its size checks out,
+// so a truncated download never replaces a good copy. A partial file left by
+// an interrupted transfer from a server that supports ranges is resumed, by
+// later attempts and later runs alike. When progress is not nil a progress
+// bar for the transfer is drawn on it.
+func downloadAsset(ctx context.Context, client *http.Client, asset NormalizedAsset, path string, retries int, progress io.Writer) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", asset.Name, err)
+	}
+
+	var err error
+	for attempt := 0; attempt <= retries; attempt++ {
+		err = fetchAsset(ctx, client, asset, path+".part", progress)
+		var mismatch *sizeMismatchError
+		if err == nil || !errors.As(err, &mismatch) || ctx.Err() != nil {
+			break
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(path+".part", path)
+}
+
+// fetchAsset performs a single download attempt of asset into path. When
+// path already holds part of the asset it asks for the rest with a Range
+// header and appends it if the server answers 206 Partial Content; any
+// other answer replaces the file from the start. On failure path is kept
+// only if the server advertised Accept-Ranges: bytes, so the next attempt
+// can resume it.
+func fetchAsset(ctx context.Context, client *http.Client, asset NormalizedAsset, path string, progress io.Writer) (err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, asset.DownloadURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create download request: %w", err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	var offset int64
+	if info, statErr := os.Stat(path); statErr == nil && info.Mode().IsRegular() && info.Size() < asset.Size {
+		offset = info.Size()
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resumable := false
+	defer func() {
+		if err != nil && !resumable {
+			_ = os.Remove(path)
+		}
+	}()
+
+	res, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", asset.Name, err)
+	}
+	defer func() {
+		if closeErr := res.Body.Close(); closeErr != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to close response body: %v\n", closeErr)
+		}
+	}()
+
+	if res.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0 {
+		// The partial file does not match the asset any more; start over.
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		return fetchAsset(ctx, client, asset, path, progress)
+	}
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	switch {
+	case res.StatusCode == http.StatusPartialContent && offset > 0 && contentRangeStart(res.Header.Get("Content-Range")) == offset:
+		flags = os.O_WRONLY | os.O_APPEND
+	case res.StatusCode == http.StatusOK:
+		offset = 0
+	default:
+		return fmt.Errorf("failed to download %s: server responded with status %d", asset.Name, res.StatusCode)
+	}
+	if res.ContentLength >= 0 && offset+res.ContentLength != asset.Size {
+		return &sizeMismatchError{Asset: asset.Name, Want: asset.Size, Got: offset + res.ContentLength}
+	}
+
+	f, err := os.OpenFile(path, flags, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	resumable = res.StatusCode == http.StatusPartialContent || res.Header.Get("Accept-Ranges") == "bytes"
+	var body io.Reader = res.Body
+	if progress != nil {
+		bar := newProgressReader(res.Body, progress, asset.Name, asset.Size)
+		bar.done = offset
+		defer bar.finish()
+		body = bar
+	}
+	n, copyErr := io.Copy(f, body)
+	if err := f.Close(); err != nil && copyErr == nil {
+		copyErr = err
+	}
+	if n += offset; n != asset.Size {
+		// More bytes than the asset has cannot be resumed from.
+		resumable = resumable && n < asset.Size
+		return &sizeMismatchError{Asset: asset.Name, Want: asset.Size, Got: n}
+	}
+	if copyErr != nil {
+		return fmt.Errorf("failed to download %s: %w", asset.Name, copyErr)
+	}
+	return nil
+}
+
+// contentRangeStart returns the first byte of a "bytes START-END/SIZE"
+// Content-Range header, or -1 if it cannot be parsed.
+func contentRangeStart(header string) int64 {
+	spec, ok := strings.CutPrefix(header, "bytes ")
+	if !ok {
+		return -1
+	}
+	start, _, ok := strings.Cut(spec, "-")
+	if !ok {
+		return -1
+	}
+	n, err := strconv.ParseInt(start, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// tagDirTemplate returns the leading directories of the download name
+// template tmpl up to the first one that contains {tag}, e.g. {repo}/{tag}
+// for {repo}/{tag}/{asset}. ok is false when {tag} only appears in the file
+// name, so releases get no directory of their own.
+func tagDirTemplate(tmpl string) (string, bool) {
+	parts := strings.Split(filepath.ToSlash(tmpl), "/")
+	for i, part := range parts[:len(parts)-1] {
+		if strings.Contains(part, "{tag}") {
+			return strings.Join(parts[:i+1], "/"), true
+		}
+	}
+	return "", false
+}
+
+// skipExistingTags drops releases whose directory under dir, as laid out by
+// the download name template tmpl, already exists, so a re-run of a mirror
+// only fetches new releases. Releases merged in from another repository use
+// their Source for {owner} and {repo}, as in planDownloads. The skipped
+// tags are returned in their original order.
+func skipExistingTags(dir, tmpl, owner, repo string, releases []NormalizedRelease) ([]NormalizedRelease, []string, error) {
+	dirTmpl, ok := tagDirTemplate(tmpl)
+	if !ok {
+		return nil, nil, fmt.Errorf("--skip-existing-tags needs a --download-name-template with {tag} in a directory, e.g. {tag}/{asset}; got %q", tmpl)
+	}
+
+	var kept []NormalizedRelease
+	var skipped []string
+	for _, r := range releases {
+		o, n := owner, repo
+		if ref, err := parseRepoSlug(r.Source); err == nil {
+			o, n = ref.Owner, ref.Repo
+		}
+		p, err := expandPlaceholders(dirTmpl, downloadPathValues(o, n, r.Version, ""))
+		if err != nil {
+			return nil, nil, err
+		}
+		info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(p)))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, nil, fmt.Errorf("failed to read download directory: %w", err)
+		}
+		if err == nil && info.IsDir() {
+			skipped = append(skipped, r.Version)
+			continue
+		}
+		kept = append(kept, r)
+	}
+	return kept, skipped, nil
+}
+
+// validateAssetFilter checks that pattern is a valid --asset-filter glob.
+func validateAssetFilter(pattern string) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid --asset-filter %q: %w", pattern, err)
+	}
+	return nil
+}
+
+// filterAssets returns releases with only the assets whose name matches
+// the glob pattern. It runs before planDownloads, so an asset the filter
+// drops never takes a path away from one it keeps.
+func filterAssets(releases []NormalizedRelease, pattern string) []NormalizedRelease {
+	filtered := make([]NormalizedRelease, len(releases))
+	for i, r := range releases {
+		r.Assets = nil
+		for _, a := range releases[i].Assets {
+			if ok, _ := path.Match(pattern, a.Name); ok {
+				r.Assets = append(r.Assets, a)
+			}
+		}
+		filtered[i] = r
+	}
+	return filtered
+}
+
+// downloadPlan downloads every planned asset into dir with up to
+// concurrency transfers at a time. Files that already exist with the
+// asset's size are left alone. A failed download does not stop the others;
+// all failures are returned together, in plan order. Unless progress is nil,
+// a single worker draws progress bars on it; several workers would overwrite
+// each other's bars, so they print one line per finished asset instead.
+func downloadPlan(ctx context.Context, client *http.Client, dir string, plan []plannedDownload, retries, concurrency int, progress io.Writer) (downloaded, present int, err error) {
+	const (
+		statusDownloaded = iota + 1
+		statusPresent
+	)
+	statuses := make([]int, len(plan))
+	failures := make([]error, len(plan))
+	jobs := make(chan int)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for w := 0; w < concurrency && w < len(plan); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				d := plan[i]
+				dest := filepath.Join(dir, d.Path)
+				if info, statErr := os.Stat(dest); statErr == nil && info.Mode().IsRegular() && info.Size() == d.Asset.Size {
+					statuses[i] = statusPresent
+					continue
+				}
+				bars := progress
+				if concurrency > 1 {
+					bars = nil
+				}
+				failures[i] = downloadAsset(ctx, client, d.Asset, dest, retries, bars)
+				if failures[i] == nil {
+					statuses[i] = statusDownloaded
+				}
+				if progress != nil && concurrency > 1 {
+					icon := icons["check"]
+					if failures[i] != nil {
+						icon = icons["error"]
+					}
+					mu.Lock()
+					fmt.Fprintf(progress, "  %s %s (%s)\n", icon, d.Asset.Name, formatBytes(d.Asset.Size))
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for i := range plan {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, s := range statuses {
+		switch s {
+		case statusDownloaded:
+			downloaded++
+		case statusPresent:
+			present++
+		}
+	}
+	return downloaded, present, errors.Join(failures...)
+}
+
+// downloadReleases implements --download for output: it plans a path for
+// every asset that passes --asset-filter under cfg.DownloadDir, applies
+// --skip-existing-tags, downloads what is left with downloadClient and,
+// with --verify-checksums, checks the files against published SHA-256 sums.
+func downloadReleases(ctx context.Context, cfg *Config, output *OutputFile) error {
+	releases := output.Releases
+	if cfg.SkipExistingTags {
+		kept, skipped, err := skipExistingTags(cfg.DownloadDir, cfg.DownloadName, output.Repository.Owner, output.Repository.Repo, releases)
+		if err != nil {
+			return err
+		}
+		if len(skipped) > 0 && !cfg.Quiet {
+			infoLog("%s Skipping %s releases already in %s: %s\n", icons["info"], bright(len(skipped)), cfg.DownloadDir, strings.Join(skipped, ", "))
+		}
+		releases = kept
+	}
+
+	// Checksum files may not pass --asset-filter, so verifyChecksums below
+	// is given the releases with all their assets.
+	wanted := releases
+	if cfg.AssetFilter != "" {
+		wanted = filterAssets(releases, cfg.AssetFilter)
+	}
+	plan, collisions, err := planDownloads(output.Repository.Owner, output.Repository.Repo, wanted, cfg.DownloadName)
+	if err != nil {
+		return err
+	}
+	if len(collisions) > 0 {
+		warningLog("%s %s assets map to a path that is already taken and were skipped: %s\n", icons["warning"], bright(len(collisions)), strings.Join(collisions, ", "))
+	}
+
+	var progress io.Writer
+	if !cfg.Quiet {
+		progress = color.Output
+	}
+	downloaded, present, err := downloadPlan(ctx, downloadClient, cfg.DownloadDir, plan, cfg.Retries, cfg.DownloadConcurrency, progress)
+	if failed := len(plan) - downloaded - present; failed > 0 {
+		warningLog("%s Downloaded %s assets to %s (%s already present, %s failed)\n", icons["warning"], bright(downloaded), cyan(cfg.DownloadDir), bright(present), bright(failed))
+	} else if downloaded > 0 || present > 0 {
+		successLog("%s Downloaded %s assets to %s (%s already present)\n", icons["check"], bright(downloaded), cyan(cfg.DownloadDir), bright(present))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to download assets: %w", err)
+	}
+
+	if cfg.VerifyChecksums {
+		results, err := verifyChecksums(ctx, httpClient, cfg.DownloadDir, releases, plan)
+		if err != nil {
+			return fmt.Errorf("failed to verify checksums: %w", err)
+		}
+		if failed := printChecksumReport(results); failed > 0 {
+			return fmt.Errorf("%d of %d downloaded assets failed checksum verification", failed, len(results))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,465 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestExpandPlaceholders(t *testing.T) {
+	values := map[string]string{"repo": "gale", "tag": "v1.0.0"}
+
+	got, err := expandPlaceholders("{repo}-{tag}.json", values)
+	if err != nil {
+		t.Fatalf("expandPlaceholders() error = %v", err)
+	}
+	if got != "gale-v1.0.0.json" {
+		t.Errorf("expandPlaceholders() = %q, want %q", got, "gale-v1.0.0.json")
+	}
+
+	if _, err := expandPlaceholders("{repo}-{nope}", values); err == nil {
+		t.Error("expandPlaceholders() with an unknown placeholder should fail")
+	}
+}
+
+func TestValidateDownloadNameTemplate(t *testing.T) {
+	testCases := []struct {
+		tmpl    string
+		wantErr bool
+	}{
+		{"{tag}/{asset}", false},
+		{"{repo}-{tag}-{asset}", false},
+		{"mirror/{owner}/{repo}/{tag}/{asset}", false},
+		{"{tag}/{file}", true},
+		{"/tmp/{asset}", true},
+		{"../{asset}", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.tmpl, func(t *testing.T) {
+			if err := validateDownloadNameTemplate(tc.tmpl); (err != nil) != tc.wantErr {
+				t.Errorf("validateDownloadNameTemplate(%q) error = %v, wantErr %v", tc.tmpl, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestPlanDownloads(t *testing.T) {
+	releases := []NormalizedRelease{
+		{Version: "v1.1.0", Assets: []NormalizedAsset{{Name: "app.tar.gz"}, {Name: "app.zip"}}},
+		{Version: "v1.0.0", Assets: []NormalizedAsset{{Name: "app.tar.gz"}}},
+		{Version: "team/v0.9", Assets: []NormalizedAsset{{Name: "app.zip"}}},
+	}
+
+	plan, collisions, err := planDownloads("acme", "app", releases, "{repo}-{tag}-{asset}")
+	if err != nil {
+		t.Fatalf("planDownloads() error = %v", err)
+	}
+	var paths []string
+	for _, p := range plan {
+		paths = append(paths, p.Path)
+	}
+	expected := []string{"app-v1.1.0-app.tar.gz", "app-v1.1.0-app.zip", "app-v1.0.0-app.tar.gz", "app-team_v0.9-app.zip"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("planDownloads() paths = %v, want %v", paths, expected)
+	}
+	if len(collisions) != 0 {
+		t.Errorf("planDownloads() collisions = %v, want none", collisions)
+	}
+
+	_, collisions, err = planDownloads("acme", "app", releases, "{asset}")
+	if err != nil {
+		t.Fatalf("planDownloads() error = %v", err)
+	}
+	expected = []string{"app.tar.gz", "app.zip"}
+	if !reflect.DeepEqual(collisions, expected) {
+		t.Errorf("planDownloads() collisions = %v, want %v", collisions, expected)
+	}
+
+	plan, _, _ = planDownloads("acme", "app", releases[:1], defaultDownloadNameTemplate)
+	if want := filepath.Join("v1.1.0", "app.tar.gz"); plan[0].Path != want {
+		t.Errorf("planDownloads() default path = %q, want %q", plan[0].Path, want)
+	}
+}
+
+func TestDownloadAssetRetriesSizeMismatch(t *testing.T) {
+	const body = "complete asset body"
+
+	testCases := []struct {
+		name      string
+		truncated int
+		retries   int
+		wantErr   bool
+	}{
+		{"Intact first time", 0, 0, false},
+		{"Recovers within retries", 2, 2, false},
+		{"Gives up after retries", 3, 2, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			requests := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if requests <= tc.truncated {
+					_, _ = w.Write([]byte(body[:5]))
+					return
+				}
+				_, _ = w.Write([]byte(body))
+			}))
+			defer srv.Close()
+
+			asset := NormalizedAsset{Name: "asset.bin", DownloadURL: srv.URL, Size: int64(len(body))}
+			path := filepath.Join(t.TempDir(), "v1", "asset.bin")
+			err := downloadAsset(context.Background(), srv.Client(), asset, path, tc.retries, nil)
+
+			if tc.wantErr {
+				var mismatch *sizeMismatchError
+				if !errors.As(err, &mismatch) {
+					t.Fatalf("downloadAsset() error = %v, want size mismatch", err)
+				}
+				if _, err := os.Stat(path); !os.IsNotExist(err) {
+					t.Errorf("downloadAsset() left a file at %s after failing", path)
+				}
+				if requests != tc.retries+1 {
+					t.Errorf("downloadAsset() made %d requests, want %d", requests, tc.retries+1)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("downloadAsset() error = %v", err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != body {
+				t.Errorf("downloadAsset() wrote %q, want %q", got, body)
+			}
+		})
+	}
+}
+
+func TestSkipExistingTags(t *testing.T) {
+	dir := t.TempDir()
+	for _, d := range []string{"v1.0.0", "release_2"} {
+		if err := os.Mkdir(filepath.Join(dir, d), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A file named like a tag is not a finished release directory.
+	if err := os.WriteFile(filepath.Join(dir, "v3.0.0"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	releases := []NormalizedRelease{{Version: "v3.0.0"}, {Version: "release/2"}, {Version: "v2.0.0"}, {Version: "v1.0.0"}}
+
+	testCases := []struct {
+		name        string
+		dir         string
+		wantKept    []string
+		wantSkipped []string
+	}{
+		{"Existing directories", dir, []string{"v3.0.0", "v2.0.0"}, []string{"release/2", "v1.0.0"}},
+		{"Missing directory", filepath.Join(dir, "missing"), []string{"v3.0.0", "release/2", "v2.0.0", "v1.0.0"}, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			kept, skipped, err := skipExistingTags(tc.dir, defaultDownloadNameTemplate, "Typeflu", "gale", releases)
+			if err != nil {
+				t.Fatalf("skipExistingTags() error = %v", err)
+			}
+			var keptTags []string
+			for _, r := range kept {
+				keptTags = append(keptTags, r.Version)
+			}
+			if !reflect.DeepEqual(keptTags, tc.wantKept) || !reflect.DeepEqual(skipped, tc.wantSkipped) {
+				t.Errorf("skipExistingTags() = %v, %v, want %v, %v", keptTags, skipped, tc.wantKept, tc.wantSkipped)
+			}
+		})
+	}
+}
+
+func TestSkipExistingTagsTemplate(t *testing.T) {
+	dir := t.TempDir()
+	for _, d := range []string{"gale/v1.0.0", "gh/v2.0.0", "v3.0.0"} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	releases := []NormalizedRelease{{Version: "v3.0.0"}, {Version: "v2.0.0", Source: "cli/gh"}, {Version: "v1.0.0"}}
+
+	kept, skipped, err := skipExistingTags(dir, "{repo}/{tag}/{asset}", "Typeflu", "gale", releases)
+	if err != nil {
+		t.Fatalf("skipExistingTags() error = %v", err)
+	}
+	if len(kept) != 1 || kept[0].Version != "v3.0.0" || !reflect.DeepEqual(skipped, []string{"v2.0.0", "v1.0.0"}) {
+		t.Errorf("skipExistingTags() = %v, %v, want only v3.0.0 kept", kept, skipped)
+	}
+
+	if _, _, err := skipExistingTags(dir, "{tag}-{asset}", "Typeflu", "gale", releases); err == nil {
+		t.Error("skipExistingTags() with {tag} only in the file name: want an error")
+	}
+	cfg := &Config{}
+	registerFlags(flag.NewFlagSet("gale", flag.ContinueOnError), cfg) // the defaults
+	cfg.DownloadName, cfg.SkipExistingTags = "{tag}-{asset}", true
+	if err := validateConfig(cfg); err == nil || !strings.Contains(err.Error(), "--skip-existing-tags") {
+		t.Errorf("validateConfig() with {tag}-{asset} error = %v, want one naming --skip-existing-tags", err)
+	}
+}
+
+func TestFilterAssets(t *testing.T) {
+	releases := []NormalizedRelease{{Version: "v1.0.0", Assets: []NormalizedAsset{
+		{Name: "gale_linux.tar.gz"},
+		{Name: "gale_windows.zip"},
+		{Name: "checksums.txt"},
+	}}}
+
+	var got []string
+	for _, a := range filterAssets(releases, "*.tar.gz")[0].Assets {
+		got = append(got, a.Name)
+	}
+	if want := []string{"gale_linux.tar.gz"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("filterAssets(*.tar.gz) = %v, want %v", got, want)
+	}
+	if len(releases[0].Assets) != 3 {
+		t.Errorf("filterAssets() changed the releases it was given: %v", releases[0].Assets)
+	}
+}
+
+func TestFilterAssetsBeforePlanning(t *testing.T) {
+	// Both assets map to v1.0.0/gale; only the one the filter keeps counts.
+	releases := []NormalizedRelease{{Version: "v1.0.0", Assets: []NormalizedAsset{
+		{Name: "gale.zip"},
+		{Name: "gale.tar.gz"},
+	}}}
+
+	plan, collisions, err := planDownloads("Typeflu", "gale", filterAssets(releases, "*.tar.gz"), "{tag}/{repo}")
+	if err != nil {
+		t.Fatalf("planDownloads() error = %v", err)
+	}
+	if len(plan) != 1 || plan[0].Asset.Name != "gale.tar.gz" || len(collisions) != 0 {
+		t.Errorf("planDownloads() = %v, collisions %v, want only gale.tar.gz", plan, collisions)
+	}
+}
+
+func TestDownloadPlanSkipsPresentFiles(t *testing.T) {
+	const body = "asset body"
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "v1"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "v1", "present.bin"), []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "v1", "stale.bin"), []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	asset := func(name string) NormalizedAsset {
+		return NormalizedAsset{Name: name, DownloadURL: srv.URL, Size: int64(len(body))}
+	}
+	plan := []plannedDownload{
+		{Tag: "v1", Asset: asset("present.bin"), Path: filepath.Join("v1", "present.bin")},
+		{Tag: "v1", Asset: asset("stale.bin"), Path: filepath.Join("v1", "stale.bin")},
+		{Tag: "v2", Asset: asset("new.bin"), Path: filepath.Join("v2", "new.bin")},
+	}
+
+	downloaded, present, err := downloadPlan(context.Background(), srv.Client(), dir, plan, 0, 1, nil)
+	if err != nil {
+		t.Fatalf("downloadPlan() error = %v", err)
+	}
+	if downloaded != 2 || present != 1 || requests != 2 {
+		t.Errorf("downloadPlan() = %d downloaded, %d present with %d requests, want 2, 1 and 2", downloaded, present, requests)
+	}
+	for _, p := range []string{"v1/stale.bin", "v2/new.bin"} {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(p)))
+		if err != nil || string(data) != body {
+			t.Errorf("%s = %q, %v, want %q", p, data, err, body)
+		}
+	}
+}
+
+func TestDownloadPlanConcurrent(t *testing.T) {
+	const body = "asset body"
+	var inFlight, peak atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		if r.URL.Path == "/broken" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	var plan []plannedDownload
+	for i := 0; i < 6; i++ {
+		url := srv.URL + "/ok"
+		if i == 2 {
+			url = srv.URL + "/broken"
+		}
+		name := fmt.Sprintf("asset-%d.bin", i)
+		plan = append(plan, plannedDownload{Tag: "v1", Asset: NormalizedAsset{Name: name, DownloadURL: url, Size: int64(len(body))}, Path: name})
+	}
+
+	var progress bytes.Buffer
+	downloaded, present, err := downloadPlan(context.Background(), srv.Client(), t.TempDir(), plan, 0, 3, &progress)
+	if downloaded != 5 || present != 0 {
+		t.Errorf("downloadPlan() = %d downloaded, %d present, want 5 and 0", downloaded, present)
+	}
+	if err == nil || !strings.Contains(err.Error(), "asset-2.bin") {
+		t.Errorf("downloadPlan() error = %v, want the failure of asset-2.bin", err)
+	}
+	if p := peak.Load(); p < 2 || p > 3 {
+		t.Errorf("peak concurrent downloads = %d, want 2 or 3", p)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(progress.String(), "\n"), "\n")
+	if len(lines) != 6 || strings.Contains(progress.String(), "\r") {
+		t.Errorf("progress = %q, want one line per asset and no redrawn bars", progress.String())
+	}
+}
+
+func TestDownloadAssetResumes(t *testing.T) {
+	const body = "0123456789abcdefghij"
+	modTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name      string
+		partial   string
+		server    string
+		wantRange []string
+	}{
+		{"Resumes with Range", body[:8], "ranges", []string{"bytes=8-"}},
+		{"Restarts without range support", "garbage!", "plain", []string{"bytes=8-"}},
+		{"Restarts when the range is not satisfiable", "garbage!", "416", []string{"bytes=8-", ""}},
+		{"Fresh download", "", "ranges", []string{""}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var gotRange []string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotRange = append(gotRange, r.Header.Get("Range"))
+				switch {
+				case tc.server == "ranges":
+					http.ServeContent(w, r, "asset.bin", modTime, strings.NewReader(body))
+				case tc.server == "416" && r.Header.Get("Range") != "":
+					w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+				default:
+					_, _ = w.Write([]byte(body))
+				}
+			}))
+			defer srv.Close()
+
+			path := filepath.Join(t.TempDir(), "asset.bin")
+			if tc.partial != "" {
+				if err := os.WriteFile(path+".part", []byte(tc.partial), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			asset := NormalizedAsset{Name: "asset.bin", DownloadURL: srv.URL, Size: int64(len(body))}
+			if err := downloadAsset(context.Background(), srv.Client(), asset, path, 0, nil); err != nil {
+				t.Fatalf("downloadAsset() error = %v", err)
+			}
+			if !reflect.DeepEqual(gotRange, tc.wantRange) {
+				t.Errorf("Range headers = %q, want %q", gotRange, tc.wantRange)
+			}
+			if got, _ := os.ReadFile(path); string(got) != body {
+				t.Errorf("downloadAsset() wrote %q, want %q", got, body)
+			}
+		})
+	}
+}
+
+func TestDownloadAssetKeepsResumablePartial(t *testing.T) {
+	const body = "0123456789abcdefghij"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Accept-Ranges", "bytes")
+		w.Header().Set("Content-Length", fmt.Sprint(len(body)))
+		_, _ = w.Write([]byte(body[:8]))
+		w.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler) // drop the connection mid-transfer
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "asset.bin")
+	asset := NormalizedAsset{Name: "asset.bin", DownloadURL: srv.URL, Size: int64(len(body))}
+	if err := downloadAsset(context.Background(), srv.Client(), asset, path, 0, nil); err == nil {
+		t.Fatal("downloadAsset() succeeded, want an error for the dropped connection")
+	}
+	if got, err := os.ReadFile(path + ".part"); err != nil || string(got) != body[:8] {
+		t.Errorf("partial file = %q, %v, want %q kept for resuming", got, err, body[:8])
+	}
+}
+
+func TestContentRangeStart(t *testing.T) {
+	testCases := []struct {
+		header   string
+		expected int64
+	}{
+		{"bytes 8-19/20", 8},
+		{"bytes 0-0/1", 0},
+		{"bytes */20", -1},
+		{"", -1},
+	}
+
+	for _, tc := range testCases {
+		if got := contentRangeStart(tc.header); got != tc.expected {
+			t.Errorf("contentRangeStart(%q) = %d, want %d", tc.header, got, tc.expected)
+		}
+	}
+}
+
+func TestDownloadClientOutlastsTimeout(t *testing.T) {
+	const body = "slow asset body"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body[:4]))
+		w.(http.Flusher).Flush()
+		time.Sleep(150 * time.Millisecond)
+		_, _ = w.Write([]byte(body[4:]))
+	}))
+	defer srv.Close()
+
+	api := newHTTPClient(&Config{Timeout: 50 * time.Millisecond, NoCache: true})
+	asset := NormalizedAsset{Name: "asset.bin", DownloadURL: srv.URL, Size: int64(len(body))}
+	if err := downloadAsset(context.Background(), api, asset, filepath.Join(t.TempDir(), "asset.bin"), 0, nil); err == nil {
+		t.Fatal("downloadAsset() with the API client: want the body read cut off by its timeout")
+	}
+
+	path := filepath.Join(t.TempDir(), "asset.bin")
+	if err := downloadAsset(context.Background(), newDownloadClient(api), asset, path, 0, nil); err != nil {
+		t.Fatalf("downloadAsset() with the download client error = %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != body {
+		t.Errorf("downloaded %q, want %q", data, body)
+	}
+}
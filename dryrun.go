@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+// dryRunReport describes what writing output and the steps after it would
+// do, with the real numbers from the fetch. Like the run it stands in for,
+// it may read the filesystem, for --skip-existing-tags, but never writes.
+func dryRunReport(cfg *Config, output *OutputFile) ([]string, error) {
+	var lines []string
+	write := func(name string, part *OutputFile) error {
+		name, err := expandOutputPath(name, part, time.Now())
+		if err != nil {
+			return err
+		}
+		target := "stdout"
+		if name != "-" {
+			if cfg.Encrypt || len(cfg.EncryptTo) > 0 {
+				name = encryptedPath(name)
+			}
+			path, err := filepath.Abs(name)
+			if err != nil {
+				return fmt.Errorf("could not resolve path %q: %w", name, err)
+			}
+			target = path
+		}
+		lines = append(lines, fmt.Sprintf("write %d releases with %s of assets to %s", len(part.Releases), formatBytes(totalAssetSize(part.Releases)), target))
+		return nil
+	}
+
+	if cfg.Split {
+		for _, part := range splitOutput(output) {
+			if err := write(splitOutputPath(cfg.Output, part.Repository), &part); err != nil {
+				return nil, err
+			}
+		}
+	} else if err := write(cfg.Output, output); err != nil {
+		return nil, err
+	}
+	if cfg.Clipboard {
+		lines = append(lines, "copy the output to the clipboard")
+	}
+	if cfg.GSheet != "" {
+		lines = append(lines, fmt.Sprintf("%s %d asset rows to sheet %q of Google Sheet %s", cfg.GSheetMode, len(assetRows(output)), cfg.GSheetSheet, cfg.GSheet))
+	}
+
+	if cfg.Download {
+		releases := output.Releases
+		if cfg.SkipExistingTags {
+			kept, _, err := skipExistingTags(cfg.DownloadDir, cfg.DownloadName, output.Repository.Owner, output.Repository.Repo, releases)
+			if err != nil {
+				return nil, err
+			}
+			releases = kept
+		}
+		if cfg.AssetFilter != "" {
+			releases = filterAssets(releases, cfg.AssetFilter)
+		}
+		plan, _, err := planDownloads(output.Repository.Owner, output.Repository.Repo, releases, cfg.DownloadName)
+		if err != nil {
+			return nil, err
+		}
+		var size int64
+		for _, d := range plan {
+			size += d.Asset.Size
+		}
+		lines = append(lines, fmt.Sprintf("download %d assets (%s) to %s", len(plan), formatBytes(size), cfg.DownloadDir))
+	}
+
+	if cfg.Check {
+		assets := 0
+		for _, r := range output.Releases {
+			assets += len(r.Assets)
+		}
+		lines = append(lines, fmt.Sprintf("check %d asset URLs", assets))
+	}
+	return lines, nil
+}
+
+// printDryRun prints dryRunReport for --dry-run.
+func printDryRun(cfg *Config, output *OutputFile) error {
+	lines, err := dryRunReport(cfg, output)
+	if err != nil {
+		return err
+	}
+	infoLog("%s Dry run, nothing was written. A real run would:\n", icons["info"])
+	for _, line := range lines {
+		infoLog("  - %s\n", line)
+	}
+	return nil
+}
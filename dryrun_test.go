@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDryRunReport(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	output := newOutputFile("cli", "gh", 2, []NormalizedRelease{
+		{Version: "v2.0.0", Assets: []NormalizedAsset{{Name: "gh.tar.gz", Size: 2048}, {Name: "gh.zip", Size: 1024}}},
+		{Version: "v1.0.0", Assets: []NormalizedAsset{{Name: "gh.tar.gz", Size: 1024}}},
+	})
+	cfg := &Config{
+		Output:       "releases.json",
+		Encrypt:      true,
+		Download:     true,
+		DownloadDir:  "downloads",
+		DownloadName: defaultDownloadNameTemplate,
+		AssetFilter:  "*.tar.gz",
+		Check:        true,
+	}
+
+	got, err := dryRunReport(cfg, &output)
+	if err != nil {
+		t.Fatalf("dryRunReport() error = %v", err)
+	}
+	want := []string{
+		"write 2 releases with 4.0 KB of assets to " + filepath.Join(dir, "releases.json.enc"),
+		"download 2 assets (3.0 KB) to downloads",
+		"check 3 asset URLs",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("dryRunReport() = %q, want %q", got, want)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) > 0 {
+		t.Errorf("dryRunReport() created %d files, want none", len(entries))
+	}
+}
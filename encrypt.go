@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"golang.org/x/term"
+)
+
+const encryptedExt = ".enc"
+
+// encryptData encrypts data to recipients in the age format.
+func encryptData(data []byte, recipients ...age.Recipient) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, recipients...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start encryption: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to encrypt output: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish encryption: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// decryptData decrypts age encrypted data with any of identities.
+func decryptData(data []byte, identities ...age.Identity) ([]byte, error) {
+	r, err := age.Decrypt(bytes.NewReader(data), identities...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
+	}
+	plain, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
+	}
+	return plain, nil
+}
+
+// readPassphrase returns GALE_PASSPHRASE or prompts for a passphrase on the
+// terminal, asking twice when confirm is set. The passphrase is never echoed
+// or logged.
+func readPassphrase(confirm bool) (string, error) {
+	if p := os.Getenv("GALE_PASSPHRASE"); p != "" {
+		return p, nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", errors.New("no passphrase: set GALE_PASSPHRASE or run from a terminal")
+	}
+	prompt := func(label string) (string, error) {
+		fmt.Fprint(os.Stderr, label)
+		p, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read passphrase: %w", err)
+		}
+		return string(p), nil
+	}
+
+	p, err := prompt("Passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if p == "" {
+		return "", errors.New("passphrase must not be empty")
+	}
+	if confirm {
+		again, err := prompt("Confirm passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if again != p {
+			return "", errors.New("passphrases do not match")
+		}
+	}
+	return p, nil
+}
+
+// encryptWithPassphrase encrypts data so that passphrase decrypts it.
+func encryptWithPassphrase(data []byte, passphrase string) ([]byte, error) {
+	recipient, err := age.NewScryptRecipient(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return encryptData(data, recipient)
+}
+
+// parseRecipients parses the --encrypt-to values. Each is an age public key
+// or the path of a recipients file holding one key per line, as written by
+// age-keygen -y.
+func parseRecipients(values []string) ([]age.Recipient, error) {
+	var recipients []age.Recipient
+	for _, value := range values {
+		if strings.HasPrefix(value, "age1") {
+			r, err := age.ParseX25519Recipient(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid --encrypt-to recipient %q: %w", value, err)
+			}
+			recipients = append(recipients, r)
+			continue
+		}
+		f, err := os.Open(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --encrypt-to %q: not an age public key or a readable recipients file", value)
+		}
+		parsed, err := age.ParseRecipients(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("invalid --encrypt-to recipients file %s: %w", value, err)
+		}
+		recipients = append(recipients, parsed...)
+	}
+	return recipients, nil
+}
+
+// encryptOutput encrypts the rendered output with the --encrypt passphrase
+// or to the --encrypt-to recipients. Only the recipients' identities can
+// decrypt the latter, e.g. with age -d -i key.txt.
+func encryptOutput(data []byte, cfg *Config, passphrase string) ([]byte, error) {
+	if cfg.Encrypt {
+		return encryptWithPassphrase(data, passphrase)
+	}
+	recipients, err := parseRecipients(cfg.EncryptTo) // validated by validateConfig
+	if err != nil {
+		return nil, err
+	}
+	return encryptData(data, recipients...)
+}
+
+// runDecrypt implements `gale decrypt file.enc [-o out]`, printing the
+// decrypted document to stdout unless -o is given.
+func runDecrypt(args []string) error {
+	fs := flag.NewFlagSet("decrypt", flag.ContinueOnError)
+	output := fs.String("output", "", "Write the decrypted output to this file instead of stdout")
+	fs.StringVar(output, "o", "", "Write the decrypted output to this file (shorthand)")
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if len(positional) != 1 {
+		return errors.New("usage: gale decrypt <file" + encryptedExt + "> [-o output]")
+	}
+
+	data, err := os.ReadFile(positional[0])
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", positional[0], err)
+	}
+	passphrase, err := readPassphrase(false)
+	if err != nil {
+		return err
+	}
+	identity, err := age.NewScryptIdentity(passphrase)
+	if err != nil {
+		return err
+	}
+	plain, err := decryptData(data, identity)
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		_, err = os.Stdout.Write(plain)
+		return err
+	}
+	if err := os.WriteFile(*output, plain, 0600); err != nil {
+		return fmt.Errorf("failed to write to file %s: %w", *output, err)
+	}
+	successLog("%s Decrypted %s to %s\n", icons["check"], positional[0], cyan(*output))
+	return nil
+}
+
+// encryptedPath appends encryptedExt to path unless it already has it.
+func encryptedPath(path string) string {
+	if strings.HasSuffix(path, encryptedExt) {
+		return path
+	}
+	return path + encryptedExt
+}
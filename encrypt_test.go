@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+)
+
+func TestEncryptRoundTrip(t *testing.T) {
+	plain := []byte(`{"releases":[{"version":"v1.0.0"}]}`)
+
+	recipient, err := age.NewScryptRecipient("correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipient.SetWorkFactor(10) // keep the test fast
+
+	encrypted, err := encryptData(plain, recipient)
+	if err != nil {
+		t.Fatalf("encryptData() error = %v", err)
+	}
+	if bytes.Contains(encrypted, []byte("v1.0.0")) {
+		t.Fatal("encrypted output contains plaintext")
+	}
+
+	identity, err := age.NewScryptIdentity("correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := decryptData(encrypted, identity)
+	if err != nil {
+		t.Fatalf("decryptData() error = %v", err)
+	}
+	if !bytes.Equal(decrypted, plain) {
+		t.Errorf("decryptData() = %q, want %q", decrypted, plain)
+	}
+
+	wrong, _ := age.NewScryptIdentity("wrong passphrase")
+	if _, err := decryptData(encrypted, wrong); err == nil {
+		t.Error("decryptData() with the wrong passphrase should fail")
+	}
+}
+
+func TestEncryptedPath(t *testing.T) {
+	if got := encryptedPath("releases.json"); got != "releases.json.enc" {
+		t.Errorf("encryptedPath() = %q, want releases.json.enc", got)
+	}
+	if got := encryptedPath("releases.json.enc"); got != "releases.json.enc" {
+		t.Errorf("encryptedPath() = %q, want releases.json.enc", got)
+	}
+}
+
+func TestEncryptOutputToRecipients(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipientsFile := filepath.Join(t.TempDir(), "recipients.txt")
+	if err := os.WriteFile(recipientsFile, []byte("# team\n"+other.Recipient().String()+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	plain := []byte(`{"releases":[{"version":"v1.0.0"}]}`)
+	cfg := &Config{EncryptTo: stringList{identity.Recipient().String(), recipientsFile}}
+	encrypted, err := encryptOutput(plain, cfg, "")
+	if err != nil {
+		t.Fatalf("encryptOutput() error = %v", err)
+	}
+	for _, id := range []*age.X25519Identity{identity, other} {
+		decrypted, err := decryptData(encrypted, id)
+		if err != nil || !bytes.Equal(decrypted, plain) {
+			t.Errorf("decryptData() = %q, %v, want %q for every recipient", decrypted, err, plain)
+		}
+	}
+
+	for _, value := range []string{"age1notakey", filepath.Join(t.TempDir(), "missing.txt")} {
+		if _, err := parseRecipients([]string{value}); err == nil {
+			t.Errorf("parseRecipients(%q): want an error", value)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"errors"
+	"net/url"
+)
+
+// Exit codes returned by main, so scripts can tell failures apart.
+const (
+	exitError        = 1
+	exitRepoNotFound = 2
+	exitUnauthorized = 3
+	exitRateLimited  = 4
+	exitNetwork      = 5
+)
+
+// exitCode maps err to the process exit code. Errors from asset downloads
+// and other requests outside the API are not wrapped in ErrNetwork, so any
+// *url.Error left over from the HTTP client counts as a network error too.
+func exitCode(err error) int {
+	var urlErr *url.Error
+	switch {
+	case errors.Is(err, ErrRepoNotFound):
+		return exitRepoNotFound
+	case errors.Is(err, ErrUnauthorized):
+		return exitUnauthorized
+	case errors.Is(err, ErrRateLimited):
+		return exitRateLimited
+	case errors.Is(err, ErrNetwork), errors.As(err, &urlErr):
+		return exitNetwork
+	}
+	return exitError
+}
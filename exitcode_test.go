@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestExitCode(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{"Other", errors.New("boom"), exitError},
+		{"Not found", fmt.Errorf("cli/gh: %w", ErrRepoNotFound), exitRepoNotFound},
+		{"Unauthorized", fmt.Errorf("%w (status 401)", ErrUnauthorized), exitUnauthorized},
+		{"Rate limited", &RateLimitError{Reset: time.Now()}, exitRateLimited},
+		{"Network", fmt.Errorf("%w: %w", ErrNetwork, errors.New("dial tcp: refused")), exitNetwork},
+		{"Download", fmt.Errorf("failed to download gh.zip: %w", &url.Error{Op: "Get", URL: "https://example.com", Err: errors.New("EOF")}), exitNetwork},
+	}
+
+	for _, tc := range testCases {
+		if got := exitCode(tc.err); got != tc.expected {
+			t.Errorf("exitCode(%s) = %d, want %d", tc.name, got, tc.expected)
+		}
+	}
+}
+
+func TestFetchGraphQLUnauthorized(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"Bad credentials"}`, http.StatusUnauthorized)
+	}))
+	oldEndpoint := graphqlEndpoint
+	graphqlEndpoint = srv.URL
+	defer func() { graphqlEndpoint = oldEndpoint }()
+
+	_, err := fetchGraphQL(context.Background(), "query { viewer { login } }", nil, "bad-token")
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("fetchGraphQL() error = %v, want ErrUnauthorized", err)
+	}
+
+	srv.Close()
+	_, err = fetchGraphQL(context.Background(), "query { viewer { login } }", nil, "")
+	if exitCode(err) != exitNetwork {
+		t.Errorf("fetchGraphQL() against a closed server: exitCode(%v) = %d, want %d", err, exitCode(err), exitNetwork)
+	}
+}
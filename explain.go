@@ -0,0 +1,302 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// explainConfig describes in plain words what a run with cfg would do. It
+// only looks at the parsed configuration and never touches the network or
+// the filesystem beyond resolving the output path.
+func explainConfig(cfg *Config) (string, error) {
+	outPath, err := explainOutputPath(cfg, cfg.Output, cfg.Owner, cfg.Repo)
+	if err != nil {
+		return "", err
+	}
+
+	releases := "releases"
+	if cfg.Channel != "" {
+		releases = cfg.Channel + " releases"
+	}
+
+	repo := cfg.Owner + "/" + cfg.Repo
+	if len(cfg.Repos) > 0 {
+		slugs := make([]string, len(cfg.Repos))
+		for i, ref := range cfg.Repos {
+			slugs[i] = ref.String()
+		}
+		repo = joinSteps(slugs)
+	}
+
+	var steps []string
+	if cfg.CheckUpdate || cfg.SelfUpdate {
+		steps = []string{fmt.Sprintf("fetch the latest stable release of %s/%s from github.com and compare it to v%s", selfOwner, selfRepo, version)}
+		if cfg.SelfUpdate {
+			steps = append(steps, "if it is newer, download its asset for this OS and architecture and replace the gale binary with it")
+		}
+	} else if cfg.LatestNotes {
+		steps = []string{
+			fmt.Sprintf("fetch the newest stable release of %s/%s", cfg.Owner, cfg.Repo),
+			"print its release notes to stdout",
+		}
+		if cfg.IncludeBodyHTML {
+			steps[1] = "print its release notes, rendered as HTML by GitHub, to stdout"
+		}
+	} else if cfg.Diff != "" {
+		steps = []string{
+			fmt.Sprintf("fetch releases %s and %s of %s/%s", cfg.Diff, cfg.DiffTo, cfg.Owner, cfg.Repo),
+			"print the assets added, removed or resized between them to stdout as JSON",
+		}
+	} else if cfg.Watch {
+		steps = []string{
+			fmt.Sprintf("check %s for new %s every %s", repo, releases, cfg.Interval),
+			"print each one as it appears until interrupted",
+		}
+	} else {
+		switch {
+		case cfg.Latest && cfg.LatestPrerelease:
+			steps = append(steps, fmt.Sprintf("fetch the newest non-draft release of %s", repo))
+		case cfg.Latest:
+			steps = append(steps, fmt.Sprintf("fetch the latest stable release of %s", repo))
+		case cfg.Tag != "":
+			steps = append(steps, fmt.Sprintf("fetch the release tagged %s of %s", cfg.Tag, repo))
+		case cfg.Count == countAll:
+			steps = append(steps, fmt.Sprintf("fetch all %s of %s", releases, repo))
+		default:
+			steps = append(steps, fmt.Sprintf("fetch up to %d %s of %s", cfg.Count, releases, repo))
+		}
+		if cfg.Dedupe {
+			steps = append(steps, "drop releases whose owner/repo and tag repeat an earlier one")
+		}
+		if cfg.MergeRepos != "" {
+			steps = append(steps, fmt.Sprintf("merge in releases from %s, preferring duplicates by %s", cfg.MergeRepos, cfg.MergePrefer))
+		}
+		if cfg.DetectYanked != "" {
+			steps = append(steps, fmt.Sprintf("report releases from %s that no longer exist", cfg.DetectYanked))
+		}
+		if len(cfg.ContentTypes) > 0 {
+			steps = append(steps, fmt.Sprintf("keep only assets of type %s", strings.Join(cfg.ContentTypes, " or ")))
+		}
+		if cfg.DropEmpty {
+			steps = append(steps, "drop releases without assets")
+		}
+		if cfg.MinAssets > 0 {
+			steps = append(steps, fmt.Sprintf("drop releases with fewer than %d assets", cfg.MinAssets))
+		}
+		switch {
+		case cfg.Since != "" && cfg.Until != "":
+			steps = append(steps, fmt.Sprintf("keep releases published between %s and %s", cfg.Since, cfg.Until))
+		case cfg.Since != "":
+			steps = append(steps, fmt.Sprintf("keep releases published since %s", cfg.Since))
+		case cfg.Until != "":
+			steps = append(steps, fmt.Sprintf("keep releases published until %s", cfg.Until))
+		}
+		if cfg.TagFilter != "" {
+			steps = append(steps, fmt.Sprintf("keep tags matching %q", cfg.TagFilter))
+		}
+		if cfg.SemverRange != "" {
+			steps = append(steps, fmt.Sprintf("keep semver tags in %q", cfg.SemverRange))
+		}
+		if cfg.DropNonSemver {
+			steps = append(steps, "drop tags that are not semver")
+		}
+		if cfg.Sort == sortSemver {
+			steps = append(steps, "sort by semantic version, highest first")
+		}
+		if cfg.SortAssets != "" {
+			order := "ascending"
+			if cfg.SortAssetsDesc {
+				order = "descending"
+			}
+			steps = append(steps, fmt.Sprintf("sort the assets of each release by %s, %s", cfg.SortAssets, order))
+		}
+		if cfg.TagRegex != "" {
+			step := fmt.Sprintf("keep tags matching %q grouped by their captured value", cfg.TagRegex)
+			if g, err := newTagGrouper(cfg.TagRegex, cfg.TagGroup); err == nil && g.group < 0 {
+				step = fmt.Sprintf("keep tags matching %q", cfg.TagRegex)
+			}
+			steps = append(steps, step)
+		}
+		if cfg.FailIfEmpty {
+			steps = append(steps, "fail if no releases are left")
+		}
+		format := "JSON"
+		if cfg.Compact {
+			format = "compact JSON"
+		}
+		switch cfg.Format {
+		case formatYAML:
+			format = "YAML"
+		case formatXML:
+			format = "XML"
+		case formatJSONL:
+			format = "one JSON line per release"
+		case formatCSV:
+			format = "CSV with one row per asset"
+		case formatMarkdown:
+			format = "Markdown release notes"
+		case formatTimeseries:
+			format = "a JSON time series"
+		case formatPrometheus:
+			format = "Prometheus release count, size and age gauges"
+		}
+		if cfg.Template != "" {
+			format = "the output of template " + cfg.Template
+		}
+		if cfg.Raw {
+			format = "the unmodified GraphQL responses"
+		}
+		if len(cfg.Fields) > 0 {
+			format += " with only the release fields " + strings.Join(cfg.Fields, ", ")
+		}
+		target := outPath
+		if cfg.Split {
+			first, err := explainOutputPath(cfg, splitOutputPath(cfg.Output, RepoInfo{Owner: cfg.Repos[0].Owner, Repo: cfg.Repos[0].Repo}), cfg.Repos[0].Owner, cfg.Repos[0].Repo)
+			if err != nil {
+				return "", err
+			}
+			if cfg.Encrypt || len(cfg.EncryptTo) > 0 {
+				first = encryptedPath(first)
+			}
+			target = "one file per repository, e.g. " + first
+		} else if cfg.Output == "-" {
+			target = "stdout"
+		} else if cfg.Encrypt || len(cfg.EncryptTo) > 0 {
+			target = encryptedPath(outPath)
+		}
+		if cfg.TUI {
+			steps = append(steps, fmt.Sprintf("open them in an interactive browser that downloads selected assets to %s", cfg.DownloadDir))
+			if cfg.Notes {
+				steps = append(steps, "show the release notes of expanded releases")
+			}
+		} else if cfg.Table {
+			steps = append(steps, "print tag, date, prerelease flag, asset count and total size of each release as a table to stdout")
+			if cfg.Notes {
+				steps = append(steps, "print the release notes under each release")
+			}
+		} else if cfg.Append {
+			steps = append(steps, fmt.Sprintf("merge them into the releases already in %s by release ID and write %s back", target, format))
+		} else if cfg.Encrypt {
+			steps = append(steps, fmt.Sprintf("encrypt %s with a passphrase and write it to %s", format, target))
+		} else if len(cfg.EncryptTo) > 0 {
+			steps = append(steps, fmt.Sprintf("encrypt %s to the age recipients %s and write it to %s", format, strings.Join(cfg.EncryptTo, ", "), target))
+		} else {
+			steps = append(steps, fmt.Sprintf("write %s to %s", format, target))
+		}
+		if cfg.Clipboard {
+			steps = append(steps, "copy it to the clipboard")
+		}
+		if cfg.SummaryJSON {
+			steps = append(steps, "print a one-line JSON summary to stdout")
+		}
+	}
+	if cfg.GSheet != "" {
+		steps = append(steps, fmt.Sprintf("%s asset rows to sheet %q of Google Sheet %s", cfg.GSheetMode, cfg.GSheetSheet, cfg.GSheet))
+	}
+	if cfg.Download {
+		assets := "every asset"
+		if cfg.AssetFilter != "" {
+			assets = fmt.Sprintf("assets matching %q", cfg.AssetFilter)
+		}
+		steps = append(steps, fmt.Sprintf("download %s to %s as %s, %d at a time", assets, cfg.DownloadDir, cfg.DownloadName, cfg.DownloadConcurrency))
+		if cfg.SkipExistingTags {
+			dirTmpl, _ := tagDirTemplate(cfg.DownloadName) // validated by validateConfig
+			steps = append(steps, fmt.Sprintf("skip releases whose %s directory already exists in %s", dirTmpl, cfg.DownloadDir))
+		}
+		if cfg.VerifyChecksums {
+			steps = append(steps, "verify the downloads against published SHA-256 checksums")
+		}
+	}
+	if cfg.Check {
+		steps = append(steps, fmt.Sprintf("verify every asset URL with up to %d concurrent requests", cfg.Concurrency))
+	}
+
+	var notes []string
+	if cfg.Output != "-" && strings.Contains(outPath, "{tag}") && !cfg.Table && !cfg.TUI {
+		notes = append(notes, "{tag} in the output path becomes the tag of the newest release, which is only known after fetching.")
+	}
+	if cfg.AppID != 0 {
+		notes = append(notes, fmt.Sprintf("Requests are authenticated with an installation token of GitHub App %d, installation %d.", cfg.AppID, cfg.InstallationID))
+	} else if cfg.Token == "" {
+		notes = append(notes, "Requests are unauthenticated, so lower rate limits apply.")
+	} else {
+		notes = append(notes, "Requests are authenticated with the provided token.")
+	}
+	if cfg.REST {
+		notes = append(notes, "Releases are read from the REST API instead of GraphQL.")
+	} else if cfg.Strict {
+		notes = append(notes, "Any GraphQL error fails the run, even when the rest of the response is usable.")
+	}
+	if u, err := url.Parse(cfg.Proxy); cfg.Proxy != "" && err == nil {
+		// Redacted hides a password in the proxy URL.
+		notes = append(notes, fmt.Sprintf("Requests and downloads go through the proxy %s.", u.Redacted()))
+	}
+	if cfg.CACert != "" {
+		notes = append(notes, fmt.Sprintf("TLS certificates are also checked against the CA certificates in %s.", cfg.CACert))
+	}
+	if cfg.InsecureSkipVerify {
+		notes = append(notes, "TLS certificates are NOT verified (--insecure-skip-verify).")
+	}
+	switch {
+	case cfg.NoCache || cfg.CacheTTL == 0 || cfg.Watch || cfg.DryRun || cfg.Raw:
+		notes = append(notes, "The release cache is not used.")
+	case cfg.Refresh:
+		notes = append(notes, "Releases are fetched again and the cache is overwritten.")
+	default:
+		notes = append(notes, fmt.Sprintf("Releases cached within the last %s are reused instead of fetched.", cfg.CacheTTL))
+		if cfg.REST {
+			notes = append(notes, "REST requests send the stored ETag, so unchanged pages do not count against the rate limit.")
+		}
+	}
+	if cfg.DryRun {
+		notes = append(notes, "This is a dry run: after fetching, nothing is written, exported or downloaded.")
+	}
+	if cfg.RescueOnWrite {
+		notes = append(notes, "If the file cannot be written, the output is printed to stdout instead.")
+	}
+
+	return fmt.Sprintf("Will %s.\n%s\n", joinSteps(steps), strings.Join(notes, "\n")), nil
+}
+
+// joinSteps joins steps into a sentence: "a", "a and b", "a, b, and c".
+func joinSteps(steps []string) string {
+	switch len(steps) {
+	case 0:
+		return ""
+	case 1:
+		return steps[0]
+	case 2:
+		return steps[0] + " and " + steps[1]
+	}
+	return strings.Join(steps[:len(steps)-1], ", ") + ", and " + steps[len(steps)-1]
+}
+
+// explainOutputPath resolves the --output name as run would for owner/repo,
+// expanding {owner}, {repo} and {date}. {tag} is kept as it is unless --tag
+// names the release, since the newest tag is only known after fetching.
+func explainOutputPath(cfg *Config, name, owner, repo string) (string, error) {
+	if name == "-" {
+		return name, nil
+	}
+	tag := "{tag}"
+	if cfg.Tag != "" {
+		tag = sanitizePathComponent(cfg.Tag)
+	}
+	expanded, err := expandPlaceholders(name, map[string]string{
+		"owner": sanitizePathComponent(owner),
+		"repo":  sanitizePathComponent(repo),
+		"date":  time.Now().Format("2006-01-02"),
+		"tag":   tag,
+	})
+	if err != nil {
+		return "", fmt.Errorf("invalid --output: %w", err)
+	}
+	path, err := filepath.Abs(expanded)
+	if err != nil {
+		return "", fmt.Errorf("could not resolve path %q: %w", expanded, err)
+	}
+	return path, nil
+}
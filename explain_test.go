@@ -0,0 +1,91 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExplainConfig(t *testing.T) {
+	cfg := &Config{
+		Owner:   "microsoft",
+		Repo:    "vscode",
+		Count:   20,
+		Output:  "out.json",
+		Channel: channelStable,
+	}
+
+	got, err := explainConfig(cfg)
+	if err != nil {
+		t.Fatalf("explainConfig() error = %v", err)
+	}
+
+	outPath, _ := filepath.Abs("out.json")
+	for _, want := range []string{
+		"Will fetch up to 20 stable releases of microsoft/vscode and write JSON to " + outPath + ".",
+		"unauthenticated",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("explainConfig() = %q, want it to contain %q", got, want)
+		}
+	}
+}
+
+func TestExplainConfigStdout(t *testing.T) {
+	cfg := &Config{Owner: "cli", Repo: "gh", Count: 5, Output: "-", Format: formatYAML}
+
+	got, err := explainConfig(cfg)
+	if err != nil {
+		t.Fatalf("explainConfig() error = %v", err)
+	}
+	if want := "write YAML to stdout."; !strings.Contains(got, want) {
+		t.Errorf("explainConfig() = %q, want it to contain %q", got, want)
+	}
+}
+
+func TestJoinSteps(t *testing.T) {
+	testCases := []struct {
+		steps    []string
+		expected string
+	}{
+		{[]string{"a"}, "a"},
+		{[]string{"a", "b"}, "a and b"},
+		{[]string{"a", "b", "c"}, "a, b, and c"},
+	}
+
+	for _, tc := range testCases {
+		if got := joinSteps(tc.steps); got != tc.expected {
+			t.Errorf("joinSteps(%v) = %q, want %q", tc.steps, got, tc.expected)
+		}
+	}
+}
+
+func TestExplainConfigOutputPlaceholders(t *testing.T) {
+	cfg := &Config{Owner: "cli", Repo: "gh", Count: 5, Output: "{owner}-{repo}-{date}-{tag}.json"}
+
+	got, err := explainConfig(cfg)
+	if err != nil {
+		t.Fatalf("explainConfig() error = %v", err)
+	}
+	outPath, _ := filepath.Abs("cli-gh-" + time.Now().Format("2006-01-02") + "-{tag}.json")
+	for _, want := range []string{"write JSON to " + outPath + ".", "{tag} in the output path becomes the tag of the newest release"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("explainConfig() = %q, want it to contain %q", got, want)
+		}
+	}
+
+	cfg.Tag = "v2.0.0"
+	got, err = explainConfig(cfg)
+	if err != nil {
+		t.Fatalf("explainConfig() error = %v", err)
+	}
+	if strings.Contains(got, "{tag}") {
+		t.Errorf("explainConfig() with --tag = %q, want the tag filled in", got)
+	}
+
+	cfg.Output = "{version}.json"
+	if _, err := explainConfig(cfg); err == nil {
+		t.Error("explainConfig() with an unknown placeholder: want an error")
+	}
+}
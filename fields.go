@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// fieldAliases lets --fields use the names people know from GitHub for
+// keys gale writes under another name.
+var fieldAliases = map[string]string{
+	"tag": "version",
+}
+
+// releaseFieldNames returns the JSON keys of NormalizedRelease in the order
+// they are written.
+func releaseFieldNames() []string {
+	t := reflect.TypeOf(NormalizedRelease{})
+	names := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// parseFields resolves the --fields names to release keys, rejecting
+// unknown ones with the list of valid names.
+func parseFields(fields []string) ([]string, error) {
+	valid := make(map[string]bool)
+	for _, name := range releaseFieldNames() {
+		valid[name] = true
+	}
+	keys := make([]string, 0, len(fields))
+	for _, f := range fields {
+		key := f
+		if alias, ok := fieldAliases[f]; ok {
+			key = alias
+		}
+		if !valid[key] {
+			return nil, fmt.Errorf("unknown field %q in --fields (valid fields: %s; tag is short for version)", f, strings.Join(releaseFieldNames(), ", "))
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// projectedRelease is a release reduced to the --fields keys, written in
+// the order they were given.
+type projectedRelease struct {
+	keys   []string
+	values map[string]json.RawMessage
+}
+
+func (p projectedRelease) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range p.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(key)
+		buf.Write(name)
+		buf.WriteByte(':')
+		value := p.values[key]
+		if value == nil {
+			// An omitempty field that was empty.
+			value = json.RawMessage("null")
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// projectedOutputFile is OutputFile with its releases projected.
+type projectedOutputFile struct {
+	Metadata   Metadata           `json:"metadata"`
+	Repository RepoInfo           `json:"repository"`
+	Releases   []projectedRelease `json:"releases"`
+	Sources    []RepoInfo         `json:"sources,omitempty"`
+	Groups     []ReleaseGroup     `json:"groups,omitempty"`
+	Yanked     []string           `json:"yanked,omitempty"`
+}
+
+// projectRelease keeps only the keys of r.
+func projectRelease(r NormalizedRelease, keys []string) (projectedRelease, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return projectedRelease{}, fmt.Errorf("failed to marshal release %s: %w", r.Version, err)
+	}
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		return projectedRelease{}, err
+	}
+	return projectedRelease{keys: keys, values: values}, nil
+}
+
+// projectOutput is the projection step of --fields: it returns the document
+// to write for output, which is output itself unless fields are selected.
+func projectOutput(output *OutputFile, fields []string) (interface{}, error) {
+	if len(fields) == 0 {
+		return output, nil
+	}
+	keys, err := parseFields(fields)
+	if err != nil {
+		return nil, err
+	}
+	doc := projectedOutputFile{
+		Metadata:   output.Metadata,
+		Repository: output.Repository,
+		Releases:   make([]projectedRelease, len(output.Releases)),
+		Sources:    output.Sources,
+		Groups:     output.Groups,
+		Yanked:     output.Yanked,
+	}
+	for i, r := range output.Releases {
+		if doc.Releases[i], err = projectRelease(r, keys); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseFields(t *testing.T) {
+	keys, err := parseFields([]string{"tag", "publishedAt", "url"})
+	if err != nil {
+		t.Fatalf("parseFields() error = %v", err)
+	}
+	if strings.Join(keys, ",") != "version,publishedAt,url" {
+		t.Errorf("parseFields() = %v, want [version publishedAt url]", keys)
+	}
+
+	_, err = parseFields([]string{"version", "stars"})
+	if err == nil {
+		t.Fatal("parseFields() with an unknown field succeeded, want an error")
+	}
+	for _, want := range []string{`"stars"`, "version", "publishedAt", "assets"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("parseFields() error = %q, want it to mention %s", err, want)
+		}
+	}
+}
+
+func TestRenderWithFields(t *testing.T) {
+	published := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	output := newOutputFile("cli", "gh", 1, []NormalizedRelease{{
+		Version:     "v1.0.0",
+		Name:        "First",
+		PublishedAt: published,
+		URL:         "https://github.com/cli/gh/releases/tag/v1.0.0",
+		Assets:      []NormalizedAsset{},
+	}})
+	cfg := &Config{Fields: stringList{"url", "tag", "group"}, Compact: true}
+
+	data, err := renderJSON(&output, cfg)
+	if err != nil {
+		t.Fatalf("renderJSON() error = %v", err)
+	}
+	want := `"releases":[{"url":"https://github.com/cli/gh/releases/tag/v1.0.0","version":"v1.0.0","group":null}]`
+	if !strings.Contains(string(data), want) {
+		t.Errorf("renderJSON() = %s, want releases %s", data, want)
+	}
+	if !strings.Contains(string(data), `"metadata":`) {
+		t.Errorf("renderJSON() = %s, want the metadata kept", data)
+	}
+
+	data, err = renderJSONL(&output, cfg)
+	if err != nil {
+		t.Fatalf("renderJSONL() error = %v", err)
+	}
+	if got := strings.TrimSpace(string(data)); got != `{"url":"https://github.com/cli/gh/releases/tag/v1.0.0","version":"v1.0.0","group":null}` {
+		t.Errorf("renderJSONL() = %s", got)
+	}
+
+	data, err = renderYAML(&output, cfg)
+	if err != nil {
+		t.Fatalf("renderYAML() error = %v", err)
+	}
+	if strings.Contains(string(data), "name: First") || !strings.Contains(string(data), "version: v1.0.0") {
+		t.Errorf("renderYAML() = %s, want only the selected fields", data)
+	}
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	formatCSV        = "csv"
+	formatJSON       = "json"
+	formatJSONL      = "jsonl"
+	formatMarkdown   = "markdown"
+	formatPrometheus = "prometheus"
+	formatTimeseries = "timeseries"
+	formatXML        = "xml"
+	formatYAML       = "yaml"
+)
+
+// outputFormats renders the output file for each --format.
+var outputFormats = map[string]func(output *OutputFile, cfg *Config) ([]byte, error){
+	formatCSV:        renderCSV,
+	formatJSON:       renderJSON,
+	formatJSONL:      renderJSONL,
+	formatMarkdown:   renderMarkdown,
+	formatPrometheus: renderPrometheus,
+	formatTimeseries: renderTimeseries,
+	formatXML:        renderXML,
+	formatYAML:       renderYAML,
+}
+
+// formatNames returns the supported --format values, sorted.
+func formatNames() []string {
+	names := make([]string, 0, len(outputFormats))
+	for name := range outputFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func validateFormat(format string) error {
+	if _, ok := outputFormats[format]; !ok {
+		return fmt.Errorf("invalid --format %q (expected %s)", format, strings.Join(formatNames(), ", "))
+	}
+	return nil
+}
+
+// renderJSON writes the output document, indented unless --compact is set.
+func renderJSON(output *OutputFile, cfg *Config) ([]byte, error) {
+	doc, err := projectOutput(output, cfg.Fields)
+	if err != nil {
+		return nil, err
+	}
+	marshal := func(v interface{}) ([]byte, error) { return json.MarshalIndent(v, "", "  ") }
+	if cfg.Compact {
+		marshal = json.Marshal
+	}
+	data, err := marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal output JSON: %w", err)
+	}
+	return data, nil
+}
+
+// renderJSONL writes each release as one compact JSON object per line,
+// without the metadata wrapper, so the output can be streamed or appended.
+// With --fields each object holds only the selected keys.
+func renderJSONL(output *OutputFile, cfg *Config) ([]byte, error) {
+	keys, err := parseFields(cfg.Fields)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, r := range output.Releases {
+		var v interface{} = r
+		if len(keys) > 0 {
+			if v, err = projectRelease(r, keys); err != nil {
+				return nil, err
+			}
+		}
+		if err := enc.Encode(v); err != nil {
+			return nil, fmt.Errorf("failed to marshal release %s: %w", r.Version, err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// renderCSV writes one row per asset, the same rows --gsheet exports, after
+// a header row. encoding/csv quotes fields that contain commas or quotes.
+func renderCSV(output *OutputFile, cfg *Config) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(assetRowHeader); err != nil {
+		return nil, fmt.Errorf("failed to write CSV: %w", err)
+	}
+	if err := w.WriteAll(assetRows(output)); err != nil {
+		return nil, fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// renderMarkdown writes one section per release: the tag as a heading, the
+// publish date, the description unchanged since GitHub already stores it as
+// Markdown, and a table of assets. The metadata goes in a short footer.
+func renderMarkdown(output *OutputFile, cfg *Config) ([]byte, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s/%s releases\n", output.Repository.Owner, output.Repository.Repo)
+
+	for _, r := range output.Releases {
+		fmt.Fprintf(&b, "\n## %s\n\n", r.Version)
+		details := []string{"Published " + r.PublishedAt.UTC().Format("Jan 02, 2006")}
+		if r.Name != "" && r.Name != r.Version {
+			details = append([]string{"**" + r.Name + "**"}, details...)
+		}
+		if r.Source != "" {
+			details = append(details, "from "+r.Source)
+		}
+		if r.IsPrerelease {
+			details = append(details, "prerelease")
+		}
+		fmt.Fprintf(&b, "%s\n", strings.Join(details, " · "))
+
+		if desc := strings.TrimSpace(r.Description); desc != "" {
+			// A file has no terminal width to default to, so only an
+			// explicit --wrap N applies.
+			if cfg.Wrap > 0 {
+				desc = wrapText(desc, cfg.Wrap)
+			}
+			fmt.Fprintf(&b, "\n%s\n", desc)
+		}
+
+		if len(r.Assets) > 0 {
+			b.WriteString("\n| Asset | Size |\n| --- | ---: |\n")
+			for _, a := range r.Assets {
+				fmt.Fprintf(&b, "| [%s](%s) | %s |\n", markdownCell(a.Name), a.DownloadURL, a.SizeFormatted)
+			}
+		}
+	}
+
+	m := output.Metadata
+	fmt.Fprintf(&b, "\n---\n\n_Fetched %s by %s from [%s/%s](%s)._\n", m.FetchedAt, m.FetchedBy, output.Repository.Owner, output.Repository.Repo, output.Repository.URL)
+	return []byte(b.String()), nil
+}
+
+// markdownCell escapes the characters that would end a table cell or a link
+// label early.
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", "\\|", "[", "\\[", "]", "\\]").Replace(s)
+}
+
+// renderYAML converts the JSON document to YAML, so field names, field
+// order and value formatting (RFC 3339 timestamps, "1.2 MB" sizes) are the
+// same in both formats.
+func renderYAML(output *OutputFile, cfg *Config) ([]byte, error) {
+	projected, err := projectOutput(output, cfg.Fields)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(projected)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal output: %w", err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to convert output to YAML: %w", err)
+	}
+	resetYAMLStyle(&doc)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to marshal output YAML: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to marshal output YAML: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// yaml11Bools are plain scalars that YAML 1.1 parsers, still common in
+// Kubernetes tooling, read as booleans although YAML 1.2 does not.
+var yaml11Bools = map[string]bool{
+	"y": true, "yes": true, "n": true, "no": true, "on": true, "off": true,
+}
+
+// resetYAMLStyle drops the flow and quoting styles inherited from JSON so
+// the document is written in block style. The encoder still quotes strings
+// that YAML 1.2 would read as another type; YAML 1.1 booleans are quoted
+// here.
+func resetYAMLStyle(n *yaml.Node) {
+	n.Style = 0
+	if n.Kind == yaml.ScalarNode && n.Tag == "!!str" && yaml11Bools[strings.ToLower(n.Value)] {
+		n.Style = yaml.DoubleQuotedStyle
+	}
+	for _, c := range n.Content {
+		resetYAMLStyle(c)
+	}
+}
+
+// TimeseriesPoint is one release in --format timeseries. The cumulative
+// fields are only set with --cumulative.
+type TimeseriesPoint struct {
+	Date             string `json:"date"`
+	Tag              string `json:"tag"`
+	AssetCount       int    `json:"assetCount"`
+	TotalBytes       int64  `json:"totalBytes"`
+	CumulativeAssets *int   `json:"cumulativeAssets,omitempty"`
+	CumulativeBytes  *int64 `json:"cumulativeBytes,omitempty"`
+}
+
+// timeseries lists the published releases oldest first with their asset
+// count and size. Unpublished drafts have no date and are left out.
+func timeseries(releases []NormalizedRelease, cumulative bool) []TimeseriesPoint {
+	var published []NormalizedRelease
+	for _, r := range releases {
+		if !r.PublishedAt.IsZero() {
+			published = append(published, r)
+		}
+	}
+	sort.SliceStable(published, func(i, j int) bool {
+		return published[i].PublishedAt.Before(published[j].PublishedAt)
+	})
+
+	points := make([]TimeseriesPoint, len(published))
+	var assets int
+	var bytes int64
+	for i, r := range published {
+		p := TimeseriesPoint{
+			Date:       r.PublishedAt.UTC().Format(time.RFC3339),
+			Tag:        r.Version,
+			AssetCount: len(r.Assets),
+		}
+		for _, a := range r.Assets {
+			p.TotalBytes += a.Size
+		}
+		assets += p.AssetCount
+		bytes += p.TotalBytes
+		if cumulative {
+			cumAssets, cumBytes := assets, bytes
+			p.CumulativeAssets, p.CumulativeBytes = &cumAssets, &cumBytes
+		}
+		points[i] = p
+	}
+	return points
+}
+
+// templateFuncs are the functions --template files can call besides the
+// text/template builtins.
+var templateFuncs = template.FuncMap{
+	"formatBytes": formatBytes,
+}
+
+// loadTemplate reads and parses the --template file at path.
+func loadTemplate(path string) (*template.Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template: %w", err)
+	}
+	tmpl, err := template.New(filepath.Base(path)).Funcs(templateFuncs).Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// renderTemplate executes the --template file with output as its data, so
+// the template sees the same fields as the JSON document under their Go
+// names, e.g. {{range .Releases}}{{.Version}}{{end}}.
+func renderTemplate(output *OutputFile, cfg *Config) ([]byte, error) {
+	tmpl, err := loadTemplate(cfg.Template)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, output); err != nil {
+		return nil, fmt.Errorf("failed to render template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func renderTimeseries(output *OutputFile, cfg *Config) ([]byte, error) {
+	data, err := json.Marshal(timeseries(output.Releases, cfg.Cumulative))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal time series: %w", err)
+	}
+	return data, nil
+}
+
+// xmlReaction is one entry of a release's reactions in --format xml, which
+// cannot encode maps.
+type xmlReaction struct {
+	Emoji string `xml:"emoji,attr"`
+	Count int    `xml:",chardata"`
+}
+
+type xmlRelease struct {
+	NormalizedRelease
+	Reactions []xmlReaction `xml:"reactions>reaction,omitempty"`
+}
+
+// xmlOutputFile is the --format xml document: OutputFile with each list
+// written as repeated child elements of a wrapper element.
+type xmlOutputFile struct {
+	XMLName    xml.Name       `xml:"gale"`
+	Metadata   Metadata       `xml:"metadata"`
+	Repository RepoInfo       `xml:"repository"`
+	Releases   []xmlRelease   `xml:"releases>release"`
+	Sources    []RepoInfo     `xml:"sources>source,omitempty"`
+	Groups     []ReleaseGroup `xml:"groups>group,omitempty"`
+	Yanked     []string       `xml:"yanked>tag,omitempty"`
+}
+
+// renderXML writes the output document as indented XML. Timestamps are
+// RFC 3339, as in JSON.
+func renderXML(output *OutputFile, cfg *Config) ([]byte, error) {
+	doc := xmlOutputFile{
+		Metadata:   output.Metadata,
+		Repository: output.Repository,
+		Releases:   make([]xmlRelease, len(output.Releases)),
+		Sources:    output.Sources,
+		Groups:     output.Groups,
+		Yanked:     output.Yanked,
+	}
+	for i, r := range output.Releases {
+		doc.Releases[i].NormalizedRelease = r
+		emoji := make([]string, 0, len(r.Reactions))
+		for e := range r.Reactions {
+			emoji = append(emoji, e)
+		}
+		sort.Strings(emoji)
+		for _, e := range emoji {
+			doc.Releases[i].Reactions = append(doc.Releases[i].Reactions, xmlReaction{Emoji: e, Count: r.Reactions[e]})
+		}
+	}
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal output XML: %w", err)
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
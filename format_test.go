@@ -0,0 +1,301 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestTimeseries(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 12, 0, 0, 0, time.UTC) }
+	assets := func(sizes ...int64) []NormalizedAsset {
+		var out []NormalizedAsset
+		for _, s := range sizes {
+			out = append(out, NormalizedAsset{Size: s})
+		}
+		return out
+	}
+	releases := []NormalizedRelease{
+		{Version: "v3", PublishedAt: day(3), Assets: assets(100)},
+		{Version: "draft"},
+		{Version: "v1", PublishedAt: day(1), Assets: assets(10, 20)},
+		{Version: "v2", PublishedAt: day(2)},
+	}
+
+	points := timeseries(releases, true)
+
+	want := []struct {
+		tag       string
+		assets    int
+		bytes     int64
+		cumAssets int
+		cumBytes  int64
+		date      string
+	}{
+		{"v1", 2, 30, 2, 30, "2024-03-01T12:00:00Z"},
+		{"v2", 0, 0, 2, 30, "2024-03-02T12:00:00Z"},
+		{"v3", 1, 100, 3, 130, "2024-03-03T12:00:00Z"},
+	}
+	if len(points) != len(want) {
+		t.Fatalf("timeseries() returned %d points, want %d", len(points), len(want))
+	}
+	for i, w := range want {
+		p := points[i]
+		if p.Tag != w.tag || p.Date != w.date || p.AssetCount != w.assets || p.TotalBytes != w.bytes {
+			t.Errorf("point %d = %s %s %d assets %d bytes, want %s %s %d assets %d bytes",
+				i, p.Tag, p.Date, p.AssetCount, p.TotalBytes, w.tag, w.date, w.assets, w.bytes)
+		}
+		if *p.CumulativeAssets != w.cumAssets || *p.CumulativeBytes != w.cumBytes {
+			t.Errorf("point %d cumulative = %d assets %d bytes, want %d assets %d bytes",
+				i, *p.CumulativeAssets, *p.CumulativeBytes, w.cumAssets, w.cumBytes)
+		}
+	}
+}
+
+func TestTimeseriesOmitsCumulative(t *testing.T) {
+	releases := []NormalizedRelease{{Version: "v1", PublishedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}}
+
+	data, err := json.Marshal(timeseries(releases, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"date":"2024-03-01T00:00:00Z","tag":"v1","assetCount":0,"totalBytes":0}]`
+	if string(data) != want {
+		t.Errorf("timeseries() = %s, want %s", data, want)
+	}
+}
+
+func TestRenderYAML(t *testing.T) {
+	output := newOutputFile("Typeflu", "gale", 1, []NormalizedRelease{{
+		Name:        "yes",
+		Version:     "v1.0",
+		PublishedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Assets:      []NormalizedAsset{{Name: "gale.zip", Size: 1234, SizeFormatted: "1.2 KB"}},
+	}})
+
+	data, err := renderYAML(&output, &Config{})
+	if err != nil {
+		t.Fatalf("renderYAML() error = %v", err)
+	}
+
+	for _, want := range []string{
+		"\n  - id: \"\"\n    name: \"yes\"\n    version: v1.0\n",
+		`    publishedAt: "2024-01-02T03:04:05Z"`,
+		"        sizeFormatted: 1.2 KB\n",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("renderYAML() output does not contain %q:\n%s", want, data)
+		}
+	}
+
+	var decoded OutputFile
+	if err := yaml.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("yaml.Unmarshal() error = %v", err)
+	}
+}
+
+func TestRenderXML(t *testing.T) {
+	output := newOutputFile("Typeflu", "gale", 1, []NormalizedRelease{{
+		Name:        "R&D",
+		Version:     "v1.0",
+		PublishedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Reactions:   map[string]int{"🚀": 3, "👍": 2},
+		Assets: []NormalizedAsset{
+			{Name: "gale.zip", Size: 1234, SizeFormatted: "1.2 KB"},
+			{Name: "gale.tar.gz", Size: 99},
+		},
+	}})
+
+	data, err := renderXML(&output, &Config{})
+	if err != nil {
+		t.Fatalf("renderXML() error = %v", err)
+	}
+
+	got := string(data)
+	for _, want := range []string{
+		"<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<gale>\n",
+		"<owner>Typeflu</owner>",
+		"<releases>\n    <release>\n",
+		"<name>R&amp;D</name>",
+		"<publishedAt>2024-01-02T03:04:05Z</publishedAt>",
+		"<asset>\n          <id></id>\n          <name>gale.zip</name>",
+		"<name>gale.tar.gz</name>",
+		"<reaction emoji=\"👍\">2</reaction>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("renderXML() output does not contain %q:\n%s", want, got)
+		}
+	}
+
+	var decoded xmlOutputFile
+	if err := xml.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("xml.Unmarshal() error = %v", err)
+	}
+	if len(decoded.Releases) != 1 || len(decoded.Releases[0].Assets) != 2 || !decoded.Releases[0].PublishedAt.Equal(output.Releases[0].PublishedAt) {
+		t.Errorf("renderXML() does not read back: %+v", decoded.Releases)
+	}
+}
+
+func TestRenderCSV(t *testing.T) {
+	output := newOutputFile("cli", "gh", 1, []NormalizedRelease{{
+		Version:     "v2.0.0",
+		Name:        "Release 2, the big one",
+		PublishedAt: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		Assets: []NormalizedAsset{{
+			Name:          "gh.tar.gz",
+			Size:          2048,
+			SizeFormatted: "2.0 KB",
+			ContentType:   "application/gzip",
+			DownloadURL:   "https://example.com/gh.tar.gz",
+		}},
+	}})
+
+	data, err := renderCSV(&output, &Config{})
+	if err != nil {
+		t.Fatalf("renderCSV() error = %v", err)
+	}
+	want := "repo,tag,name,publishedAt,prerelease,asset,sizeBytes,sizeFormatted,contentType,downloadUrl\n" +
+		`cli/gh,v2.0.0,"Release 2, the big one",2024-03-01T12:00:00Z,false,gh.tar.gz,2048,2.0 KB,application/gzip,https://example.com/gh.tar.gz` + "\n"
+	if string(data) != want {
+		t.Errorf("renderCSV() = %q, want %q", data, want)
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	output := newOutputFile("cli", "gh", 1, []NormalizedRelease{{
+		Version:      "v2.0.0",
+		Name:         "GitHub CLI 2.0",
+		PublishedAt:  time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		IsPrerelease: true,
+		Description:  "## Highlights\n\n* Faster `gh pr list`\n",
+		Assets: []NormalizedAsset{{
+			Name:          "gh|linux.tar.gz",
+			SizeFormatted: "2.0 KB",
+			DownloadURL:   "https://example.com/gh.tar.gz",
+		}},
+	}})
+	output.Metadata.FetchedAt = "2024-03-02T00:00:00Z"
+
+	data, err := renderMarkdown(&output, &Config{})
+	if err != nil {
+		t.Fatalf("renderMarkdown() error = %v", err)
+	}
+	got := string(data)
+	for _, want := range []string{
+		"# cli/gh releases\n\n## v2.0.0\n\n**GitHub CLI 2.0** · Published Mar 01, 2024 · prerelease\n",
+		"\n## Highlights\n\n* Faster `gh pr list`\n",
+		"| [gh\\|linux.tar.gz](https://example.com/gh.tar.gz) | 2.0 KB |\n",
+		"_Fetched 2024-03-02T00:00:00Z by gale v" + version + " from [cli/gh](https://github.com/cli/gh)._\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("renderMarkdown() = %q, want it to contain %q", got, want)
+		}
+	}
+}
+
+func TestRenderJSONCompact(t *testing.T) {
+	output := newOutputFile("cli", "gh", 1, []NormalizedRelease{{Version: "v1.0.0", Assets: []NormalizedAsset{}}})
+
+	pretty, err := renderJSON(&output, &Config{})
+	if err != nil {
+		t.Fatalf("renderJSON() error = %v", err)
+	}
+	compact, err := renderJSON(&output, &Config{Compact: true})
+	if err != nil {
+		t.Fatalf("renderJSON() with Compact error = %v", err)
+	}
+	if !strings.Contains(string(pretty), "\n  ") {
+		t.Errorf("renderJSON() = %q, want indented JSON by default", pretty)
+	}
+	if strings.Contains(string(compact), "\n") || len(compact) >= len(pretty) {
+		t.Errorf("renderJSON() with Compact = %q, want a single shorter line", compact)
+	}
+	var back OutputFile
+	if err := json.Unmarshal(compact, &back); err != nil || back.Releases[0].Version != "v1.0.0" {
+		t.Errorf("compact output does not read back: %v", err)
+	}
+}
+
+func TestRenderJSONL(t *testing.T) {
+	output := newOutputFile("cli", "gh", 2, []NormalizedRelease{
+		{Version: "v2.0.0", Assets: []NormalizedAsset{}},
+		{Version: "v1.0.0", Assets: []NormalizedAsset{}},
+	})
+
+	data, err := renderJSONL(&output, &Config{})
+	if err != nil {
+		t.Fatalf("renderJSONL() error = %v", err)
+	}
+	lines := strings.Split(string(data), "\n")
+	if len(lines) != 3 || lines[2] != "" {
+		t.Fatalf("renderJSONL() = %q, want two newline-terminated lines", data)
+	}
+	for i, want := range []string{"v2.0.0", "v1.0.0"} {
+		var r NormalizedRelease
+		if err := json.Unmarshal([]byte(lines[i]), &r); err != nil || r.Version != want {
+			t.Errorf("line %d = %q (%v), want release %s", i, lines[i], err, want)
+		}
+		if strings.Contains(lines[i], "  ") {
+			t.Errorf("line %d = %q, want compact JSON", i, lines[i])
+		}
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "changelog.tmpl")
+	tmpl := "{{.Repository.Owner}}/{{.Repository.Repo}}\n{{range .Releases}}- {{.Version}}{{range .Assets}} {{.Name}} ({{formatBytes .Size}}){{end}}\n{{end}}"
+	if err := os.WriteFile(path, []byte(tmpl), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	output := newOutputFile("cli", "gh", 2, []NormalizedRelease{
+		{Version: "v2.0.0", Assets: []NormalizedAsset{{Name: "gh.deb", Size: 2048}}},
+		{Version: "v1.0.0"},
+	})
+
+	got, err := renderTemplate(&output, &Config{Format: formatYAML, Template: path})
+	if err != nil {
+		t.Fatalf("renderTemplate() error = %v", err)
+	}
+	if want := "cli/gh\n- v2.0.0 gh.deb (2.0 KB)\n- v1.0.0\n"; string(got) != want {
+		t.Errorf("renderTemplate() = %q, want %q", got, want)
+	}
+
+	bad := filepath.Join(dir, "bad.tmpl")
+	if err := os.WriteFile(bad, []byte("{{.Releases"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadTemplate(bad); err == nil {
+		t.Error("loadTemplate() of an unterminated action succeeded, want error")
+	}
+}
+
+func TestRenderMarkdownWrap(t *testing.T) {
+	output := newOutputFile("cli", "gh", 1, []NormalizedRelease{{
+		Version:     "v2.0.0",
+		Description: "Pull request listing is much faster on large repositories now.",
+	}})
+
+	for _, tc := range []struct {
+		wrap int
+		want string
+	}{
+		{0, "\nPull request listing is much faster on large repositories now.\n"},
+		{-1, "\nPull request listing is much faster on large repositories now.\n"},
+		{30, "\nPull request listing is much\nfaster on large repositories\nnow.\n"},
+	} {
+		data, err := renderMarkdown(&output, &Config{Wrap: tc.wrap})
+		if err != nil {
+			t.Fatalf("renderMarkdown() error = %v", err)
+		}
+		if !strings.Contains(string(data), tc.want) {
+			t.Errorf("renderMarkdown() with --wrap %d = %q, want it to contain %q", tc.wrap, data, tc.want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ghHost is one entry of the gh CLI's hosts.yml. Since gh 2.40 a host may
+// list several accounts under users, with user naming the active one.
+type ghHost struct {
+	OAuthToken string `yaml:"oauth_token"`
+	User       string `yaml:"user"`
+	Users      map[string]struct {
+		OAuthToken string `yaml:"oauth_token"`
+	} `yaml:"users"`
+}
+
+// ghConfigDir returns where the gh CLI keeps its configuration, following
+// the same lookup order as gh itself.
+func ghConfigDir() (string, error) {
+	if dir := os.Getenv("GH_CONFIG_DIR"); dir != "" {
+		return dir, nil
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "gh"), nil
+	}
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv("AppData"); dir != "" {
+			return filepath.Join(dir, "GitHub CLI"), nil
+		}
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "gh"), nil
+}
+
+// ghHostname maps an API base URL to the host name gh stores it under:
+// github.com for the public API, the server's host for Enterprise.
+func ghHostname(apiURL string) string {
+	u, err := url.Parse(apiURL)
+	if apiURL == "" || err != nil || u.Hostname() == "api.github.com" {
+		return "github.com"
+	}
+	return u.Hostname()
+}
+
+// ghHostsToken reads the token for host from the hosts.yml at path. Tokens
+// that gh keeps in the system keyring are not in the file and cannot be
+// found this way.
+func ghHostsToken(path, host string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	var hosts map[string]ghHost
+	if err := yaml.Unmarshal(data, &hosts); err != nil {
+		return "", fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	h, ok := hosts[host]
+	if !ok {
+		return "", fmt.Errorf("%s has no entry for %s", path, host)
+	}
+	if h.OAuthToken != "" {
+		return h.OAuthToken, nil
+	}
+	if token := h.Users[h.User].OAuthToken; token != "" {
+		return token, nil
+	}
+	return "", fmt.Errorf("%s stores no token for %s (gh may keep it in the system keyring)", path, host)
+}
+
+// ghToken returns the token the gh CLI is logged in with for apiURL.
+func ghToken(apiURL string) (string, error) {
+	dir, err := ghConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return ghHostsToken(filepath.Join(dir, "hosts.yml"), ghHostname(apiURL))
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGHHostname(t *testing.T) {
+	testCases := []struct {
+		apiURL   string
+		expected string
+	}{
+		{"", "github.com"},
+		{"https://api.github.com", "github.com"},
+		{"https://github.example.com/api/v3", "github.example.com"},
+	}
+
+	for _, tc := range testCases {
+		if got := ghHostname(tc.apiURL); got != tc.expected {
+			t.Errorf("ghHostname(%q) = %q, want %q", tc.apiURL, got, tc.expected)
+		}
+	}
+}
+
+func TestGHHostsToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hosts.yml")
+	hosts := `github.com:
+    oauth_token: gho_single
+    user: alice
+    git_protocol: https
+github.example.com:
+    user: bob
+    users:
+        bob:
+            oauth_token: gho_enterprise
+keyring.example.com:
+    user: carol
+    users:
+        carol:
+`
+	if err := os.WriteFile(path, []byte(hosts), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		host     string
+		expected string
+		wantErr  bool
+	}{
+		{"github.com", "gho_single", false},
+		{"github.example.com", "gho_enterprise", false},
+		{"keyring.example.com", "", true},
+		{"missing.example.com", "", true},
+	}
+
+	for _, tc := range testCases {
+		got, err := ghHostsToken(path, tc.host)
+		if (err != nil) != tc.wantErr || got != tc.expected {
+			t.Errorf("ghHostsToken(%q) = %q, %v, want %q (error: %v)", tc.host, got, err, tc.expected, tc.wantErr)
+		}
+	}
+}
+
+func TestGHToken(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "hosts.yml"), []byte("github.com:\n    oauth_token: gho_env\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GH_CONFIG_DIR", dir)
+
+	got, err := ghToken("")
+	if err != nil || got != "gho_env" {
+		t.Errorf("ghToken() = %q, %v, want gho_env", got, err)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// appJWT returns the short-lived JWT a GitHub App authenticates as itself
+// with. It is backdated a minute to allow for clock drift, and GitHub
+// rejects expiry times more than ten minutes out.
+func appJWT(appID int64, keyPEM []byte, now time.Time) (string, error) {
+	key, err := parseRSAPrivateKey(keyPEM)
+	if err != nil {
+		return "", fmt.Errorf("invalid GitHub App private key: %w", err)
+	}
+	return signJWT(key, map[string]interface{}{
+		"iss": strconv.FormatInt(appID, 10),
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+	})
+}
+
+// installationToken mints an installation access token for the GitHub App
+// appID, whose private key is the PEM file at keyPath. The token is good
+// for an hour and is used like a personal access token.
+func installationToken(ctx context.Context, appID int64, keyPath string, installationID int64) (string, error) {
+	keyPEM, err := os.ReadFile(keyPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read GitHub App private key: %w", err)
+	}
+	jwt, err := appJWT(appID, keyPEM, time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	endpoint := fmt.Sprintf("%s/app/installations/%d/access_tokens", restAPIURL, installationID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Authorization", "Bearer "+jwt)
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrNetwork, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusCreated {
+		resBody, _ := io.ReadAll(res.Body)
+		return "", fmt.Errorf("failed to create an installation token for installation %d (status %d): %s", installationID, res.StatusCode, redactSecrets(string(resBody), jwt))
+	}
+	var token struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to decode installation token response: %w", err)
+	}
+	if token.Token == "" {
+		return "", fmt.Errorf("GitHub returned no token for installation %d", installationID)
+	}
+	return token.Token, nil
+}
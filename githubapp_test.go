@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInstallationToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPath := filepath.Join(t.TempDir(), "app.pem")
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := os.WriteFile(keyPath, keyPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/app/installations/42/access_tokens" {
+			http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+			return
+		}
+		parts := strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), ".")
+		if len(parts) != 3 {
+			t.Errorf("Authorization = %q, want a bearer JWT", r.Header.Get("Authorization"))
+			return
+		}
+		sig, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], sig); err != nil {
+			t.Errorf("JWT signature does not verify: %v", err)
+		}
+		payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+		var claims struct {
+			Iss string `json:"iss"`
+			Iat int64  `json:"iat"`
+			Exp int64  `json:"exp"`
+		}
+		if err := json.Unmarshal(payload, &claims); err != nil || claims.Iss != "7" || claims.Exp-claims.Iat > 600 {
+			t.Errorf("JWT claims = %+v (%v), want iss 7 and at most ten minutes of validity", claims, err)
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"token":"ghs_installation","expires_at":"2030-01-01T00:00:00Z"}`))
+	}))
+	defer srv.Close()
+	oldREST := restAPIURL
+	restAPIURL = srv.URL
+	defer func() { restAPIURL = oldREST }()
+
+	token, err := installationToken(context.Background(), 7, keyPath, 42)
+	if err != nil {
+		t.Fatalf("installationToken() error = %v", err)
+	}
+	if token != "ghs_installation" {
+		t.Errorf("installationToken() = %q, want ghs_installation", token)
+	}
+
+	if _, err := installationToken(context.Background(), 7, keyPath, 43); err == nil || !strings.Contains(err.Error(), "installation 43") {
+		t.Errorf("installationToken() for an unknown installation error = %v, want it to name the installation", err)
+	}
+}
+
+func TestParseArgsGitHubApp(t *testing.T) {
+	t.Setenv("HOME", t.TempDir()) // keep a ~/.galerc out of the defaults
+	t.Setenv("GITHUB_TOKEN_FILE", "")
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+	oldFlagSet := flag.CommandLine
+	defer func() { flag.CommandLine = oldFlagSet }()
+
+	testCases := []struct {
+		args    []string
+		wantErr bool
+	}{
+		{[]string{"cmd", "--app-id", "7", "--app-private-key", "app.pem", "--installation-id", "42"}, false},
+		{[]string{"cmd", "--app-id", "7"}, true},
+		{[]string{"cmd", "--app-id", "7", "--app-private-key", "app.pem"}, true},
+		{[]string{"cmd", "--installation-id", "42"}, true},
+		{[]string{"cmd", "--app-id", "7", "--app-private-key", "app.pem", "--installation-id", "42", "--token", "ghp_x"}, true},
+	}
+
+	for _, tc := range testCases {
+		flag.CommandLine = flag.NewFlagSet("test", flag.ExitOnError)
+		os.Args = tc.args
+		if _, err := parseArgs(); (err != nil) != tc.wantErr {
+			t.Errorf("parseArgs(%v) error = %v, wantErr %v", tc.args[1:], err, tc.wantErr)
+		}
+	}
+}
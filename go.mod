@@ -3,13 +3,16 @@ module gale
 go 1.25.0
 
 require (
+	filippo.io/age v1.2.1
 	github.com/briandowns/spinner v1.23.2
 	github.com/fatih/color v1.18.0
+	golang.org/x/term v0.21.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
+	golang.org/x/crypto v0.24.0 // indirect
 	golang.org/x/sys v0.25.0 // indirect
-	golang.org/x/term v0.1.0 // indirect
 )
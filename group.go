@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// ReleaseGroup lists the tags whose --tag-regex capture produced Key.
+type ReleaseGroup struct {
+	Key      string   `json:"key" xml:"key"`
+	Releases []string `json:"releases" xml:"releases>tag"`
+}
+
+// tagGrouper filters releases by a tag regex and, when the regex has a named
+// capture group, groups them by it.
+type tagGrouper struct {
+	re    *regexp.Regexp
+	group int // -1 when the regex only filters
+}
+
+// newTagGrouper compiles pattern and resolves the capture group called name,
+// or the first named group when name is empty. A pattern without named
+// groups only filters.
+func newTagGrouper(pattern, name string) (*tagGrouper, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --tag-regex %q: %w", pattern, err)
+	}
+
+	for i, n := range re.SubexpNames() {
+		if n != "" && (name == "" || n == name) {
+			return &tagGrouper{re: re, group: i}, nil
+		}
+	}
+	if name == "" {
+		return &tagGrouper{re: re, group: -1}, nil
+	}
+	return nil, fmt.Errorf("--tag-regex %q has no capture group named %q", pattern, name)
+}
+
+// apply drops releases whose tag does not match. When grouping, it sets
+// Group on the rest and orders them by group key, highest first; releases
+// keep their relative order within a group.
+func (g *tagGrouper) apply(releases []NormalizedRelease) ([]NormalizedRelease, []ReleaseGroup) {
+	kept := releases[:0]
+	for _, r := range releases {
+		m := g.re.FindStringSubmatch(r.Version)
+		if m == nil {
+			continue
+		}
+		if g.group >= 0 {
+			r.Group = m[g.group]
+		}
+		kept = append(kept, r)
+	}
+	if g.group < 0 {
+		return kept, nil
+	}
+
+	sort.SliceStable(kept, func(i, j int) bool {
+		return compareNatural(kept[i].Group, kept[j].Group) > 0
+	})
+
+	var groups []ReleaseGroup
+	for _, r := range kept {
+		if len(groups) == 0 || groups[len(groups)-1].Key != r.Group {
+			groups = append(groups, ReleaseGroup{Key: r.Group})
+		}
+		last := &groups[len(groups)-1]
+		last.Releases = append(last.Releases, r.Version)
+	}
+	return kept, groups
+}
+
+// validateTagFilter checks that pattern is a valid --tag-filter glob.
+func validateTagFilter(pattern string) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid --tag-filter %q: %w", pattern, err)
+	}
+	return nil
+}
+
+// filterTags keeps the releases whose tag matches the glob pattern, using
+// path.Match syntax: * and ? do not match "/".
+func filterTags(releases []NormalizedRelease, pattern string) []NormalizedRelease {
+	kept := releases[:0]
+	for _, r := range releases {
+		if ok, _ := path.Match(pattern, r.Version); ok {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+// compareNatural compares strings so that runs of digits compare by numeric
+// value: "2" < "10" and "2024.2" < "2024.10".
+func compareNatural(a, b string) int {
+	for a != "" && b != "" {
+		ra, rest := splitRun(a)
+		rb, restB := splitRun(b)
+		a, b = rest, restB
+
+		na, errA := strconv.ParseUint(ra, 10, 64)
+		nb, errB := strconv.ParseUint(rb, 10, 64)
+		switch {
+		case errA == nil && errB == nil && na != nb:
+			if na < nb {
+				return -1
+			}
+			return 1
+		case ra < rb:
+			return -1
+		case ra > rb:
+			return 1
+		}
+	}
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return -1
+	}
+	return 1
+}
+
+// splitRun returns the leading run of digits or non-digits of s and the rest.
+func splitRun(s string) (string, string) {
+	isDigit := func(c byte) bool { return c >= '0' && c <= '9' }
+	i := 1
+	for i < len(s) && isDigit(s[i]) == isDigit(s[0]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTagGrouper(t *testing.T) {
+	testCases := []struct {
+		name     string
+		pattern  string
+		group    string
+		tags     []string
+		expected []ReleaseGroup
+	}{
+		{
+			name:    "Semver major",
+			pattern: `^v(?P<major>\d+)\.`,
+			tags:    []string{"v2.1.0", "v10.0.0", "v2.0.0", "nightly", "v1.9.0"},
+			expected: []ReleaseGroup{
+				{Key: "10", Releases: []string{"v10.0.0"}},
+				{Key: "2", Releases: []string{"v2.1.0", "v2.0.0"}},
+				{Key: "1", Releases: []string{"v1.9.0"}},
+			},
+		},
+		{
+			name:    "Date based",
+			pattern: `^(?P<year>\d{4})\.(?P<month>\d{2})`,
+			group:   "year",
+			tags:    []string{"2025.01.2", "2024.12.0", "2025.01.1", "2024.11.3"},
+			expected: []ReleaseGroup{
+				{Key: "2025", Releases: []string{"2025.01.2", "2025.01.1"}},
+				{Key: "2024", Releases: []string{"2024.12.0", "2024.11.3"}},
+			},
+		},
+		{
+			name:    "Component prefix",
+			pattern: `^(?P<component>[a-z]+)-v`,
+			tags:    []string{"cli-v1.0.0", "api-v2.0.0", "cli-v0.9.0"},
+			expected: []ReleaseGroup{
+				{Key: "cli", Releases: []string{"cli-v1.0.0", "cli-v0.9.0"}},
+				{Key: "api", Releases: []string{"api-v2.0.0"}},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			grouper, err := newTagGrouper(tc.pattern, tc.group)
+			if err != nil {
+				t.Fatalf("newTagGrouper() error = %v", err)
+			}
+			var releases []NormalizedRelease
+			for _, tag := range tc.tags {
+				releases = append(releases, NormalizedRelease{Version: tag})
+			}
+
+			kept, groups := grouper.apply(releases)
+			if !reflect.DeepEqual(groups, tc.expected) {
+				t.Errorf("apply() groups = %+v, want %+v", groups, tc.expected)
+			}
+			for _, r := range kept {
+				if r.Group == "" {
+					t.Errorf("release %s has no group", r.Version)
+				}
+			}
+		})
+	}
+}
+
+func TestNewTagGrouperErrors(t *testing.T) {
+	testCases := []struct {
+		name    string
+		pattern string
+		group   string
+	}{
+		{"Invalid regex", `^v(`, ""},
+		{"Missing named group", `^v(?P<major>\d+)`, "minor"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := newTagGrouper(tc.pattern, tc.group); err == nil {
+				t.Errorf("newTagGrouper(%q, %q) should fail", tc.pattern, tc.group)
+			}
+		})
+	}
+}
+
+func TestTagFilters(t *testing.T) {
+	tags := []string{"v2.1.0", "v10.0.0", "v2.0.0", "cli-v2.0.0", "v1.9.0"}
+	want := []string{"v2.1.0", "v2.0.0"}
+
+	versions := func(releases []NormalizedRelease) []string {
+		var out []string
+		for _, r := range releases {
+			out = append(out, r.Version)
+		}
+		return out
+	}
+	releases := func() []NormalizedRelease {
+		var out []NormalizedRelease
+		for _, tag := range tags {
+			out = append(out, NormalizedRelease{Version: tag})
+		}
+		return out
+	}
+
+	if got := versions(filterTags(releases(), "v2.*")); !reflect.DeepEqual(got, want) {
+		t.Errorf("filterTags(v2.*) = %v, want %v", got, want)
+	}
+
+	grouper, err := newTagGrouper(`^v2\.`, "")
+	if err != nil {
+		t.Fatalf("newTagGrouper() error = %v", err)
+	}
+	kept, groups := grouper.apply(releases())
+	if got := versions(kept); !reflect.DeepEqual(got, want) || groups != nil {
+		t.Errorf("apply(^v2\\.) = %v, %v, want %v without groups", got, groups, want)
+	}
+
+	if err := validateTagFilter("v[2"); err == nil {
+		t.Error("validateTagFilter(v[2) should fail")
+	}
+}
+
+func TestCompareNatural(t *testing.T) {
+	testCases := []struct {
+		a, b     string
+		expected int
+	}{
+		{"2", "10", -1},
+		{"2024.10", "2024.2", 1},
+		{"abc", "abd", -1},
+		{"v1", "v1", 0},
+		{"v1", "v1.0", -1},
+	}
+
+	for _, tc := range testCases {
+		if got := compareNatural(tc.a, tc.b); got != tc.expected {
+			t.Errorf("compareNatural(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.expected)
+		}
+	}
+}
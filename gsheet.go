@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	gsheetModeAppend    = "append"
+	gsheetModeOverwrite = "overwrite"
+
+	sheetsScope = "https://www.googleapis.com/auth/spreadsheets"
+)
+
+var sheetsAPIURL = "https://sheets.googleapis.com/v4"
+
+// assetRowHeader names the columns produced by assetRows.
+var assetRowHeader = []string{
+	"repo", "tag", "name", "publishedAt", "prerelease",
+	"asset", "sizeBytes", "sizeFormatted", "contentType", "downloadUrl",
+}
+
+// assetRows flattens output into one row per asset. Releases without assets
+// still get a row with the asset columns left empty.
+func assetRows(output *OutputFile) [][]string {
+	repo := output.Repository.Owner + "/" + output.Repository.Repo
+	var rows [][]string
+	for _, r := range output.Releases {
+		source := repo
+		if r.Source != "" {
+			source = r.Source
+		}
+		release := []string{source, r.Version, r.Name, r.PublishedAt.UTC().Format(time.RFC3339), strconv.FormatBool(r.IsPrerelease)}
+		if len(r.Assets) == 0 {
+			rows = append(rows, append(release, "", "", "", "", ""))
+			continue
+		}
+		for _, a := range r.Assets {
+			row := append(append([]string(nil), release...),
+				a.Name, strconv.FormatInt(a.Size, 10), a.SizeFormatted, a.ContentType, a.DownloadURL)
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// serviceAccount is the subset of a Google service account key file that is
+// needed to mint access tokens.
+type serviceAccount struct {
+	Type        string `json:"type"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// loadServiceAccount reads the key file named by GOOGLE_APPLICATION_CREDENTIALS.
+func loadServiceAccount() (*serviceAccount, error) {
+	path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	if path == "" {
+		return nil, errors.New("GOOGLE_APPLICATION_CREDENTIALS is not set; point it at a service account key file")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Google credentials: %w", err)
+	}
+	var sa serviceAccount
+	if err := json.Unmarshal(data, &sa); err != nil {
+		return nil, fmt.Errorf("failed to parse Google credentials %s: %w", path, err)
+	}
+	if sa.Type != "service_account" || sa.ClientEmail == "" || sa.PrivateKey == "" {
+		return nil, fmt.Errorf("%s is not a service account key file", path)
+	}
+	if sa.TokenURI == "" {
+		sa.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+	return &sa, nil
+}
+
+// accessToken exchanges a signed JWT assertion for an OAuth access token.
+func (sa *serviceAccount) accessToken(ctx context.Context, scope string) (string, error) {
+	key, err := parseRSAPrivateKey([]byte(sa.PrivateKey))
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	assertion, err := signJWT(key, map[string]interface{}{
+		"iss":   sa.ClientEmail,
+		"scope": scope,
+		"aud":   sa.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sa.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := doGoogleRequest(req, &token); err != nil {
+		return "", fmt.Errorf("failed to obtain Google access token: %w", err)
+	}
+	return token.AccessToken, nil
+}
+
+// exportToSheet writes output's asset rows to sheet in the spreadsheet
+// spreadsheetID. Overwrite clears the sheet and writes a header row first;
+// append adds the rows after the existing data.
+func exportToSheet(ctx context.Context, output *OutputFile, spreadsheetID, sheet, mode string) error {
+	sa, err := loadServiceAccount()
+	if err != nil {
+		return err
+	}
+	token, err := sa.accessToken(ctx, sheetsScope)
+	if err != nil {
+		return err
+	}
+
+	rows := assetRows(output)
+	base := fmt.Sprintf("%s/spreadsheets/%s/values/", sheetsAPIURL, url.PathEscape(spreadsheetID))
+	sheetRange := url.PathEscape("'" + strings.ReplaceAll(sheet, "'", "''") + "'")
+
+	if mode == gsheetModeOverwrite {
+		if err := sheetsCall(ctx, token, http.MethodPost, base+sheetRange+":clear", nil); err != nil {
+			return fmt.Errorf("failed to clear sheet %q: %w", sheet, err)
+		}
+		rows = append([][]string{assetRowHeader}, rows...)
+		return sheetsCall(ctx, token, http.MethodPut, base+sheetRange+"?valueInputOption=RAW", rows)
+	}
+	return sheetsCall(ctx, token, http.MethodPost, base+sheetRange+":append?valueInputOption=RAW&insertDataOption=INSERT_ROWS", rows)
+}
+
+func sheetsCall(ctx context.Context, token, method, endpoint string, rows [][]string) error {
+	payload := map[string]interface{}{}
+	if rows != nil {
+		payload["values"] = rows
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal sheet values: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create Sheets request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	return doGoogleRequest(req, nil)
+}
+
+// doGoogleRequest sends req with the shared client and decodes a JSON
+// response into out when it is non-nil.
+func doGoogleRequest(req *http.Request, out interface{}) error {
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := res.Body.Close(); closeErr != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to close response body: %v\n", closeErr)
+		}
+	}()
+
+	if res.StatusCode >= 400 {
+		resBody, _ := io.ReadAll(res.Body)
+		token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+		return fmt.Errorf("Google API responded with status %d: %s", res.StatusCode, redactSecrets(string(resBody), token))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode Google API response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAssetRows(t *testing.T) {
+	output := newOutputFile("acme", "app", 2, []NormalizedRelease{
+		{
+			Version:     "v1.0.0",
+			Name:        "First",
+			PublishedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			Assets: []NormalizedAsset{
+				{Name: "app.zip", Size: 1536, SizeFormatted: "1.5 KB", ContentType: "application/zip", DownloadURL: "https://example.com/app.zip"},
+			},
+		},
+		{Version: "v0.9.0", Name: "Empty", IsPrerelease: true},
+	})
+
+	expected := [][]string{
+		{"acme/app", "v1.0.0", "First", "2025-01-01T00:00:00Z", "false", "app.zip", "1536", "1.5 KB", "application/zip", "https://example.com/app.zip"},
+		{"acme/app", "v0.9.0", "Empty", "0001-01-01T00:00:00Z", "true", "", "", "", "", ""},
+	}
+	if got := assetRows(&output); !reflect.DeepEqual(got, expected) {
+		t.Errorf("assetRows() = %v, want %v", got, expected)
+	}
+	for _, row := range expected {
+		if len(row) != len(assetRowHeader) {
+			t.Errorf("row has %d columns, header has %d", len(row), len(assetRowHeader))
+		}
+	}
+}
+
+func TestExportToSheet(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type call struct {
+		method string
+		path   string
+		rows   int
+	}
+	var calls []call
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if r.FormValue("assertion") == "" {
+				t.Error("token request has no assertion")
+			}
+			w.Write([]byte(`{"access_token":"sheet-token"}`))
+			return
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer sheet-token" {
+			t.Errorf("Authorization = %q, want the minted token", got)
+		}
+		var body struct {
+			Values [][]string `json:"values"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		calls = append(calls, call{r.Method, r.URL.EscapedPath(), len(body.Values)})
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	creds, _ := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"client_email": "gale@example.iam.gserviceaccount.com",
+		"private_key":  string(keyPEM),
+		"token_uri":    srv.URL + "/token",
+	})
+	credsPath := filepath.Join(t.TempDir(), "creds.json")
+	if err := os.WriteFile(credsPath, creds, 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", credsPath)
+
+	oldURL := sheetsAPIURL
+	sheetsAPIURL = srv.URL
+	defer func() { sheetsAPIURL = oldURL }()
+
+	output := newOutputFile("acme", "app", 1, []NormalizedRelease{{Version: "v1.0.0", Assets: []NormalizedAsset{{Name: "a"}, {Name: "b"}}}})
+
+	if err := exportToSheet(context.Background(), &output, "sheet-id", "Releases", gsheetModeOverwrite); err != nil {
+		t.Fatalf("exportToSheet(overwrite) error = %v", err)
+	}
+	if err := exportToSheet(context.Background(), &output, "sheet-id", "Releases", gsheetModeAppend); err != nil {
+		t.Fatalf("exportToSheet(append) error = %v", err)
+	}
+
+	expected := []call{
+		{http.MethodPost, "/spreadsheets/sheet-id/values/%27Releases%27:clear", 0},
+		{http.MethodPut, "/spreadsheets/sheet-id/values/%27Releases%27", 3},
+		{http.MethodPost, "/spreadsheets/sheet-id/values/%27Releases%27:append", 2},
+	}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Sheets calls = %+v, want %+v", calls, expected)
+	}
+}
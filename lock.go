@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// lockPath names the lock file of the output file at path: a hidden file
+// next to it, so the lock survives the output file being replaced.
+func lockPath(path string) string {
+	return filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".lock")
+}
+
+// lockOutput takes an exclusive lock on the output file at path, so that
+// --append runs merging into the same file take turns: each one reads the
+// file only once the previous one has written it. If another run holds the
+// lock, lockOutput says so unless quiet is set and waits for it. The
+// returned function releases the lock and removes the lock file.
+func lockOutput(path string, quiet bool) (func(), error) {
+	lock := lockPath(path)
+	for waited := false; ; {
+		f, err := os.OpenFile(lock, os.O_RDWR|os.O_CREATE, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+		locked, err := tryLockFile(f)
+		if err == nil && !locked {
+			if !quiet && !waited {
+				infoLog("%s Waiting for another gale run to finish writing %s\n", icons["info"], cyan(path))
+			}
+			waited = true
+			err = lockFile(f)
+		}
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+		// The run holding the lock before us removed the file it locked, so
+		// a lock on our now unlinked file would exclude nobody; try again.
+		if held, err := f.Stat(); err == nil {
+			if current, err := os.Stat(lock); err != nil || !os.SameFile(held, current) {
+				f.Close()
+				continue
+			}
+		}
+		// Removing the file before closing it, which releases the lock,
+		// makes the next run holding the old file try again.
+		return func() {
+			os.Remove(lock)
+			f.Close()
+		}, nil
+	}
+}
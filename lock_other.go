@@ -0,0 +1,15 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// tryLockFile always succeeds: output files are only locked on Unix.
+func tryLockFile(f *os.File) (bool, error) {
+	return true, nil
+}
+
+// lockFile does nothing: output files are only locked on Unix.
+func lockFile(f *os.File) error {
+	return nil
+}
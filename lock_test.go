@@ -0,0 +1,47 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLockOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "releases.json")
+	unlock, err := lockOutput(path, true)
+	if err != nil {
+		t.Fatalf("lockOutput() error = %v", err)
+	}
+
+	acquired := make(chan func())
+	go func() {
+		second, err := lockOutput(path, true)
+		if err != nil {
+			t.Errorf("second lockOutput() error = %v", err)
+			close(acquired)
+			return
+		}
+		acquired <- second
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("second lockOutput() returned while the first lock was held")
+	case <-time.After(100 * time.Millisecond):
+	}
+	unlock()
+	select {
+	case second := <-acquired:
+		if second != nil {
+			second()
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("second lockOutput() did not return after the first lock was released")
+	}
+	if _, err := os.Stat(lockPath(path)); !os.IsNotExist(err) {
+		t.Errorf("lock file left behind after unlocking: %v", err)
+	}
+}
@@ -7,17 +7,25 @@ package main
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/briandowns/spinner"
 	"github.com/fatih/color"
+	"golang.org/x/term"
 )
 
 var (
@@ -52,7 +60,7 @@ func showBanner() {
   %s/ /  %s GALE %s
  %s/ /___%s A modern CLI to fetch GitHub releases
 %s`
-	fmt.Printf(banner,
+	fmt.Fprintf(color.Output, banner,
 		logoColor.Sprint(" "),
 		logoColor.Sprint(""),
 		bright(fmt.Sprintf("v%s", version)),
@@ -67,217 +75,1158 @@ func showHelp() {
 	fmt.Printf(`
 %s:
   gale [owner] [repo] [options]
+  gale [owner/repo ...] [options]
+  gale <command> [options]
+
+%s:
+  %s        Serve releases over HTTP (--addr :8080, --cache-ttl 5m, --token-file or --app-id auth)
+  %s         Wait until a tag is published (owner repo --tag v2.0.0 --interval 30s --timeout 1h)
+  %s      Decrypt a file written with --encrypt (file.enc [-o output])
+  %s      Fetch the newest releases of your starred repos (--count 3 --limit 0 -o starred.json)
+  %s Print supported commands, formats and flags (--json)
+  %s   Save the given owner, repo and options for --query-file (file.json [owner] [repo] [options])
 
 %s:
   %s                       # Fetch releases for the default repo
   %s microsoft vscode         # Fetch VS Code releases
   %s cli gh --count 20        # Fetch 20 GitHub CLI releases
+  %s cli/gh golang/go --split # Fetch two repos into one file each
   %s --help                   # Show this help
 
 %s:
-  %s, -c   Number of releases to fetch (default: 10)
-  %s, -o   Output file name (default: releases.json)
-  %s, -t   GitHub token (or use GITHUB_TOKEN env var)
+  %s, -c   Number of releases to fetch, or all (or 0) for every release (default: 10)
+  %s, -o   Output file name, which may use {owner}, {repo}, {date} and {tag} (default: releases.json)
+  %s, -f   Output format: json, jsonl, yaml, xml, csv, markdown, timeseries or prometheus (default: json)
+  %s   Render the output with a Go text/template file instead; formatBytes is available (overrides --format)
+  %s   Add running asset and byte totals to --format timeseries
+  %s   Write JSON on one line without indentation; jsonl and timeseries are always compact
+  %s   Keep only these release fields, e.g. tag,publishedAt,url (json, jsonl and yaml)
+  %s   Merge the fetched releases into the existing --output file, keyed by release ID
+  %s   Write the GraphQL responses unmodified instead of the normalized releases
+  %s, -t   GitHub token (or use GITHUB_TOKEN env var; falls back to the gh CLI login)
+  %s   Read the GitHub token from this file (or use GITHUB_TOKEN_FILE); wins over GITHUB_TOKEN
+  %s   Authenticate as this GitHub App, with --app-private-key and --installation-id
+  %s   PEM private key file of the GitHub App
+  %s   Installation of the GitHub App to mint an access token for
   %s, -q   Quiet mode (minimal output)
+  %s, -V   Log every HTTP request and response with its timing to stderr, with credentials redacted
+  %s   Like --verbose, and also dump JSON response bodies
+  %s   Disable colored output (also off when NO_COLOR is set or logs are not a terminal)
+  %s   GitHub API base URL for Enterprise, e.g. https://github.example.com/api/v3 (or use GITHUB_API_URL env var)
+  %s   Warn when the token expires within N days (default: 7, 0 disables)
+  %s   Print the output to stdout if writing the file fails
+  %s   Print a one-line JSON summary to stdout and send logs to stderr
+  %s   Encrypt the output with a passphrase and write it to <output>.enc
+  %s   Encrypt the output to this age public key (age1...) or recipients file instead (repeatable)
+  %s   Also copy the rendered output to the system clipboard
+  %s   Only keep releases on this channel: stable, beta or alpha
+  %s   Tags containing any of these mark beta releases, e.g. v2.0.0-rc.1 (default: -beta,-rc,-pre)
+  %s   Tags containing any of these mark alpha releases (default: -alpha,-dev,-nightly,-canary)
+  %s   Only keep releases published on or after this date (RFC 3339 or YYYY-MM-DD)
+  %s   Only keep releases published on or before this date (RFC 3339 or YYYY-MM-DD)
+  %s   Keep tags matching a glob, e.g. 'cli-v*'
+  %s   Keep tags matching a regex; with a named capture, also group by it, e.g. '^v(?P<major>\d+)\.'
+  %s   Capture group to group by (default: the first named group)
+  %s   Release order: date (newest first) or semver (highest version first) (default: date)
+  %s   Order the assets of each release by name, size or type (default: API order)
+  %s   Reverse the --sort-assets order
+  %s   Only keep semver tags in this range, e.g. '>=1.2.0 <2.0.0' or '<1.0.0 || >=2.0.0'
+  %s   Drop tags that do not parse as semver instead of sorting them last
+  %s   Keep only assets of this content type, e.g. application/vnd.debian.binary-package (repeatable)
+  %s   Drop releases left without assets, e.g. after --content-type
+  %s   Drop releases with fewer than N assets; 0 keeps all (default: 0)
+  %s   Fail instead of writing an empty list when no releases are left after filtering
+  %s   Write "assets": null instead of [] for releases without assets
+  %s   Add each asset's REST API download URL as apiDownloadUrl
+  %s   Add each release's notes rendered by GitHub as HTML as descriptionHtml
+  %s   Add the profile URL of each release's author as authorUrl
+  %s   Add each release's reaction counts by emoji as reactions, e.g. {"🎉": 12}
+  %s   Add the repository's description, default branch and star count to repository
+  %s   Report releases in this earlier output file that no longer exist
+  %s   Exit with an error when --detect-yanked finds missing releases
+  %s   Wrap release notes at N columns in --latest-notes and --notes (default: terminal width) and --format markdown (only an explicit N); -1 disables
+  %s   Download every asset after writing the output
+  %s   Directory for --download (default: downloads)
+  %s   Local path for each downloaded asset (default: {tag}/{asset})
+  %s   Only download assets whose name matches a glob, e.g. '*.tar.gz'
+  %s   Retry a download whose size does not match up to N times (default: 2)
+  %s   Number of assets --download fetches at the same time (default: 4)
+  %s   Skip releases whose {tag} directory from --download-name-template already exists
+  %s   Check downloads against the release's checksums.txt or *.sha256 assets
+  %s   Also write asset rows to this Google Sheets spreadsheet ID
+  %s   Sheet (tab) name to write to (default: Sheet1)
+  %s   Google Sheets write mode: overwrite or append (default: overwrite)
+  %s   Also fetch these comma-separated owner/repo mirrors and merge releases by tag
+  %s   Which duplicate tag wins when merging: assets (most assets) or priority (default: assets)
+  %s   With several owner/repo arguments, write one file per repo, e.g. releases-cli-gh.json
+  %s   With several owner/repo arguments, keep one release per owner/repo and tag (owner/repo ignore case)
+  %s   Verify every asset download URL and fail if any is unavailable
+  %s   Print the --check summary as JSON
+  %s   Maximum number of concurrent requests (default: 4)
+  %s   Sleep until a GitHub rate limit resets instead of failing
+  %s   Fail on any GraphQL error instead of warning when the repository was still returned
+  %s   Keep running and print each new release as it appears; Ctrl-C stops
+  %s   Time between polls for --watch (default: 5m)
+  %s   Give up on fetching after this long, e.g. 2m; asset downloads are not limited; 0 disables (default: 30s)
+  %s   Reuse releases fetched within this long from $XDG_CACHE_HOME/gale, e.g. 5m (default: 0, no caching)
+  %s   Neither read nor write the cache
+  %s   Fetch again and overwrite the cached releases
+  %s   Use the REST API instead of GraphQL, e.g. when GraphQL is disabled on Enterprise
+  %s   Proxy for API requests and asset downloads, e.g. http://proxy:3128 or socks5://proxy:1080
+  %s   PEM file with additional CA certificates to trust, e.g. your Enterprise server's internal CA
+  %s   Do not verify TLS certificates; for testing only, requires --api-url
+  %s   User-Agent header for every request (or use GALE_USER_AGENT; default: gale/VERSION)
+  %s   Use HTTP/2 when the server supports it (default: true)
+  %s   Do not ask for gzip-compressed responses (compression is on by default)
+  %s   Transport read buffer size in bytes (default: 4096)
+  %s   Transport write buffer size in bytes (default: 4096)
+  %s   Fetch only the newest stable, non-draft release (cannot be combined with --count)
+  %s   Fetch only the release with this tag, e.g. v1.2.3 (cannot be combined with --count)
+  %s   With --latest, also consider prereleases
+  %s   Print only the release notes of the newest stable release, as HTML with --include-body-html
+  %s   Print a JSON diff of the assets of two releases: --diff v1.0.0 v1.1.0 or --diff v1.0.0..v1.1.0
+  %s   Load saved parameters from a JSON file written by save-query; flags override it
+  %s   Read option defaults from this TOML file instead of ./gale.toml, ./.galerc, ~/gale.toml or ~/.galerc
+  %s   Describe what gale would do and exit without fetching or writing
+  %s   Print tag, date, prerelease flag, asset count and size as a table instead of writing a file
+  %s   Browse releases in the terminal: enter expands a release, d downloads the highlighted asset
+  %s   Show release notes under each release in --table and expanded releases in --tui
+  %s   Fetch, then report the releases, paths and asset sizes a real run would write, without writing
+  %s   Check whether a newer gale release than this one exists on GitHub
+  %s   Download the latest gale release for this OS and architecture, check it against checksums.txt and replace this binary with it
   %s, -h   Show this help
   %s, -v   Show version
+  %s   Print the JSON Schema of the --format json output and exit
 
 %s:
   %s   Your GitHub personal access token
+  %s   File holding your GitHub token, read instead of GITHUB_TOKEN
+  %s   GitHub API base URL (default: https://api.github.com)
+  %s   Service account key file used by --gsheet
+  %s   Passphrase for --encrypt and decrypt (prompted for when unset)
+  %s   gh CLI config directory whose hosts.yml supplies a token when none is given
+  %s   User-Agent header sent instead of gale's own unless --user-agent is given
+  %s   Proxy for API requests and asset downloads unless --proxy is given
+  %s   Disable colored output when set to any value
+
+%s:
+  1   Any other error
+  2   Repository not found or not visible with the token
+  3   Authentication failed (HTTP 401)
+  4   Rate limited by GitHub
+  5   Network error reaching GitHub
 `,
 		bright("USAGE"),
+		bright("COMMANDS"),
+		cyan("serve"),
+		cyan("wait"),
+		cyan("decrypt"),
+		cyan("starred"),
+		cyan("capabilities"),
+		cyan("save-query"),
 		bright("EXAMPLES"),
 		cyan("gale"),
 		cyan("gale"),
 		cyan("gale"),
 		cyan("gale"),
+		cyan("gale"),
 		bright("OPTIONS"),
 		color.GreenString("--count"),
 		color.GreenString("--output"),
+		color.GreenString("--format"),
+		color.GreenString("--template"),
+		color.GreenString("--cumulative"),
+		color.GreenString("--compact"),
+		color.GreenString("--fields"),
+		color.GreenString("--append"),
+		color.GreenString("--raw"),
 		color.GreenString("--token"),
+		color.GreenString("--token-file"),
+		color.GreenString("--app-id"),
+		color.GreenString("--app-private-key"),
+		color.GreenString("--installation-id"),
 		color.GreenString("--quiet"),
+		color.GreenString("--verbose"),
+		color.GreenString("--debug"),
+		color.GreenString("--no-color"),
+		color.GreenString("--api-url"),
+		color.GreenString("--token-expiry-warn"),
+		color.GreenString("--rescue-on-write-error"),
+		color.GreenString("--summary-json-stdout"),
+		color.GreenString("--encrypt"),
+		color.GreenString("--encrypt-to"),
+		color.GreenString("--clipboard"),
+		color.GreenString("--channel"),
+		color.GreenString("--beta-suffixes"),
+		color.GreenString("--alpha-suffixes"),
+		color.GreenString("--since"),
+		color.GreenString("--until"),
+		color.GreenString("--tag-filter"),
+		color.GreenString("--tag-regex"),
+		color.GreenString("--tag-group"),
+		color.GreenString("--sort"),
+		color.GreenString("--sort-assets"),
+		color.GreenString("--sort-assets-desc"),
+		color.GreenString("--semver-range"),
+		color.GreenString("--drop-non-semver"),
+		color.GreenString("--content-type"),
+		color.GreenString("--drop-empty"),
+		color.GreenString("--min-assets"),
+		color.GreenString("--fail-if-empty"),
+		color.GreenString("--null-empty-assets"),
+		color.GreenString("--with-api-urls"),
+		color.GreenString("--include-body-html"),
+		color.GreenString("--include-author"),
+		color.GreenString("--include-reactions"),
+		color.GreenString("--repo-meta"),
+		color.GreenString("--detect-yanked"),
+		color.GreenString("--fail-on-yanked"),
+		color.GreenString("--wrap"),
+		color.GreenString("--download"),
+		color.GreenString("--download-dir"),
+		color.GreenString("--download-name-template"),
+		color.GreenString("--asset-filter"),
+		color.GreenString("--retries"),
+		color.GreenString("--download-concurrency"),
+		color.GreenString("--skip-existing-tags"),
+		color.GreenString("--verify-checksums"),
+		color.GreenString("--gsheet"),
+		color.GreenString("--gsheet-sheet"),
+		color.GreenString("--gsheet-mode"),
+		color.GreenString("--merge-repos"),
+		color.GreenString("--merge-prefer"),
+		color.GreenString("--split"),
+		color.GreenString("--dedupe"),
+		color.GreenString("--check"),
+		color.GreenString("--check-json"),
+		color.GreenString("--concurrency"),
+		color.GreenString("--wait-on-ratelimit"),
+		color.GreenString("--strict"),
+		color.GreenString("--watch"),
+		color.GreenString("--interval"),
+		color.GreenString("--timeout"),
+		color.GreenString("--cache-ttl"),
+		color.GreenString("--no-cache"),
+		color.GreenString("--refresh"),
+		color.GreenString("--rest"),
+		color.GreenString("--proxy"),
+		color.GreenString("--ca-cert"),
+		color.GreenString("--insecure-skip-verify"),
+		color.GreenString("--user-agent"),
+		color.GreenString("--http2"),
+		color.GreenString("--no-compression"),
+		color.GreenString("--read-buffer-size"),
+		color.GreenString("--write-buffer-size"),
+		color.GreenString("--latest"),
+		color.GreenString("--tag"),
+		color.GreenString("--latest-prerelease"),
+		color.GreenString("--latest-notes"),
+		color.GreenString("--diff"),
+		color.GreenString("--query-file"),
+		color.GreenString("--config"),
+		color.GreenString("--explain"),
+		color.GreenString("--table"),
+		color.GreenString("--tui"),
+		color.GreenString("--notes"),
+		color.GreenString("--dry-run"),
+		color.GreenString("--check-update"),
+		color.GreenString("--self-update"),
 		color.GreenString("--help"),
 		color.GreenString("--version"),
+		color.GreenString("--print-schema"),
 		bright("ENVIRONMENT"),
 		color.YellowString("GITHUB_TOKEN"),
+		color.YellowString("GITHUB_TOKEN_FILE"),
+		color.YellowString("GITHUB_API_URL"),
+		color.YellowString("GOOGLE_APPLICATION_CREDENTIALS"),
+		color.YellowString("GALE_PASSPHRASE"),
+		color.YellowString("GH_CONFIG_DIR"),
+		color.YellowString("GALE_USER_AGENT"),
+		color.YellowString("HTTPS_PROXY, HTTP_PROXY, NO_PROXY"),
+		color.YellowString("NO_COLOR"),
+		bright("EXIT CODES"),
 	)
 }
 
+// releaseFieldsFragment is shared by every query that returns releases so
+// that ReleaseNode is always populated the same way. Opt-in fields are
+// guarded by @include and switched on by releaseFieldOptions.
+const releaseFieldsFragment = `
+fragment ReleaseFields on Release {
+  id
+  name
+  tagName
+  tagCommit {
+    oid
+  }
+  publishedAt
+  isPrerelease
+  isDraft
+  url
+  description
+  descriptionHTML @include(if: $includeBodyHTML)
+  author {
+    login
+    url @include(if: $includeAuthor)
+  }
+  reactionGroups @include(if: $includeReactions) {
+    content
+    reactors {
+      totalCount
+    }
+  }
+  releaseAssets(first: 50) {
+    totalCount
+    pageInfo {
+      hasNextPage
+      endCursor
+    }
+    nodes {
+      ...AssetFields
+    }
+  }
+}` + assetFieldsFragment
+
+// repoMetaFragment holds the repository details --repo-meta adds to
+// RepoInfo.
+const repoMetaFragment = `
+fragment RepoMetaFields on Repository {
+  description
+  defaultBranchRef {
+    name
+  }
+  stargazerCount
+}`
+
+// releaseFieldOptions selects the opt-in fields of ReleaseFields and the
+// repository, which are left out by default to keep responses small.
+type releaseFieldOptions struct {
+	BodyHTML  bool
+	Author    bool
+	Reactions bool
+	RepoMeta  bool
+}
+
+// includeFields is set from the --include-* flags before fetching.
+var includeFields releaseFieldOptions
+
+// variables returns the @include switches of ReleaseFields.
+func (o releaseFieldOptions) variables() map[string]interface{} {
+	return map[string]interface{}{"includeBodyHTML": o.BodyHTML, "includeAuthor": o.Author, "includeReactions": o.Reactions, "includeRepoMeta": o.RepoMeta}
+}
+
+// cacheKey tells cached responses fetched with different fields apart. It is
+// empty for the default fields so existing cache entries stay valid.
+func (o releaseFieldOptions) cacheKey() string {
+	key := ""
+	if o.BodyHTML {
+		key += "+html"
+	}
+	if o.Author {
+		key += "+author"
+	}
+	if o.Reactions {
+		key += "+reactions"
+	}
+	if o.RepoMeta {
+		key += "+repo"
+	}
+	return key
+}
+
+const assetFieldsFragment = `
+fragment AssetFields on ReleaseAsset {
+  id
+  name
+  size
+  downloadUrl
+  contentType
+  downloadCount
+}`
+
+// githubReleaseAssetsQuery fetches the assets of a release beyond the first
+// page included by ReleaseFields.
+const githubReleaseAssetsQuery = `
+query ($id: ID!, $after: String) {
+  node(id: $id) {
+    ... on Release {
+      releaseAssets(first: 100, after: $after) {
+        totalCount
+        pageInfo {
+          hasNextPage
+          endCursor
+        }
+        nodes {
+          ...AssetFields
+        }
+      }
+    }
+  }
+}` + assetFieldsFragment
+
 const githubGraphQLQuery = `
-query ($owner: String!, $repo: String!, $first: Int!) {
+query ($owner: String!, $repo: String!, $first: Int!, $after: String, $includeBodyHTML: Boolean = false, $includeAuthor: Boolean = false, $includeReactions: Boolean = false, $includeRepoMeta: Boolean = false) {
   repository(owner: $owner, name: $repo) {
-    releases(first: $first, orderBy: { field: CREATED_AT, direction: DESC }) {
+    ...RepoMetaFields @include(if: $includeRepoMeta)
+    releases(first: $first, after: $after, orderBy: { field: CREATED_AT, direction: DESC }) {
       totalCount
+      pageInfo {
+        hasNextPage
+        endCursor
+      }
       nodes {
-        id
-        name
-        tagName
-        publishedAt
-        isPrerelease
-        isDraft
-        url
-        description
-        releaseAssets(first: 50) {
-          totalCount
-          nodes {
-            id
-            name
-            size
-            downloadUrl
-            contentType
-          }
-        }
+        ...ReleaseFields
       }
     }
   }
-}`
+}` + releaseFieldsFragment + repoMetaFragment
+
+// githubLatestReleaseQuery asks for GitHub's "latest" release, which is the
+// newest release that is neither a draft nor a prerelease.
+const githubLatestReleaseQuery = `
+query ($owner: String!, $repo: String!, $includeBodyHTML: Boolean = false, $includeAuthor: Boolean = false, $includeReactions: Boolean = false, $includeRepoMeta: Boolean = false) {
+  repository(owner: $owner, name: $repo) {
+    ...RepoMetaFields @include(if: $includeRepoMeta)
+    releases {
+      totalCount
+    }
+    latestRelease {
+      ...ReleaseFields
+    }
+  }
+}` + releaseFieldsFragment + repoMetaFragment
+
+// githubReleaseByTagQuery fetches a single release by its tag name.
+const githubReleaseByTagQuery = `
+query ($owner: String!, $repo: String!, $tag: String!, $includeBodyHTML: Boolean = false, $includeAuthor: Boolean = false, $includeReactions: Boolean = false, $includeRepoMeta: Boolean = false) {
+  repository(owner: $owner, name: $repo) {
+    ...RepoMetaFields @include(if: $includeRepoMeta)
+    releases {
+      totalCount
+    }
+    release(tagName: $tag) {
+      ...ReleaseFields
+    }
+  }
+}` + releaseFieldsFragment + repoMetaFragment
 
 type GraphQLResponse struct {
 	Data   *GraphQLData   `json:"data"`
 	Errors []GraphQLError `json:"errors"`
+
+	// TokenExpiresAt is taken from the GitHub-Authentication-Token-Expiration
+	// response header, which GitHub only sends for tokens that expire.
+	TokenExpiresAt time.Time `json:"-"`
 }
 
 type GraphQLError struct {
+	Type    string `json:"type"`
 	Message string `json:"message"`
 }
 
+// ErrRepoNotFound is returned when GitHub reports that the repository does
+// not exist or is not visible with the current credentials.
+var ErrRepoNotFound = errors.New("repository not found or access denied")
+
+// ErrUnauthorized is returned when GitHub rejects the token with 401.
+var ErrUnauthorized = errors.New("GitHub rejected the credentials")
+
+// ErrNetwork wraps failures to reach the GitHub API at all.
+var ErrNetwork = errors.New("could not reach the GitHub API")
+
 type GraphQLData struct {
-	Repository *Repository `json:"repository"`
+	Repository *Repository  `json:"repository"`
+	Viewer     *Viewer      `json:"viewer"`
+	Node       *ReleaseNode `json:"node"`
 }
 
 type Repository struct {
-	Releases Releases `json:"releases"`
+	Releases      Releases     `json:"releases"`
+	LatestRelease *ReleaseNode `json:"latestRelease"`
+	Release       *ReleaseNode `json:"release"`
+
+	// Only present with --repo-meta.
+	Description      *string `json:"description"`
+	DefaultBranchRef *struct {
+		Name string `json:"name"`
+	} `json:"defaultBranchRef"`
+	StargazerCount *int `json:"stargazerCount"`
 }
 
 type Releases struct {
 	TotalCount int           `json:"totalCount"`
+	PageInfo   PageInfo      `json:"pageInfo"`
 	Nodes      []ReleaseNode `json:"nodes"`
 }
 
+type PageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
 type ReleaseNode struct {
-	ID            string        `json:"id"`
-	Name          string        `json:"name"`
-	TagName       string        `json:"tagName"`
-	PublishedAt   time.Time     `json:"publishedAt"`
-	IsPrerelease  bool          `json:"isPrerelease"`
-	IsDraft       bool          `json:"isDraft"`
-	URL           string        `json:"url"`
-	Description   string        `json:"description"`
-	ReleaseAssets ReleaseAssets `json:"releaseAssets"`
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	TagName string `json:"tagName"`
+	// TagCommit is null when the tag no longer exists, e.g. for drafts.
+	TagCommit    *Commit   `json:"tagCommit"`
+	PublishedAt  time.Time `json:"publishedAt"`
+	IsPrerelease bool      `json:"isPrerelease"`
+	IsDraft      bool      `json:"isDraft"`
+	URL          string    `json:"url"`
+	Description  string    `json:"description"`
+	// DescriptionHTML is only requested with --include-body-html.
+	DescriptionHTML string `json:"descriptionHTML"`
+	Author          *Actor `json:"author"`
+	// ReactionGroups is only requested with --include-reactions.
+	ReactionGroups []ReactionGroup `json:"reactionGroups"`
+	ReleaseAssets  ReleaseAssets   `json:"releaseAssets"`
+}
+
+// Commit is the Git commit a release's tag points to.
+type Commit struct {
+	OID string `json:"oid"`
+}
+
+// Actor is a GitHub user. Releases created by automation may have none.
+// URL, the profile page, is only requested with --include-author.
+type Actor struct {
+	Login string `json:"login"`
+	URL   string `json:"url"`
 }
 
 type ReleaseAssets struct {
 	TotalCount int         `json:"totalCount"`
+	PageInfo   PageInfo    `json:"pageInfo"`
 	Nodes      []AssetNode `json:"nodes"`
 }
 
 type AssetNode struct {
-	ID          string `json:"id"`
-	Name        string `json:"name"`
-	Size        int64  `json:"size"`
-	DownloadURL string `json:"downloadUrl"`
-	ContentType string `json:"contentType"`
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	Size          int64  `json:"size"`
+	DownloadURL   string `json:"downloadUrl"`
+	ContentType   string `json:"contentType"`
+	DownloadCount int    `json:"downloadCount"`
 }
 
 type OutputFile struct {
 	Metadata   Metadata            `json:"metadata"`
 	Repository RepoInfo            `json:"repository"`
 	Releases   []NormalizedRelease `json:"releases"`
+	Sources    []RepoInfo          `json:"sources,omitempty"`
+	Groups     []ReleaseGroup      `json:"groups,omitempty"`
+	Yanked     []string            `json:"yanked,omitempty"`
 }
 
 type Metadata struct {
-	FetchedAt string `json:"fetchedAt"`
-	FetchedBy string `json:"fetchedBy"`
-	Author    string `json:"author"`
-	URL       string `json:"url"`
+	FetchedAt string `json:"fetchedAt" xml:"fetchedAt"`
+	FetchedBy string `json:"fetchedBy" xml:"fetchedBy"`
+	Author    string `json:"author" xml:"author"`
+	URL       string `json:"url" xml:"url"`
+
+	// FetchDurationMs is how long fetching took and RequestCount how many
+	// GitHub API requests it made, none when every release came from the
+	// cache.
+	FetchDurationMs int64 `json:"fetchDurationMs,omitempty" xml:"fetchDurationMs,omitempty"`
+	RequestCount    int   `json:"requestCount,omitempty" xml:"requestCount,omitempty"`
 }
 
 type RepoInfo struct {
-	Owner           string `json:"owner"`
-	Repo            string `json:"repo"`
-	URL             string `json:"url"`
-	TotalReleases   int    `json:"totalReleases"`
-	FetchedReleases int    `json:"fetchedReleases"`
+	Owner           string `json:"owner" xml:"owner"`
+	Repo            string `json:"repo" xml:"repo"`
+	URL             string `json:"url" xml:"url"`
+	TotalReleases   int    `json:"totalReleases" xml:"totalReleases"`
+	FetchedReleases int    `json:"fetchedReleases" xml:"fetchedReleases"`
+	TotalSize       int64  `json:"totalSize" xml:"totalSize"`
+
+	// Set with --repo-meta.
+	Description    string `json:"description,omitempty" xml:"description,omitempty"`
+	DefaultBranch  string `json:"defaultBranch,omitempty" xml:"defaultBranch,omitempty"`
+	StargazerCount *int   `json:"stargazerCount,omitempty" xml:"stargazerCount,omitempty"`
+}
+
+// setRepoMeta copies the --repo-meta fields of repo, if it has them, into
+// info.
+func (info *RepoInfo) setRepoMeta(repo *Repository) {
+	if repo.Description != nil {
+		info.Description = *repo.Description
+	}
+	if repo.DefaultBranchRef != nil {
+		info.DefaultBranch = repo.DefaultBranchRef.Name
+	}
+	info.StargazerCount = repo.StargazerCount
 }
 
 type NormalizedRelease struct {
-	ID            string            `json:"id"`
-	Name          string            `json:"name"`
-	Version       string            `json:"version"`
-	PublishedAt   time.Time         `json:"publishedAt"`
-	IsPrerelease  bool              `json:"isPrerelease"`
-	IsDraft       bool              `json:"isDraft"`
-	URL           string            `json:"url"`
-	Description   string            `json:"description"`
-	DownloadCount int               `json:"downloadCount"`
-	Assets        []NormalizedAsset `json:"assets"`
+	ID              string         `json:"id" xml:"id"`
+	Name            string         `json:"name" xml:"name"`
+	Version         string         `json:"version" xml:"version"`
+	CommitSHA       string         `json:"commitSha,omitempty" xml:"commitSha,omitempty"`
+	PublishedAt     time.Time      `json:"publishedAt" xml:"publishedAt"`
+	IsPrerelease    bool           `json:"isPrerelease" xml:"isPrerelease"`
+	IsDraft         bool           `json:"isDraft" xml:"isDraft"`
+	Author          string         `json:"author" xml:"author"`
+	AuthorURL       string         `json:"authorUrl,omitempty" xml:"authorUrl,omitempty"`
+	Channel         string         `json:"channel" xml:"channel"`
+	Group           string         `json:"group,omitempty" xml:"group,omitempty"`
+	Source          string         `json:"source,omitempty" xml:"source,omitempty"`
+	URL             string         `json:"url" xml:"url"`
+	Description     string         `json:"description" xml:"description"`
+	DescriptionHTML string         `json:"descriptionHtml,omitempty" xml:"descriptionHtml,omitempty"`
+	Reactions       map[string]int `json:"reactions,omitempty" xml:"-"`
+	// AssetCount is the number of assets. DownloadCount is the sum of
+	// their download counts, which every asset page already carries, so no
+	// extra request is needed.
+	AssetCount    int               `json:"assetCount" xml:"assetCount"`
+	DownloadCount int               `json:"downloadCount" xml:"downloadCount"`
+	Assets        []NormalizedAsset `json:"assets" xml:"assets>asset"`
 }
 
 type NormalizedAsset struct {
-	ID            string `json:"id"`
-	Name          string `json:"name"`
-	Size          int64  `json:"size"`
-	SizeFormatted string `json:"sizeFormatted"`
-	ContentType   string `json:"contentType"`
-	DownloadURL   string `json:"downloadUrl"`
+	ID             string `json:"id" xml:"id"`
+	Name           string `json:"name" xml:"name"`
+	Size           int64  `json:"size" xml:"size"`
+	SizeFormatted  string `json:"sizeFormatted" xml:"sizeFormatted"`
+	ContentType    string `json:"contentType" xml:"contentType"`
+	DownloadURL    string `json:"downloadUrl" xml:"downloadUrl"`
+	DownloadCount  int    `json:"downloadCount" xml:"downloadCount"`
+	APIDownloadURL string `json:"apiDownloadUrl,omitempty" xml:"apiDownloadUrl,omitempty"`
 }
 
 type Config struct {
-	Owner   string
-	Repo    string
-	Count   int
-	Output  string
-	Token   string
-	Quiet   bool
-	Help    bool
-	Version bool
-}
-
-var httpClient = &http.Client{
-	Transport: &http.Transport{
-		MaxIdleConns:        10,
-		IdleConnTimeout:     30 * time.Second,
-		DisableCompression:  true,
-		MaxIdleConnsPerHost: 10,
-	},
-	Timeout: 30 * time.Second,
-}
-
-func parseArgs() *Config {
+	Owner               string
+	Repo                string
+	Count               int
+	Output              string
+	Format              string
+	Template            string
+	Cumulative          bool
+	Compact             bool
+	Fields              stringList
+	Append              bool
+	Raw                 bool
+	Token               string
+	TokenFile           string
+	AppID               int64
+	AppPrivateKey       string
+	InstallationID      int64
+	APIURL              string
+	TokenExpiryWarn     int
+	Quiet               bool
+	Verbose             bool
+	Debug               bool
+	NoColor             bool
+	REST                bool
+	Proxy               string
+	UserAgent           string
+	CACert              string
+	InsecureSkipVerify  bool
+	WaitOnRateLimit     bool
+	Strict              bool
+	Watch               bool
+	Interval            time.Duration
+	Timeout             time.Duration
+	CacheTTL            time.Duration
+	NoCache             bool
+	Refresh             bool
+	RescueOnWrite       bool
+	SummaryJSON         bool
+	Encrypt             bool
+	EncryptTo           stringList
+	Clipboard           bool
+	Channel             string
+	BetaSuffixes        string
+	AlphaSuffixes       string
+	Since               string
+	Until               string
+	TagFilter           string
+	TagRegex            string
+	TagGroup            string
+	Sort                string
+	SortAssets          string
+	SortAssetsDesc      bool
+	SemverRange         string
+	DropNonSemver       bool
+	NullEmptyAssets     bool
+	ContentTypes        stringList
+	DropEmpty           bool
+	MinAssets           int
+	FailIfEmpty         bool
+	IncludeBodyHTML     bool
+	IncludeAuthor       bool
+	IncludeReactions    bool
+	RepoMeta            bool
+	WithAPIURLs         bool
+	DetectYanked        string
+	FailOnYanked        bool
+	Wrap                int
+	Download            bool
+	DownloadDir         string
+	DownloadName        string
+	AssetFilter         string
+	Retries             int
+	DownloadConcurrency int
+	SkipExistingTags    bool
+	VerifyChecksums     bool
+	GSheet              string
+	GSheetSheet         string
+	GSheetMode          string
+	MergeRepos          string
+	MergePrefer         string
+	Repos               []repoRef
+	Split               bool
+	Dedupe              bool
+	Check               bool
+	CheckJSON           bool
+	Concurrency         int
+	HTTP2               bool
+	NoCompression       bool
+	ReadBufferSize      int
+	WriteBufferSize     int
+	Latest              bool
+	Tag                 string
+	LatestPrerelease    bool
+	LatestNotes         bool
+	Diff                string
+	DiffTo              string
+	QueryFile           string
+	ConfigFile          string
+	Explain             bool
+	DryRun              bool
+	Table               bool
+	TUI                 bool
+	Notes               bool
+	CheckUpdate         bool
+	SelfUpdate          bool
+	Help                bool
+	Version             bool
+	PrintSchema         bool
+}
+
+const defaultTimeout = 30 * time.Second
+
+var httpClient = newHTTPClient(&Config{HTTP2: true, Timeout: defaultTimeout})
+
+// downloadClient fetches release assets through httpClient's transport. It
+// has no overall Timeout, which would also cover reading the body and cut
+// off any asset taking longer than --timeout; the context and the
+// transport's response header and idle timeouts still catch stalls.
+var downloadClient = newDownloadClient(httpClient)
+
+// newDownloadClient returns a client sharing api's transport without its
+// overall timeout.
+func newDownloadClient(api *http.Client) *http.Client {
+	return &http.Client{Transport: api.Transport}
+}
+
+// userAgent is the User-Agent header of every request gale sends. It is
+// replaced by --user-agent or GALE_USER_AGENT.
+var userAgent = fmt.Sprintf("gale/%s (+https://github.com/Typeflu)", version)
+
+// newHTTPClient builds the shared client from the transport options in cfg.
+// HTTP/2 multiplexes concurrent requests over a single connection. In
+// BenchmarkHTTPClient, bursts of twenty parallel requests on a reused client
+// finish more than ten times faster over HTTP/2, because HTTP/1.1 keeps only
+// ten idle connections and has to repeat the TLS handshake for the rest.
+// Larger buffers mainly help with big GraphQL responses, as does gzip, which
+// the transport requests and decodes unless --no-compression is set; release
+// lists of big repositories shrink several times over. cfg.Timeout bounds
+// each request; zero means no limit. The client serves API requests and
+// asset downloads alike, so both go through the proxy.
+func newHTTPClient(cfg *Config) *http.Client {
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(cfg.HTTP2)
+	proxy, _ := proxyFunc(cfg.Proxy)     // validated by validateConfig
+	tlsConfig, _ := tlsClientConfig(cfg) // validated by validateConfig
+
+	var transport http.RoundTripper = &http.Transport{
+		Proxy:           proxy,
+		TLSClientConfig: tlsConfig,
+		MaxIdleConns:    10,
+		IdleConnTimeout: 30 * time.Second,
+		// Bounds the wait for a response without limiting a download.
+		ResponseHeaderTimeout: cfg.Timeout,
+		DisableCompression:    cfg.NoCompression,
+		MaxIdleConnsPerHost:   10,
+		ForceAttemptHTTP2:     cfg.HTTP2,
+		Protocols:             protocols,
+		ReadBufferSize:        cfg.ReadBufferSize,
+		WriteBufferSize:       cfg.WriteBufferSize,
+	}
+	if cfg.Verbose || cfg.Debug {
+		transport = &loggingTransport{next: transport, w: os.Stderr, bodies: cfg.Debug}
+	}
+	transport = newETagTransport(transport, cfg)
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   cfg.Timeout,
+	}
+}
+
+// tlsClientConfig returns the transport's TLS configuration: nil for Go's
+// defaults, or one that also trusts the CA certificates in the --ca-cert PEM
+// file, for Enterprise servers behind an internal CA. --insecure-skip-verify
+// turns certificate verification off altogether and is meant for testing.
+func tlsClientConfig(cfg *Config) (*tls.Config, error) {
+	if cfg.CACert == "" && !cfg.InsecureSkipVerify {
+		return nil, nil
+	}
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+	if cfg.CACert == "" {
+		return tlsConfig, nil
+	}
+	pemData, err := os.ReadFile(cfg.CACert)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read --ca-cert: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pemData) {
+		return nil, fmt.Errorf("--ca-cert %s holds no PEM-encoded certificates", cfg.CACert)
+	}
+	tlsConfig.RootCAs = pool
+	return tlsConfig, nil
+}
+
+// proxyFunc returns the transport's proxy selection: the --proxy URL when
+// set, otherwise HTTP_PROXY, HTTPS_PROXY and NO_PROXY from the environment.
+// http, https, socks5 and socks5h proxies are supported.
+func proxyFunc(proxy string) (func(*http.Request) (*url.URL, error), error) {
+	if proxy == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+	u, err := url.Parse(proxy)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid --proxy %q (expected e.g. http://proxy.example.com:3128)", proxy)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("invalid --proxy %q: unsupported scheme %q (expected http, https, socks5 or socks5h)", proxy, u.Scheme)
+	}
+	return http.ProxyURL(u), nil
+}
+
+const (
+	channelStable = "stable"
+	channelBeta   = "beta"
+	channelAlpha  = "alpha"
+
+	defaultBetaSuffixes  = "-beta,-rc,-pre"
+	defaultAlphaSuffixes = "-alpha,-dev,-nightly,-canary"
+)
+
+// countAll is the Count of --count all or --count 0: every release.
+const countAll = 0
+
+// countValue is the --count flag: a number of releases, or "all".
+type countValue int
+
+func (c *countValue) String() string {
+	if c == nil {
+		return ""
+	}
+	if *c == countAll {
+		return "all"
+	}
+	return strconv.Itoa(int(*c))
+}
+
+func (c *countValue) Set(s string) error {
+	if s == "all" {
+		*c = countAll
+		return nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return fmt.Errorf("expected a number of releases or all")
+	}
+	*c = countValue(n)
+	return nil
+}
+
+func (c *countValue) Get() interface{} {
+	return int(*c)
+}
+
+// registerFlags defines every release fetch option on fs, storing the values
+// in cfg. It is shared by the main command and save-query.
+func registerFlags(fs *flag.FlagSet, cfg *Config) {
+	cfg.Count = 10
+	fs.Var((*countValue)(&cfg.Count), "count", "Number of releases to fetch, or all")
+	fs.Var((*countValue)(&cfg.Count), "c", "Number of releases to fetch, or all (shorthand)")
+	fs.StringVar(&cfg.Output, "output", "releases.json", "Output file name")
+	fs.StringVar(&cfg.Output, "o", "releases.json", "Output file name (shorthand)")
+	fs.StringVar(&cfg.Format, "format", formatJSON, "Output format: json, jsonl, yaml, xml, csv, markdown, timeseries or prometheus")
+	fs.StringVar(&cfg.Format, "f", formatJSON, "Output format (shorthand)")
+	fs.StringVar(&cfg.Template, "template", "", "Render the output with this Go text/template file instead of --format")
+	fs.BoolVar(&cfg.Cumulative, "cumulative", false, "Add running asset and byte totals to --format timeseries")
+	fs.BoolVar(&cfg.Compact, "compact", false, "Write JSON without indentation")
+	fs.Var(&cfg.Fields, "fields", "Keep only these comma-separated release fields")
+	fs.BoolVar(&cfg.Append, "append", false, "Merge the fetched releases into the existing output file")
+	fs.BoolVar(&cfg.Raw, "raw", false, "Write the GraphQL responses unmodified instead of the normalized releases")
+	fs.StringVar(&cfg.Token, "token", os.Getenv("GITHUB_TOKEN"), "GitHub token")
+	fs.StringVar(&cfg.Token, "t", os.Getenv("GITHUB_TOKEN"), "GitHub token (shorthand)")
+	fs.StringVar(&cfg.TokenFile, "token-file", os.Getenv("GITHUB_TOKEN_FILE"), "Read the GitHub token from this file")
+	fs.Int64Var(&cfg.AppID, "app-id", 0, "Authenticate as this GitHub App")
+	fs.StringVar(&cfg.AppPrivateKey, "app-private-key", "", "PEM private key file of the GitHub App")
+	fs.Int64Var(&cfg.InstallationID, "installation-id", 0, "GitHub App installation to mint an access token for")
+	fs.StringVar(&cfg.APIURL, "api-url", os.Getenv("GITHUB_API_URL"), "GitHub API base URL, e.g. https://github.example.com/api/v3 for Enterprise")
+	fs.IntVar(&cfg.TokenExpiryWarn, "token-expiry-warn", 7, "Warn when the token expires within N days")
+	fs.BoolVar(&cfg.Quiet, "quiet", false, "Quiet mode (minimal output)")
+	fs.BoolVar(&cfg.Quiet, "q", false, "Quiet mode (shorthand)")
+	fs.BoolVar(&cfg.Verbose, "verbose", false, "Log every HTTP request and response to stderr, with credentials redacted")
+	fs.BoolVar(&cfg.Verbose, "V", false, "Log every HTTP request and response (shorthand)")
+	fs.BoolVar(&cfg.Debug, "debug", false, "Like --verbose, and also dump JSON response bodies")
+	fs.BoolVar(&cfg.NoColor, "no-color", false, "Disable colored output")
+	fs.BoolVar(&cfg.RescueOnWrite, "rescue-on-write-error", false, "Print the output to stdout if writing the file fails")
+	fs.BoolVar(&cfg.SummaryJSON, "summary-json-stdout", false, "Print a one-line JSON summary to stdout and send logs to stderr")
+	fs.BoolVar(&cfg.Encrypt, "encrypt", false, "Encrypt the output with a passphrase and write it to <output>.enc")
+	fs.Var(&cfg.EncryptTo, "encrypt-to", "Encrypt the output to this age recipient or recipients file (repeatable)")
+	fs.BoolVar(&cfg.Clipboard, "clipboard", false, "Also copy the rendered output to the system clipboard")
+	fs.StringVar(&cfg.Channel, "channel", "", "Only keep releases on this channel (stable, beta, alpha)")
+	fs.StringVar(&cfg.BetaSuffixes, "beta-suffixes", defaultBetaSuffixes, "Comma-separated strings that mark beta releases when a tag contains one")
+	fs.StringVar(&cfg.AlphaSuffixes, "alpha-suffixes", defaultAlphaSuffixes, "Comma-separated strings that mark alpha releases when a tag contains one")
+	fs.StringVar(&cfg.Since, "since", "", "Only keep releases published on or after this RFC 3339 time or YYYY-MM-DD date")
+	fs.StringVar(&cfg.Until, "until", "", "Only keep releases published on or before this RFC 3339 time or YYYY-MM-DD date")
+	fs.StringVar(&cfg.TagFilter, "tag-filter", "", "Keep tags matching this glob, e.g. 'cli-v*'")
+	fs.StringVar(&cfg.TagRegex, "tag-regex", "", "Keep tags matching this regex; with a named capture, also group by it")
+	fs.StringVar(&cfg.TagGroup, "tag-group", "", "Named capture group of --tag-regex to group by")
+	fs.StringVar(&cfg.Sort, "sort", sortDate, "Release order: date or semver")
+	fs.StringVar(&cfg.SortAssets, "sort-assets", "", "Order the assets of each release by name, size or type")
+	fs.BoolVar(&cfg.SortAssetsDesc, "sort-assets-desc", false, "Reverse the --sort-assets order")
+	fs.StringVar(&cfg.SemverRange, "semver-range", "", "Only keep semver tags in this range, e.g. '>=1.2.0 <2.0.0'")
+	fs.BoolVar(&cfg.DropNonSemver, "drop-non-semver", false, "Drop tags that do not parse as semver")
+	fs.Var(&cfg.ContentTypes, "content-type", "Keep only assets with this content type (repeatable)")
+	fs.BoolVar(&cfg.DropEmpty, "drop-empty", false, "Drop releases that have no assets left")
+	fs.IntVar(&cfg.MinAssets, "min-assets", 0, "Drop releases with fewer than N assets")
+	fs.BoolVar(&cfg.FailIfEmpty, "fail-if-empty", false, "Fail when no releases are left after filtering")
+	fs.BoolVar(&cfg.NullEmptyAssets, "null-empty-assets", false, `Write "assets": null instead of [] for releases without assets`)
+	fs.BoolVar(&cfg.WithAPIURLs, "with-api-urls", false, "Add each asset's REST API download URL as apiDownloadUrl")
+	fs.BoolVar(&cfg.IncludeBodyHTML, "include-body-html", false, "Add each release's notes rendered as HTML as descriptionHtml")
+	fs.BoolVar(&cfg.IncludeAuthor, "include-author", false, "Add the profile URL of each release's author as authorUrl")
+	fs.BoolVar(&cfg.IncludeReactions, "include-reactions", false, "Add each release's reaction counts by emoji as reactions")
+	fs.BoolVar(&cfg.RepoMeta, "repo-meta", false, "Add the repository's description, default branch and star count")
+	fs.StringVar(&cfg.DetectYanked, "detect-yanked", "", "Report releases in this earlier output file that no longer exist")
+	fs.BoolVar(&cfg.FailOnYanked, "fail-on-yanked", false, "Exit with an error when --detect-yanked finds missing releases")
+	fs.IntVar(&cfg.Wrap, "wrap", 0, "Wrap release notes at N columns in --latest-notes and --notes (0 = terminal width) and --format markdown (N > 0 only); -1 = off")
+	fs.BoolVar(&cfg.Download, "download", false, "Download every asset after writing the output")
+	fs.StringVar(&cfg.DownloadDir, "download-dir", "downloads", "Directory for --download")
+	fs.StringVar(&cfg.DownloadName, "download-name-template", defaultDownloadNameTemplate, "Local path for each downloaded asset; placeholders: {owner} {repo} {tag} {asset}")
+	fs.StringVar(&cfg.AssetFilter, "asset-filter", "", "Only download assets whose name matches this glob")
+	fs.IntVar(&cfg.Retries, "retries", 2, "Retry a download whose size does not match up to N times")
+	fs.IntVar(&cfg.DownloadConcurrency, "download-concurrency", 4, "Number of assets to download at the same time")
+	fs.BoolVar(&cfg.SkipExistingTags, "skip-existing-tags", false, "Skip releases whose {tag} directory from --download-name-template already exists")
+	fs.BoolVar(&cfg.VerifyChecksums, "verify-checksums", false, "Check downloads against the release's checksums.txt or *.sha256 assets")
+	fs.StringVar(&cfg.GSheet, "gsheet", "", "Also write asset rows to this Google Sheets spreadsheet ID")
+	fs.StringVar(&cfg.GSheetSheet, "gsheet-sheet", "Sheet1", "Sheet (tab) name to write to")
+	fs.StringVar(&cfg.GSheetMode, "gsheet-mode", gsheetModeOverwrite, "Google Sheets write mode: overwrite or append")
+	fs.StringVar(&cfg.MergeRepos, "merge-repos", "", "Also fetch these comma-separated owner/repo mirrors and merge releases by tag")
+	fs.StringVar(&cfg.MergePrefer, "merge-prefer", mergePreferAssets, "Which duplicate tag wins when merging: assets or priority")
+	fs.BoolVar(&cfg.Split, "split", false, "With several owner/repo arguments, write one file per repo")
+	fs.BoolVar(&cfg.Dedupe, "dedupe", false, "With several owner/repo arguments, drop repeated owner/repo and tag pairs")
+	fs.BoolVar(&cfg.Check, "check", false, "Verify every asset download URL")
+	fs.BoolVar(&cfg.CheckJSON, "check-json", false, "Print the --check summary as JSON")
+	fs.IntVar(&cfg.Concurrency, "concurrency", 4, "Maximum number of concurrent requests")
+	fs.BoolVar(&cfg.WaitOnRateLimit, "wait-on-ratelimit", false, "Sleep until a GitHub rate limit resets instead of failing")
+	fs.BoolVar(&cfg.Strict, "strict", false, "Fail on any GraphQL error, even when the response still holds the repository")
+	fs.BoolVar(&cfg.Watch, "watch", false, "Keep running and print each new release as it appears")
+	fs.DurationVar(&cfg.Interval, "interval", defaultWatchInterval, "Time between polls for --watch")
+	fs.DurationVar(&cfg.Timeout, "timeout", defaultTimeout, "Give up on fetching after this long; asset downloads are not limited; 0 disables")
+	fs.DurationVar(&cfg.CacheTTL, "cache-ttl", 0, "Reuse releases fetched within this long from the cache; 0 disables")
+	fs.BoolVar(&cfg.NoCache, "no-cache", false, "Neither read nor write the cache")
+	fs.BoolVar(&cfg.Refresh, "refresh", false, "Fetch again and overwrite the cached releases")
+	fs.BoolVar(&cfg.REST, "rest", false, "Use the REST API instead of GraphQL")
+	fs.StringVar(&cfg.Proxy, "proxy", "", "HTTP or SOCKS proxy URL for API requests and downloads, overriding HTTPS_PROXY")
+	fs.StringVar(&cfg.CACert, "ca-cert", "", "PEM file with additional CA certificates to trust, e.g. an Enterprise server's internal CA")
+	fs.BoolVar(&cfg.InsecureSkipVerify, "insecure-skip-verify", false, "Do not verify TLS certificates; for testing only")
+	fs.StringVar(&cfg.UserAgent, "user-agent", os.Getenv("GALE_USER_AGENT"), "User-Agent header for every request")
+	fs.BoolVar(&cfg.HTTP2, "http2", true, "Use HTTP/2 when the server supports it")
+	fs.BoolVar(&cfg.NoCompression, "no-compression", false, "Do not ask for gzip-compressed responses")
+	fs.IntVar(&cfg.ReadBufferSize, "read-buffer-size", 0, "Transport read buffer size in bytes (0 = 4096)")
+	fs.IntVar(&cfg.WriteBufferSize, "write-buffer-size", 0, "Transport write buffer size in bytes (0 = 4096)")
+	fs.BoolVar(&cfg.Latest, "latest", false, "Fetch only the newest stable, non-draft release")
+	fs.StringVar(&cfg.Tag, "tag", "", "Fetch only the release with this tag")
+	fs.BoolVar(&cfg.LatestPrerelease, "latest-prerelease", false, "With --latest, also consider prereleases")
+	fs.BoolVar(&cfg.LatestNotes, "latest-notes", false, "Print only the release notes of the newest stable release, as HTML with --include-body-html")
+	fs.StringVar(&cfg.Diff, "diff", "", "Print a JSON diff of the assets of this release and the tag given last")
+	fs.StringVar(&cfg.QueryFile, "query-file", "", "Load saved parameters from this JSON file")
+	fs.StringVar(&cfg.ConfigFile, "config", "", "Read option defaults from this file instead of gale.toml or .galerc")
+	fs.BoolVar(&cfg.Explain, "explain", false, "Describe what gale would do and exit")
+	fs.BoolVar(&cfg.Table, "table", false, "Print releases as a table to the terminal instead of writing a file")
+	fs.BoolVar(&cfg.TUI, "tui", false, "Browse releases and download assets interactively instead of writing a file")
+	fs.BoolVar(&cfg.Notes, "notes", false, "Show release notes in --table and --tui, rendered as Markdown on a color terminal")
+	fs.BoolVar(&cfg.DryRun, "dry-run", false, "Fetch and report what would be written or downloaded without writing anything")
+	fs.BoolVar(&cfg.CheckUpdate, "check-update", false, "Check whether a newer gale release exists")
+	fs.BoolVar(&cfg.SelfUpdate, "self-update", false, "Replace this gale binary with the latest release for this OS and architecture")
+	fs.BoolVar(&cfg.Help, "help", false, "Show help")
+	fs.BoolVar(&cfg.Help, "h", false, "Show help (shorthand)")
+	fs.BoolVar(&cfg.Version, "version", false, "Show version")
+	fs.BoolVar(&cfg.Version, "v", false, "Show version (shorthand)")
+	fs.BoolVar(&cfg.PrintSchema, "print-schema", false, "Print the JSON Schema of the output and exit")
+}
+
+func parseArgs() (*Config, error) {
 	cfg := &Config{}
+	registerFlags(flag.CommandLine, cfg)
+
+	flag.Usage = showHelp                                       // Use our custom help function
+	args, _ := parseInterspersed(flag.CommandLine, os.Args[1:]) // CommandLine exits on error
+
+	if cfg.QueryFile != "" {
+		var err error
+		if args, err = applyQueryFile(flag.CommandLine, cfg.QueryFile, args); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.Latest && flagSet(flag.CommandLine, "count") {
+		return nil, errors.New("--latest and --count are mutually exclusive")
+	}
+	if cfg.Tag != "" && flagSet(flag.CommandLine, "count") {
+		return nil, errors.New("--tag and --count are mutually exclusive")
+	}
+
+	// The config file only fills in what the command line and query file
+	// left unset, so it is applied last.
+	path, workdir := cfg.ConfigFile, false
+	if path == "" {
+		path, workdir = findConfigFile()
+	}
+	if path != "" {
+		if err := applyConfigFile(flag.CommandLine, path, workdir); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.Diff != "" {
+		var err error
+		if args, err = splitDiffArgs(cfg, args); err != nil {
+			return nil, err
+		}
+	}
+
+	// A token file replaces GITHUB_TOKEN, but not a --token given explicitly.
+	if cfg.TokenFile != "" {
+		if flagSet(flag.CommandLine, "token") && flagSet(flag.CommandLine, "token-file") {
+			return nil, errors.New("--token and --token-file are mutually exclusive")
+		}
+		if !flagSet(flag.CommandLine, "token") {
+			token, err := readTokenFile(cfg.TokenFile)
+			if err != nil {
+				return nil, err
+			}
+			cfg.Token = token
+		}
+	}
+
+	// A GitHub App mints its own token, so an explicit one is a conflict.
+	if cfg.AppID != 0 || cfg.AppPrivateKey != "" || cfg.InstallationID != 0 {
+		if cfg.AppID <= 0 || cfg.AppPrivateKey == "" || cfg.InstallationID <= 0 {
+			return nil, errors.New("GitHub App authentication needs --app-id, --app-private-key and --installation-id together")
+		}
+		if flagSet(flag.CommandLine, "token") || flagSet(flag.CommandLine, "token-file") {
+			return nil, errors.New("--app-id cannot be combined with --token or --token-file")
+		}
+	}
+
+	if err := setRepoArgs(cfg, args); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// flagSet reports whether the flag called name, or its shorthand, was set
+// on the command line or by a query file.
+func flagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		set = set || longFlagName(f.Name) == name
+	})
+	return set
+}
 
-	flag.IntVar(&cfg.Count, "count", 10, "Number of releases to fetch")
-	flag.IntVar(&cfg.Count, "c", 10, "Number of releases to fetch (shorthand)")
-	flag.StringVar(&cfg.Output, "output", "releases.json", "Output file name")
-	flag.StringVar(&cfg.Output, "o", "releases.json", "Output file name (shorthand)")
-	flag.StringVar(&cfg.Token, "token", os.Getenv("GITHUB_TOKEN"), "GitHub token")
-	flag.StringVar(&cfg.Token, "t", os.Getenv("GITHUB_TOKEN"), "GitHub token (shorthand)")
-	flag.BoolVar(&cfg.Quiet, "quiet", false, "Quiet mode (minimal output)")
-	flag.BoolVar(&cfg.Quiet, "q", false, "Quiet mode (shorthand)")
-	flag.BoolVar(&cfg.Help, "help", false, "Show help")
-	flag.BoolVar(&cfg.Help, "h", false, "Show help (shorthand)")
-	flag.BoolVar(&cfg.Version, "version", false, "Show version")
-	flag.BoolVar(&cfg.Version, "v", false, "Show version (shorthand)")
-
-	flag.Usage = showHelp // Use our custom help function
-	flag.Parse()
-
-	args := flag.Args()
-	cfg.Owner = "Typeflu"
-	cfg.Repo = "gale"
+// setRepoArgs sets Owner and Repo from the positional arguments, falling
+// back to gale's own repository. Arguments are either "owner repo" or one or
+// more "owner/repo" slugs; with several slugs, Repos lists all of them and
+// Owner and Repo name the first.
+func setRepoArgs(cfg *Config, args []string) error {
+	cfg.Owner = selfOwner
+	cfg.Repo = selfRepo
+	if len(args) > 0 && strings.Contains(args[0], "/") {
+		var repos []repoRef
+		for _, arg := range args {
+			ref, err := parseRepoSlug(arg)
+			if err != nil {
+				return err
+			}
+			repos = append(repos, ref)
+		}
+		cfg.Owner, cfg.Repo = repos[0].Owner, repos[0].Repo
+		if len(repos) > 1 {
+			cfg.Repos = repos
+		}
+		return nil
+	}
+	if len(args) > 2 {
+		return fmt.Errorf("unexpected argument %q (use owner/repo for each repository to fetch several)", args[2])
+	}
 	if len(args) > 0 {
 		cfg.Owner = args[0]
 	}
 	if len(args) > 1 {
 		cfg.Repo = args[1]
 	}
+	return validateRepoRef(cfg.Owner, cfg.Repo)
+}
+
+const defaultAPIURL = "https://api.github.com"
+
+var graphqlEndpoint = defaultAPIURL + "/graphql"
+
+// apiEndpoints returns the REST root and GraphQL endpoint for base, the REST
+// API root of github.com or a GitHub Enterprise Server
+// ("https://HOST/api/v3"). An empty base selects github.com.
+func apiEndpoints(base string) (rest, graphql string, err error) {
+	if base == "" {
+		base = defaultAPIURL
+	}
+	u, err := url.Parse(base)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", "", fmt.Errorf("invalid API URL %q (expected e.g. https://github.example.com/api/v3)", base)
+	}
 
-	return cfg
+	rest = strings.TrimRight(base, "/")
+	if host, ok := strings.CutSuffix(rest, "/api/v3"); ok {
+		return rest, host + "/api/graphql", nil
+	}
+	return rest, rest + "/graphql", nil
+}
+
+// setAPIURL points the REST and GraphQL clients at base.
+func setAPIURL(base string) error {
+	rest, graphql, err := apiEndpoints(base)
+	if err != nil {
+		return err
+	}
+	restAPIURL, graphqlEndpoint = rest, graphql
+	return nil
+}
+
+func fetchGraphQL(ctx context.Context, query string, variables map[string]interface{}, token string) (*GraphQLResponse, error) {
+	return withRateLimitWait(ctx, func() (*GraphQLResponse, error) {
+		return fetchGraphQLOnce(ctx, query, variables, token)
+	})
 }
 
-func fetchGraphQL(ctx context.Context, variables map[string]interface{}, token string) (*GraphQLResponse, error) {
+func fetchGraphQLOnce(ctx context.Context, query string, variables map[string]interface{}, token string) (*GraphQLResponse, error) {
 	payload := map[string]interface{}{
-		"query":     githubGraphQLQuery,
+		"query":     query,
 		"variables": variables,
 	}
 
@@ -286,20 +1235,20 @@ func fetchGraphQL(ctx context.Context, variables map[string]interface{}, token s
 		return nil, fmt.Errorf("failed to marshal GraphQL query: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.github.com/graphql", bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", graphqlEndpoint, bytes.NewBuffer(body))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", fmt.Sprintf("gale/%s (+https://github.com/Typeflu)", version))
+	req.Header.Set("User-Agent", userAgent)
 	if token != "" {
 		req.Header.Set("Authorization", "bearer "+token)
 	}
 
 	res, err := httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request to GitHub API: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrNetwork, err)
 	}
 	defer func() {
 		if closeErr := res.Body.Close(); closeErr != nil {
@@ -309,191 +1258,1326 @@ func fetchGraphQL(ctx context.Context, variables map[string]interface{}, token s
 		}
 	}()
 
+	rateLimits.record(res.Header)
+	if limited := rateLimitFromResponse(res, false, time.Now()); limited != nil {
+		return nil, limited
+	}
+	if res.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("%w (status 401); check --token or GITHUB_TOKEN", ErrUnauthorized)
+	}
 	if res.StatusCode >= 400 {
 		resBody, _ := io.ReadAll(res.Body)
-		return nil, fmt.Errorf("GitHub API responded with status %d: %s", res.StatusCode, string(resBody))
+		return nil, fmt.Errorf("GitHub API responded with status %d: %s", res.StatusCode, redactSecrets(string(resBody), token))
 	}
 
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNetwork, err)
+	}
 	var result GraphQLResponse
-	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+	if err := json.Unmarshal(resBody, &result); err != nil {
 		return nil, fmt.Errorf("failed to decode GitHub API response: %w", err)
 	}
-
-	return &result, nil
-}
-
-func formatBytes(bytes int64) string {
-	if bytes == 0 {
-		return "0 B"
-	}
-	const unit = 1024
-	if bytes < unit {
-		return fmt.Sprintf("%d B", bytes)
+	rawResponses.record(resBody)
+	// Error messages end up in returned errors; they must not echo the token.
+	for i := range result.Errors {
+		result.Errors[i].Message = redactSecrets(result.Errors[i].Message, token)
 	}
-	div, exp := int64(unit), 0
-	for n := bytes / unit; n >= unit; n /= unit {
-		div *= unit
-		exp++
+	for _, e := range result.Errors {
+		if e.Type == "RATE_LIMITED" {
+			return nil, rateLimitFromResponse(res, true, time.Now())
+		}
 	}
-	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
-}
 
-func normalizeData(nodes []ReleaseNode) []NormalizedRelease {
-	releases := make([]NormalizedRelease, len(nodes))
-	for i, node := range nodes {
-		name := node.Name
-		if name == "" {
-			name = node.TagName
-		}
-		if name == "" {
-			name = "Unnamed Release"
+	if expiry := res.Header.Get("GitHub-Authentication-Token-Expiration"); expiry != "" {
+		if t, err := parseTokenExpiration(expiry); err == nil {
+			result.TokenExpiresAt = t
 		}
+	}
 
-		assets := make([]NormalizedAsset, len(node.ReleaseAssets.Nodes))
-		for j, asset := range node.ReleaseAssets.Nodes {
-			assets[j] = NormalizedAsset{
-				ID:            asset.ID,
-				Name:          asset.Name,
-				Size:          asset.Size,
-				SizeFormatted: formatBytes(asset.Size),
-				ContentType:   asset.ContentType,
-				DownloadURL:   asset.DownloadURL,
-			}
-		}
+	return &result, nil
+}
 
-		releases[i] = NormalizedRelease{
-			ID:            node.ID,
-			Name:          name,
-			Version:       node.TagName,
-			PublishedAt:   node.PublishedAt,
-			IsPrerelease:  node.IsPrerelease,
-			IsDraft:       node.IsDraft,
-			URL:           node.URL,
-			Description:   node.Description,
-			DownloadCount: node.ReleaseAssets.TotalCount,
-			Assets:        assets,
+// parseTokenExpiration parses the GitHub-Authentication-Token-Expiration
+// header. GitHub has used both a zone abbreviation and a numeric offset.
+func parseTokenExpiration(value string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02 15:04:05 MST", "2006-01-02 15:04:05 -0700"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
 		}
 	}
-	return releases
+	return time.Time{}, fmt.Errorf("unrecognized token expiration %q", value)
 }
 
-func run() error {
-	cfg := parseArgs()
-
-	if cfg.Help {
-		showBanner()
-		showHelp()
-		return nil
+// tokenExpiresWithin reports whether expiry falls within the next days days.
+// A zero expiry means the token does not expire.
+func tokenExpiresWithin(expiry, now time.Time, days int) bool {
+	if expiry.IsZero() || days <= 0 {
+		return false
 	}
+	return expiry.Sub(now) <= time.Duration(days)*24*time.Hour
+}
 
-	if cfg.Version {
-		fmt.Printf("%s v%s\n", bright("gale"), version)
-		return nil
-	}
+// releasesPageSize is the largest page GitHub's GraphQL API will return.
+const releasesPageSize = 100
 
-	if !cfg.Quiet {
-		showBanner()
+// fetchRepository fetches the newest count releases of owner/repo, following
+// the release cursor across as many pages as needed. The returned response
+// holds the nodes of every page.
+func fetchRepository(ctx context.Context, owner, repo string, count int, token string) (*GraphQLResponse, error) {
+	if count == countAll {
+		count = math.MaxInt
 	}
+	var result *GraphQLResponse
+	var after interface{}
+	for {
+		page, err := fetchReleasePage(ctx, owner, repo, min(count, releasesPageSize), after, token)
+		if err != nil {
+			return nil, err
+		}
+		if result == nil {
+			result = page
+		} else {
+			result.Data.Repository.Releases.Nodes = append(result.Data.Repository.Releases.Nodes, page.Data.Repository.Releases.Nodes...)
+		}
 
-	if cfg.Token == "" {
-		warningLog("%s No GitHub token provided. Rate limits may be lower.\n", icons["warning"])
+		releases := page.Data.Repository.Releases
+		count -= len(releases.Nodes)
+		if count <= 0 || !releases.PageInfo.HasNextPage {
+			result.Data.Repository.Releases.PageInfo = releases.PageInfo
+			break
+		}
+		after = releases.PageInfo.EndCursor
 	}
 
-	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond, spinner.WithSuffix(fmt.Sprintf(" Fetching %d releases for %s...", cfg.Count, bright(fmt.Sprintf("%s/%s", cfg.Owner, cfg.Repo)))))
-	if !cfg.Quiet {
-		s.Start()
+	nodes := result.Data.Repository.Releases.Nodes
+	for i := range nodes {
+		if err := fetchRemainingAssets(ctx, &nodes[i], token); err != nil {
+			return nil, err
+		}
 	}
+	return result, nil
+}
 
-	type fetchResult struct {
-		response *GraphQLResponse
-		err      error
+// fetchRemainingAssets appends the assets of release that did not fit on
+// the first page of its releaseAssets connection.
+func fetchRemainingAssets(ctx context.Context, release *ReleaseNode, token string) error {
+	assets := &release.ReleaseAssets
+	for assets.PageInfo.HasNextPage {
+		variables := map[string]interface{}{"id": release.ID, "after": assets.PageInfo.EndCursor}
+		result, err := fetchGraphQL(ctx, githubReleaseAssetsQuery, variables, token)
+		if err != nil {
+			return err
+		}
+		if len(result.Errors) > 0 {
+			return fmt.Errorf("failed to fetch assets of %s: %s", release.TagName, result.Errors[0].Message)
+		}
+		if result.Data == nil || result.Data.Node == nil {
+			return fmt.Errorf("failed to fetch assets of %s: release not found", release.TagName)
+		}
+		page := result.Data.Node.ReleaseAssets
+		assets.Nodes = append(assets.Nodes, page.Nodes...)
+		assets.PageInfo = page.PageInfo
 	}
-	resultChan := make(chan fetchResult, 1)
+	return nil
+}
 
-	go func() {
-		variables := map[string]interface{}{
-			"owner": cfg.Owner,
-			"repo":  cfg.Repo,
-			"first": cfg.Count,
-		}
-		res, err := fetchGraphQL(context.Background(), variables, cfg.Token)
-		resultChan <- fetchResult{response: res, err: err}
-	}()
+// fetchReleasePage fetches one page of up to first releases after the
+// cursor, or from the newest release when after is nil.
+func fetchReleasePage(ctx context.Context, owner, repo string, first int, after interface{}, token string) (*GraphQLResponse, error) {
+	variables := map[string]interface{}{
+		"owner": owner,
+		"repo":  repo,
+		"first": first,
+		"after": after,
+	}
+	return queryRepository(ctx, githubGraphQLQuery, variables, token)
+}
 
-	resultData := <-resultChan
-	s.Stop() // Stop the spinner
+// strictGraphQL is set by --strict; queryRepository then rejects partial data.
+var strictGraphQL bool
 
-	if resultData.err != nil {
-		return resultData.err
+// queryRepository runs a repository query and rejects responses that hold no
+// repository. GraphQL errors alongside a repository are only a warning unless
+// strictGraphQL is set. Every repository query selects ReleaseFields, so the
+// opt-in field switches are added to variables.
+func queryRepository(ctx context.Context, query string, variables map[string]interface{}, token string) (*GraphQLResponse, error) {
+	for name, on := range includeFields.variables() {
+		variables[name] = on
+	}
+	result, err := fetchGraphQL(ctx, query, variables, token)
+	if err != nil {
+		return nil, err
 	}
-	result := resultData.response
 
 	if len(result.Errors) > 0 {
 		var errorMessages string
 		for _, e := range result.Errors {
 			errorMessages += "- " + e.Message + "\n"
 		}
-		return fmt.Errorf("GraphQL returned errors:\n%s", errorMessages)
-	}
-
+		// GitHub resolves what it can and reports the fields that failed,
+		// e.g. the author of one release; the rest is still usable.
+		if !strictGraphQL && result.Data != nil && result.Data.Repository != nil {
+			fmt.Fprintf(os.Stderr, "warning: GraphQL returned partial data for %v/%v; continuing without the failed fields:\n%s", variables["owner"], variables["repo"], errorMessages)
+			return result, nil
+		}
+		for _, e := range result.Errors {
+			if e.Type == "NOT_FOUND" {
+				return nil, fmt.Errorf("%w: %s", ErrRepoNotFound, e.Message)
+			}
+		}
+		return nil, fmt.Errorf("GraphQL returned errors:\n%s", errorMessages)
+	}
+
 	if result.Data == nil || result.Data.Repository == nil {
-		return fmt.Errorf("repository not found or access denied")
+		return nil, ErrRepoNotFound
+	}
+	return result, nil
+}
+
+// fetchLatestRelease returns a response holding only the newest release of
+// owner/repo that is not a draft. Without prereleases that is the release
+// GitHub marks as latest; with prereleases the newest non-draft release,
+// whatever its kind. Repositories without such a release return no nodes.
+func fetchLatestRelease(ctx context.Context, owner, repo string, prereleases bool, token string) (*GraphQLResponse, error) {
+	if !prereleases {
+		variables := map[string]interface{}{"owner": owner, "repo": repo}
+		result, err := queryRepository(ctx, githubLatestReleaseQuery, variables, token)
+		if err != nil {
+			return nil, err
+		}
+		repoData := result.Data.Repository
+		if repoData.LatestRelease != nil {
+			if err := fetchRemainingAssets(ctx, repoData.LatestRelease, token); err != nil {
+				return nil, err
+			}
+			repoData.Releases.Nodes = []ReleaseNode{*repoData.LatestRelease}
+		}
+		return result, nil
 	}
 
+	var after interface{}
+	for {
+		result, err := fetchReleasePage(ctx, owner, repo, releasesPageSize, after, token)
+		if err != nil {
+			return nil, err
+		}
+		releases := &result.Data.Repository.Releases
+		for _, r := range releases.Nodes {
+			if !r.IsDraft {
+				if err := fetchRemainingAssets(ctx, &r, token); err != nil {
+					return nil, err
+				}
+				releases.Nodes = []ReleaseNode{r}
+				return result, nil
+			}
+		}
+		if !releases.PageInfo.HasNextPage {
+			releases.Nodes = nil
+			return result, nil
+		}
+		after = releases.PageInfo.EndCursor
+	}
+}
+
+// fetchReleaseByTag returns the release tagged tag, or nil if there is none.
+func fetchReleaseByTag(ctx context.Context, owner, repo, tag, token string) (*ReleaseNode, error) {
+	variables := map[string]interface{}{
+		"owner": owner,
+		"repo":  repo,
+		"tag":   tag,
+	}
+	result, err := queryRepository(ctx, githubReleaseByTagQuery, variables, token)
+	if err != nil {
+		return nil, err
+	}
+	return result.Data.Repository.Release, nil
+}
+
+// fetchTagRelease returns a response holding only the release of owner/repo
+// tagged tag, with all its assets. It fails if there is no such release.
+func fetchTagRelease(ctx context.Context, owner, repo, tag, token string) (*GraphQLResponse, error) {
+	variables := map[string]interface{}{
+		"owner": owner,
+		"repo":  repo,
+		"tag":   tag,
+	}
+	result, err := queryRepository(ctx, githubReleaseByTagQuery, variables, token)
+	if err != nil {
+		return nil, err
+	}
 	repoData := result.Data.Repository
-	releases := normalizeData(repoData.Releases.Nodes)
+	if repoData.Release == nil {
+		return nil, fmt.Errorf("%s/%s has no release tagged %s", owner, repo, tag)
+	}
+	if err := fetchRemainingAssets(ctx, repoData.Release, token); err != nil {
+		return nil, err
+	}
+	repoData.Releases.Nodes = []ReleaseNode{*repoData.Release}
+	return result, nil
+}
 
-	if !cfg.Quiet {
-		infoLog("%s Found %s releases (%s total)\n", icons["info"], bright(len(releases)), bright(repoData.Releases.TotalCount))
-		if len(releases) > 0 {
-			latest := releases[0]
-			infoLog("%s Latest is %s published on %s\n", icons["sparkles"], magenta(latest.Version), latest.PublishedAt.Format("Jan 02, 2006"))
+// parseInterspersed parses fs from args while allowing flags to follow
+// positional arguments, as in "gale cli gh --count 20". Everything after a
+// "--" terminator is positional.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, rest...), nil
 		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
 	}
+}
 
-	output := OutputFile{
-		Metadata: Metadata{
-			FetchedAt: time.Now().UTC().Format(time.RFC3339),
-			FetchedBy: fmt.Sprintf("gale v%s", version),
-			Author:    "Saksham Singla (@Typeflu)",
-			URL:       "https://github.com/Typeflu",
-		},
+func newMetadata() Metadata {
+	return Metadata{
+		FetchedAt: time.Now().UTC().Format(time.RFC3339),
+		FetchedBy: fmt.Sprintf("gale v%s", version),
+		Author:    "Saksham Singla (@Typeflu)",
+		URL:       "https://github.com/Typeflu",
+	}
+}
+
+func newOutputFile(owner, repo string, totalReleases int, releases []NormalizedRelease) OutputFile {
+	return OutputFile{
+		Metadata: newMetadata(),
 		Repository: RepoInfo{
-			Owner:           cfg.Owner,
-			Repo:            cfg.Repo,
-			URL:             fmt.Sprintf("https://github.com/%s/%s", cfg.Owner, cfg.Repo),
-			TotalReleases:   repoData.Releases.TotalCount,
+			Owner:           owner,
+			Repo:            repo,
+			URL:             fmt.Sprintf("https://github.com/%s/%s", owner, repo),
+			TotalReleases:   totalReleases,
 			FetchedReleases: len(releases),
+			TotalSize:       totalAssetSize(releases),
 		},
 		Releases: releases,
 	}
+}
+
+// totalAssetSize sums the size of every asset in releases.
+func totalAssetSize(releases []NormalizedRelease) int64 {
+	var total int64
+	for _, r := range releases {
+		for _, a := range r.Assets {
+			total += a.Size
+		}
+	}
+	return total
+}
+
+func formatBytes(bytes int64) string {
+	if bytes == 0 {
+		return "0 B"
+	}
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
+
+func normalizeData(nodes []ReleaseNode) []NormalizedRelease {
+	releases := make([]NormalizedRelease, len(nodes))
+	for i, node := range nodes {
+		name := node.Name
+		if name == "" {
+			name = node.TagName
+		}
+		if name == "" {
+			name = "Unnamed Release"
+		}
+
+		downloads := 0
+		assets := make([]NormalizedAsset, len(node.ReleaseAssets.Nodes))
+		for j, asset := range node.ReleaseAssets.Nodes {
+			downloads += asset.DownloadCount
+			assets[j] = NormalizedAsset{
+				ID:            asset.ID,
+				Name:          asset.Name,
+				Size:          asset.Size,
+				SizeFormatted: formatBytes(asset.Size),
+				ContentType:   asset.ContentType,
+				DownloadURL:   asset.DownloadURL,
+				DownloadCount: asset.DownloadCount,
+			}
+		}
+
+		var author, authorURL string
+		if node.Author != nil {
+			author, authorURL = node.Author.Login, node.Author.URL
+		}
+		var commitSHA string
+		if node.TagCommit != nil {
+			commitSHA = node.TagCommit.OID
+		}
+
+		releases[i] = NormalizedRelease{
+			ID:              node.ID,
+			Name:            name,
+			Version:         node.TagName,
+			CommitSHA:       commitSHA,
+			PublishedAt:     node.PublishedAt,
+			IsPrerelease:    node.IsPrerelease,
+			IsDraft:         node.IsDraft,
+			Author:          author,
+			AuthorURL:       authorURL,
+			URL:             node.URL,
+			Description:     node.Description,
+			DescriptionHTML: node.DescriptionHTML,
+			Reactions:       reactionCounts(node.ReactionGroups),
+			AssetCount:      node.ReleaseAssets.TotalCount,
+			DownloadCount:   downloads,
+			Assets:          assets,
+		}
+	}
+	return releases
+}
+
+// nullEmptyAssets replaces the empty asset lists produced by normalizeData
+// with nil so they are written as null, for consumers that expect that.
+func nullEmptyAssets(releases []NormalizedRelease) {
+	for i := range releases {
+		if len(releases[i].Assets) == 0 {
+			releases[i].Assets = nil
+		}
+	}
+}
+
+// channelRules classifies releases into channels by what their tag
+// contains, so -rc matches v2.0.0-rc.1 as well. A tag that contains one of
+// the alpha suffixes is alpha, otherwise one of the beta suffixes makes it
+// beta. Remaining prereleases are treated as beta and
+// everything else is stable. Matching is case-insensitive.
+type channelRules struct {
+	alpha []string
+	beta  []string
+}
+
+func newChannelRules(alpha, beta string) channelRules {
+	return channelRules{alpha: splitSuffixes(alpha), beta: splitSuffixes(beta)}
+}
+
+func splitSuffixes(list string) []string {
+	var suffixes []string
+	for _, s := range strings.Split(list, ",") {
+		if s = strings.ToLower(strings.TrimSpace(s)); s != "" {
+			suffixes = append(suffixes, s)
+		}
+	}
+	return suffixes
+}
+
+func (r channelRules) classify(tag string, prerelease bool) string {
+	tag = strings.ToLower(tag)
+	for _, s := range r.alpha {
+		if strings.Contains(tag, s) {
+			return channelAlpha
+		}
+	}
+	for _, s := range r.beta {
+		if strings.Contains(tag, s) {
+			return channelBeta
+		}
+	}
+	if prerelease {
+		return channelBeta
+	}
+	return channelStable
+}
 
-	outPath, err := filepath.Abs(cfg.Output)
+// applyChannels sets Channel on every release and, if channel is non-empty,
+// drops releases that are not on it.
+func applyChannels(releases []NormalizedRelease, rules channelRules, channel string) []NormalizedRelease {
+	kept := releases[:0]
+	for _, r := range releases {
+		r.Channel = rules.classify(r.Version, r.IsPrerelease)
+		if channel == "" || r.Channel == channel {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+// parseDateBound parses a --since or --until value given as RFC 3339 or as a
+// YYYY-MM-DD date in UTC. With endOfDay, a plain date means the end of that
+// day so that --until includes releases published on it.
+func parseDateBound(flagName, value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
 	if err != nil {
-		return fmt.Errorf("could not resolve path %q: %w", cfg.Output, err)
+		return time.Time{}, fmt.Errorf("invalid --%s %q (expected RFC 3339 or YYYY-MM-DD)", flagName, value)
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
+
+// filterPublished keeps releases published within [since, until]. A zero
+// bound is open. Unpublished drafts have no date and are dropped whenever a
+// bound is set.
+func filterPublished(releases []NormalizedRelease, since, until time.Time) []NormalizedRelease {
+	if since.IsZero() && until.IsZero() {
+		return releases
+	}
+	kept := releases[:0]
+	for _, r := range releases {
+		switch {
+		case r.PublishedAt.IsZero():
+		case !since.IsZero() && r.PublishedAt.Before(since):
+		case !until.IsZero() && r.PublishedAt.After(until):
+		default:
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+func validateChannel(channel string) error {
+	switch channel {
+	case "", channelStable, channelBeta, channelAlpha:
+		return nil
+	}
+	return fmt.Errorf("invalid channel %q (expected stable, beta or alpha)", channel)
+}
+
+// validateConfig rejects invalid option values before any network call.
+func validateConfig(cfg *Config) error {
+	if _, _, err := apiEndpoints(cfg.APIURL); err != nil {
+		return fmt.Errorf("invalid --api-url: %w", err)
+	}
+	if cfg.REST && (cfg.LatestNotes || cfg.Latest) {
+		return fmt.Errorf("--latest and --latest-notes are not supported with --rest")
+	}
+	if cfg.REST && cfg.RepoMeta {
+		return fmt.Errorf("--repo-meta is not supported with --rest")
+	}
+	if cfg.REST && cfg.IncludeBodyHTML {
+		return fmt.Errorf("--include-body-html is not supported with --rest")
+	}
+	if cfg.LatestPrerelease && !cfg.Latest {
+		return fmt.Errorf("--latest-prerelease requires --latest")
+	}
+	if cfg.SummaryJSON && cfg.Output == "-" {
+		return fmt.Errorf("--summary-json-stdout cannot be combined with --output -, both write to stdout")
+	}
+	if err := validateChannel(cfg.Channel); err != nil {
+		return err
+	}
+	since, err := parseDateBound("since", cfg.Since, false)
+	if err != nil {
+		return err
+	}
+	until, err := parseDateBound("until", cfg.Until, true)
+	if err != nil {
+		return err
+	}
+	if !since.IsZero() && !until.IsZero() && since.After(until) {
+		return fmt.Errorf("--since %s is after --until %s", cfg.Since, cfg.Until)
+	}
+	if cfg.Sort != sortDate && cfg.Sort != sortSemver {
+		return fmt.Errorf("invalid --sort %q (expected date or semver)", cfg.Sort)
+	}
+	if _, ok := assetLess[cfg.SortAssets]; cfg.SortAssets != "" && !ok {
+		return fmt.Errorf("invalid --sort-assets %q (expected name, size or type)", cfg.SortAssets)
+	}
+	if cfg.SortAssetsDesc && cfg.SortAssets == "" {
+		return fmt.Errorf("--sort-assets-desc requires --sort-assets")
+	}
+	if cfg.SemverRange != "" {
+		if _, err := parseSemverRange(cfg.SemverRange); err != nil {
+			return err
+		}
+	}
+	if cfg.TagFilter != "" {
+		if err := validateTagFilter(cfg.TagFilter); err != nil {
+			return err
+		}
+	}
+	if cfg.TagRegex != "" {
+		if _, err := newTagGrouper(cfg.TagRegex, cfg.TagGroup); err != nil {
+			return err
+		}
+	} else if cfg.TagGroup != "" {
+		return fmt.Errorf("--tag-group requires --tag-regex")
+	}
+	if cfg.FailOnYanked && cfg.DetectYanked == "" {
+		return fmt.Errorf("--fail-on-yanked requires --detect-yanked")
+	}
+	if _, err := expandOutputPath(cfg.Output, &OutputFile{}, time.Now()); err != nil {
+		return err
+	}
+	if err := validateDownloadNameTemplate(cfg.DownloadName); err != nil {
+		return err
+	}
+	if _, ok := tagDirTemplate(cfg.DownloadName); cfg.SkipExistingTags && !ok {
+		return fmt.Errorf("--skip-existing-tags needs a --download-name-template with {tag} in a directory, e.g. {tag}/{asset}; got %q", cfg.DownloadName)
+	}
+	if cfg.AssetFilter != "" {
+		if err := validateAssetFilter(cfg.AssetFilter); err != nil {
+			return err
+		}
+	}
+	if cfg.DownloadConcurrency < 1 {
+		return fmt.Errorf("--download-concurrency must be at least 1, got %d", cfg.DownloadConcurrency)
+	}
+	if cfg.Retries < 0 {
+		return fmt.Errorf("--retries must not be negative, got %d", cfg.Retries)
+	}
+	if cfg.VerifyChecksums && !cfg.Download {
+		return fmt.Errorf("--verify-checksums requires --download")
+	}
+	if cfg.GSheetMode != gsheetModeOverwrite && cfg.GSheetMode != gsheetModeAppend {
+		return fmt.Errorf("invalid --gsheet-mode %q (expected overwrite or append)", cfg.GSheetMode)
+	}
+	if _, err := parseRepoList(cfg.MergeRepos); err != nil {
+		return fmt.Errorf("invalid --merge-repos: %w", err)
+	}
+	if cfg.MergePrefer != mergePreferAssets && cfg.MergePrefer != mergePreferPriority {
+		return fmt.Errorf("invalid --merge-prefer %q (expected assets or priority)", cfg.MergePrefer)
+	}
+	if len(cfg.Repos) > 0 && (cfg.MergeRepos != "" || cfg.LatestNotes || cfg.Diff != "") {
+		return fmt.Errorf("--merge-repos, --latest-notes and --diff take a single repository")
+	}
+	if cfg.Diff != "" && (cfg.REST || cfg.Latest || cfg.LatestNotes) {
+		return fmt.Errorf("--diff cannot be combined with --rest, --latest or --latest-notes")
+	}
+	if cfg.Tag != "" && (cfg.REST || cfg.Latest || cfg.LatestNotes || cfg.Diff != "" || cfg.Watch) {
+		return fmt.Errorf("--tag cannot be combined with --rest, --latest, --latest-notes, --diff or --watch")
+	}
+	if len(cfg.EncryptTo) > 0 {
+		if cfg.Encrypt || cfg.Clipboard || cfg.Append || cfg.Table || cfg.TUI {
+			return fmt.Errorf("--encrypt-to cannot be combined with --encrypt, --clipboard, --append, --table or --tui")
+		}
+		if _, err := parseRecipients(cfg.EncryptTo); err != nil {
+			return err
+		}
+	}
+	if cfg.Clipboard && (cfg.Encrypt || cfg.Split) {
+		return fmt.Errorf("--clipboard cannot be combined with --encrypt or --split")
+	}
+	if cfg.Dedupe && len(cfg.Repos) == 0 {
+		return fmt.Errorf("--dedupe requires several owner/repo arguments")
+	}
+	if cfg.Split {
+		switch {
+		case len(cfg.Repos) == 0:
+			return fmt.Errorf("--split requires several owner/repo arguments")
+		case cfg.Output == "-":
+			return fmt.Errorf("--split cannot be combined with --output -")
+		case cfg.DetectYanked != "":
+			return fmt.Errorf("--split cannot be combined with --detect-yanked")
+		}
+	}
+	if err := validateFormat(cfg.Format); err != nil {
+		return err
+	}
+	if cfg.Template != "" {
+		if _, err := loadTemplate(cfg.Template); err != nil {
+			return err
+		}
+	}
+	if cfg.Cumulative && cfg.Format != formatTimeseries {
+		return fmt.Errorf("--cumulative requires --format timeseries")
+	}
+	if cfg.Compact && (cfg.Template != "" || (cfg.Format != formatJSON && cfg.Format != formatJSONL && cfg.Format != formatTimeseries)) {
+		return fmt.Errorf("--compact only applies to --format json, jsonl or timeseries")
+	}
+	if cfg.Raw && (cfg.REST || cfg.Format != formatJSON || cfg.Template != "" || len(cfg.Fields) > 0 || cfg.Append || cfg.Split || cfg.Table || cfg.TUI) {
+		return fmt.Errorf("--raw cannot be combined with --rest, --format, --template, --fields, --append, --split, --table or --tui")
+	}
+	if cfg.Append && (cfg.Format != formatJSON || cfg.Template != "" || len(cfg.Fields) > 0 || cfg.Output == "-" || cfg.Split || cfg.Encrypt || cfg.Table || cfg.TUI) {
+		return fmt.Errorf("--append needs a --format json output file and cannot be combined with --template, --fields, --split, --encrypt, --table or --tui")
+	}
+	if len(cfg.Fields) > 0 {
+		if cfg.Template != "" || cfg.Table || cfg.TUI || (cfg.Format != formatJSON && cfg.Format != formatJSONL && cfg.Format != formatYAML) {
+			return fmt.Errorf("--fields only applies to --format json, jsonl or yaml")
+		}
+		if _, err := parseFields(cfg.Fields); err != nil {
+			return err
+		}
+	}
+	if cfg.Count < 0 {
+		return fmt.Errorf("--count must be a positive number or all, got %d", cfg.Count)
+	}
+	if cfg.MinAssets < 0 {
+		return fmt.Errorf("--min-assets must not be negative, got %d", cfg.MinAssets)
+	}
+	if cfg.Timeout < 0 {
+		return fmt.Errorf("--timeout must not be negative, got %s", cfg.Timeout)
+	}
+	if cfg.CacheTTL < 0 {
+		return fmt.Errorf("--cache-ttl must not be negative, got %s", cfg.CacheTTL)
+	}
+	if cfg.Interval <= 0 {
+		return fmt.Errorf("--interval must be positive, got %s", cfg.Interval)
+	}
+	if cfg.Watch && (cfg.Latest || cfg.LatestNotes || cfg.Diff != "" || cfg.Download || cfg.Check || cfg.GSheet != "" || cfg.MergeRepos != "") {
+		return fmt.Errorf("--watch cannot be combined with --latest, --latest-notes, --diff, --download, --check, --gsheet or --merge-repos")
+	}
+	if cfg.Notes && !cfg.Table && !cfg.TUI {
+		return fmt.Errorf("--notes requires --table or --tui")
+	}
+	if cfg.Table && (cfg.Split || cfg.Encrypt || cfg.Clipboard || cfg.SummaryJSON || cfg.Format != formatJSON || cfg.Template != "") {
+		return fmt.Errorf("--table cannot be combined with --split, --encrypt, --clipboard, --summary-json-stdout, --format or --template")
+	}
+	if cfg.TUI && (cfg.Table || cfg.DryRun || cfg.Watch || cfg.Split || cfg.Encrypt || cfg.Clipboard || cfg.SummaryJSON || cfg.Format != formatJSON || cfg.Template != "" || cfg.Download || cfg.Check || cfg.GSheet != "") {
+		return fmt.Errorf("--tui cannot be combined with --table, --dry-run, --watch, --split, --encrypt, --clipboard, --summary-json-stdout, --format, --template, --download, --check or --gsheet")
+	}
+	if cfg.SelfUpdate && cfg.DryRun {
+		return fmt.Errorf("--self-update cannot be combined with --dry-run; use --check-update to only check")
+	}
+	if cfg.NoCache && cfg.Refresh {
+		return fmt.Errorf("--no-cache and --refresh are mutually exclusive")
+	}
+	if cfg.Concurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1, got %d", cfg.Concurrency)
+	}
+	if _, err := proxyFunc(cfg.Proxy); err != nil {
+		return err
+	}
+	if _, err := tlsClientConfig(cfg); err != nil {
+		return err
+	}
+	if cfg.InsecureSkipVerify && cfg.APIURL == "" {
+		return errors.New("--insecure-skip-verify is only allowed with --api-url; github.com always presents a valid certificate")
+	}
+	if cfg.ReadBufferSize < 0 || cfg.WriteBufferSize < 0 {
+		return fmt.Errorf("buffer sizes must not be negative")
+	}
+	return nil
+}
+
+// subcommands maps the first argument to a command with its own flags.
+// Anything else is treated as an owner/repo release fetch.
+var subcommands = map[string]func(args []string) error{
+	"serve":      runServe,
+	"wait":       runWait,
+	"decrypt":    runDecrypt,
+	"starred":    runStarred,
+	"save-query": runSaveQuery,
+}
+
+func run() error {
+	if err := setAPIURL(os.Getenv("GITHUB_API_URL")); err != nil {
+		return fmt.Errorf("GITHUB_API_URL: %w", err)
+	}
+	if len(os.Args) > 1 {
+		if cmd, ok := subcommands[os.Args[1]]; ok {
+			return cmd(os.Args[2:])
+		}
 	}
 
-	file, err := json.MarshalIndent(output, "", "  ")
+	cfg, err := parseArgs()
 	if err != nil {
-		return fmt.Errorf("failed to marshal output JSON: %w", err)
+		return err
 	}
+	color.NoColor = !colorEnabled(cfg.NoColor, os.Stdout)
 
-	err = os.WriteFile(outPath, file, 0644)
+	if cfg.Help {
+		showBanner()
+		showHelp()
+		return nil
+	}
+
+	if cfg.Version {
+		fmt.Printf("%s v%s\n", bright("gale"), version)
+		return nil
+	}
+
+	if cfg.PrintSchema {
+		return printSchema()
+	}
+
+	if err := validateConfig(cfg); err != nil {
+		return err
+	}
+
+	// Without a token, borrow the one gh is logged in with, if any.
+	tokenFromGH := false
+	if cfg.Token == "" && cfg.AppID == 0 {
+		if token, err := ghToken(cfg.APIURL); err == nil {
+			cfg.Token, tokenFromGH = token, true
+		}
+	}
+	registerSecret(cfg.Token)
+
+	if cfg.Explain {
+		explanation, err := explainConfig(cfg)
+		if err != nil {
+			return err
+		}
+		fmt.Print(explanation)
+		return nil
+	}
+
+	if cfg.TUI {
+		if err := checkTUITerminal(); err != nil {
+			return err
+		}
+	}
+
+	httpClient = newHTTPClient(cfg)
+	downloadClient = newDownloadClient(httpClient)
+	if cfg.InsecureSkipVerify {
+		warningLog("%s TLS certificate verification is OFF (--insecure-skip-verify). Anyone on the network can read and alter gale's requests, including your token. Use --ca-cert instead outside of testing.\n", icons["warning"])
+	}
+	waitOnRateLimit = cfg.WaitOnRateLimit
+	strictGraphQL = cfg.Strict
+	if cfg.UserAgent != "" {
+		userAgent = cfg.UserAgent
+	}
+	includeFields = releaseFieldOptions{BodyHTML: cfg.IncludeBodyHTML, Author: cfg.IncludeAuthor, Reactions: cfg.IncludeReactions, RepoMeta: cfg.RepoMeta}
+	if err := setAPIURL(cfg.APIURL); err != nil {
+		return err
+	}
+
+	// The deadline covers the whole fetch, every page and repository
+	// included, so pagination stops once it passes. The client timeout set
+	// by newHTTPClient only bounds each single request.
+	fetchCtx := context.Background()
+	if cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		fetchCtx, cancel = context.WithTimeout(fetchCtx, cfg.Timeout)
+		defer cancel()
+	}
+
+	// A GitHub App token replaces GITHUB_TOKEN for every request below.
+	if cfg.AppID != 0 {
+		token, err := installationToken(fetchCtx, cfg.AppID, cfg.AppPrivateKey, cfg.InstallationID)
+		if err != nil {
+			return err
+		}
+		cfg.Token = token
+		registerSecret(token)
+	}
+
+	// The self-update download is not bound by --timeout, so only the
+	// release lookup inside runCheckUpdate takes the deadline.
+	if cfg.CheckUpdate || cfg.SelfUpdate {
+		return runCheckUpdate(context.Background(), cfg)
+	}
+	if cfg.LatestNotes {
+		return printLatestNotes(fetchCtx, cfg)
+	}
+	if cfg.Diff != "" {
+		return printReleaseDiff(fetchCtx, cfg)
+	}
+	if cfg.Watch {
+		repos := cfg.Repos
+		if len(repos) == 0 {
+			repos = []repoRef{{Owner: cfg.Owner, Repo: cfg.Repo}}
+		}
+		return runWatch(cfg, repos)
+	}
+
+	toStdout := cfg.Output == "-" || cfg.Table || cfg.TUI
+	logFile := os.Stdout
+	if cfg.SummaryJSON || toStdout {
+		// Keep stdout free for the document or the summary line.
+		color.Output, logFile = color.Error, os.Stderr
+	}
+	color.NoColor = !colorEnabled(cfg.NoColor, logFile)
+
+	var passphrase string
+	if cfg.Encrypt && !cfg.DryRun {
+		// Ask before fetching so the prompt does not interrupt the spinner.
+		var err error
+		if passphrase, err = readPassphrase(true); err != nil {
+			return err
+		}
+	}
+
+	if !cfg.Quiet {
+		showBanner()
+	}
+
+	if cfg.Token == "" {
+		warningLog("%s No GitHub token provided or found in the gh CLI config. Rate limits may be lower.\n", icons["warning"])
+	} else if tokenFromGH && !cfg.Quiet {
+		infoLog("%s Using the token of the gh CLI for %s\n", icons["info"], ghHostname(cfg.APIURL))
+	}
+
+	what := fmt.Sprintf("%d releases", cfg.Count)
+	if cfg.Count == countAll {
+		what = "all releases"
+	}
+	if cfg.Latest {
+		what = "the latest release"
+	}
+	if cfg.Tag != "" {
+		what = "release " + cfg.Tag
+	}
+	merge, _ := parseRepoList(cfg.MergeRepos) // validated above
+	repos := append([]repoRef{{Owner: cfg.Owner, Repo: cfg.Repo}}, merge...)
+	target := fmt.Sprintf("%s/%s", cfg.Owner, cfg.Repo)
+	if len(cfg.Repos) > 0 {
+		repos = cfg.Repos
+		target = fmt.Sprintf("%d repositories", len(repos))
+	}
+
+	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond, spinner.WithWriterFile(logFile), spinner.WithSuffix(fmt.Sprintf(" Fetching %s for %s...", what, bright(target))))
+	if !cfg.Quiet {
+		s.Start()
+	}
+
+	type fetchResult struct {
+		responses []*GraphQLResponse
+		err       error
+	}
+	resultChan := make(chan fetchResult, 1)
+
+	fetch, mode := fetchRepository, "graphql"
+	switch {
+	case cfg.REST:
+		fetch, mode = fetchRepositoryREST, "rest"
+	case cfg.Latest:
+		fetch, mode = func(ctx context.Context, owner, repo string, _ int, token string) (*GraphQLResponse, error) {
+			return fetchLatestRelease(ctx, owner, repo, cfg.LatestPrerelease, token)
+		}, "latest"
+		if cfg.LatestPrerelease {
+			mode = "latest-prerelease"
+		}
+	case cfg.Tag != "":
+		fetch, mode = func(ctx context.Context, owner, repo string, _ int, token string) (*GraphQLResponse, error) {
+			return fetchTagRelease(ctx, owner, repo, cfg.Tag, token)
+		}, "tag-"+sanitizePathComponent(cfg.Tag)
+	}
+	cache, err := newResponseCache(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to write to file %s: %w", cfg.Output, err)
+		warningLog("%s %v; continuing without the cache\n", icons["warning"], err)
+	}
+	if cfg.Raw {
+		rawResponses = &rawRecorder{}
+	}
+	if cache != nil {
+		fetch = cache.wrap(mode+includeFields.cacheKey(), fetch)
+	}
+
+	fetchStart, requestsBefore := time.Now(), rateLimits.requestCount()
+	go func() {
+		responses, err := fetchRepos(fetchCtx, repos, cfg.Concurrency, func(ctx context.Context, ref repoRef) (*GraphQLResponse, error) {
+			return fetch(ctx, ref.Owner, ref.Repo, cfg.Count, cfg.Token)
+		})
+		resultChan <- fetchResult{responses: responses, err: err}
+	}()
+
+	resultData := <-resultChan
+	s.Stop() // Stop the spinner
+	fetchDuration, requestCount := time.Since(fetchStart), rateLimits.requestCount()-requestsBefore
+
+	if cache != nil && cache.hits.Load() > 0 && !cfg.Quiet {
+		infoLog("%s Using cached releases for %s of %s repositories (--refresh to fetch again)\n", icons["info"], bright(cache.hits.Load()), bright(len(repos)))
+	}
+	if errors.Is(resultData.err, context.DeadlineExceeded) {
+		return fmt.Errorf("fetching did not finish within --timeout %s: %w", cfg.Timeout, resultData.err)
+	}
+	if resultData.err != nil {
+		return resultData.err
+	}
+	result := resultData.responses[0]
+
+	if tokenExpiresWithin(result.TokenExpiresAt, time.Now(), cfg.TokenExpiryWarn) {
+		warningLog("%s GitHub token expires on %s. Renew it to avoid authentication failures.\n", icons["warning"], result.TokenExpiresAt.Local().Format("Jan 02, 2006 15:04 MST"))
+	}
+
+	if cfg.Latest {
+		for i, res := range resultData.responses {
+			if len(res.Data.Repository.Releases.Nodes) == 0 {
+				return fmt.Errorf("%s has no published release to return for --latest", repos[i])
+			}
+		}
+	}
+	repoData := result.Data.Repository
+	releases := normalizeData(repoData.Releases.Nodes)
+	complete := len(releases) >= repoData.Releases.TotalCount
+	total := repoData.Releases.TotalCount
+
+	var sources []RepoInfo
+	if len(repos) > 1 {
+		sets := make([][]NormalizedRelease, len(repos))
+		for i, res := range resultData.responses {
+			data := res.Data.Repository
+			sets[i] = normalizeData(data.Releases.Nodes)
+			for j := range sets[i] {
+				sets[i][j].Source = repos[i].String()
+			}
+			complete = complete && len(sets[i]) >= data.Releases.TotalCount
+			info := newOutputFile(repos[i].Owner, repos[i].Repo, data.Releases.TotalCount, sets[i]).Repository
+			info.setRepoMeta(data)
+			sources = append(sources, info)
+		}
+		if len(merge) > 0 {
+			releases = mergeReleases(sets, cfg.MergePrefer)
+		} else {
+			releases = combineReleases(sets)
+			total = 0
+			for _, info := range sources {
+				total += info.TotalReleases
+			}
+		}
+		if cfg.Dedupe {
+			var dropped int
+			if releases, dropped = dedupeReleases(releases); dropped > 0 && !cfg.Quiet {
+				infoLog("%s Dropped %s duplicate releases\n", icons["info"], bright(dropped))
+			}
+		}
+	}
+
+	var yanked []string
+	if cfg.DetectYanked != "" {
+		baseline, err := loadOutputFile(cfg.DetectYanked)
+		if err != nil {
+			return err
+		}
+		yanked = detectYanked(baseline.Releases, releases, complete)
+		if len(yanked) > 0 {
+			warningLog("%s %s releases from %s no longer exist: %s\n", icons["warning"], bright(len(yanked)), cfg.DetectYanked, strings.Join(yanked, ", "))
+		} else if !cfg.Quiet {
+			infoLog("%s No releases from %s have disappeared\n", icons["info"], cfg.DetectYanked)
+		}
+	}
+	releases = filterContentTypes(releases, cfg.ContentTypes)
+	if cfg.DropEmpty {
+		releases = dropEmptyReleases(releases)
+	}
+	releases = dropFewAssets(releases, cfg.MinAssets)
+	releases = applyChannels(releases, newChannelRules(cfg.AlphaSuffixes, cfg.BetaSuffixes), cfg.Channel)
+
+	if cfg.TagFilter != "" {
+		releases = filterTags(releases, cfg.TagFilter)
+	}
+	since, _ := parseDateBound("since", cfg.Since, false) // validated above
+	until, _ := parseDateBound("until", cfg.Until, true)
+	releases = filterPublished(releases, since, until)
+	if cfg.SemverRange != "" {
+		semverRange, _ := parseSemverRange(cfg.SemverRange) // validated above
+		releases = semverRange.filter(releases)
+	}
+	if cfg.DropNonSemver {
+		releases = dropNonSemver(releases)
+	}
+	if cfg.Sort == sortSemver {
+		sortSemverReleases(releases)
 	}
+	sortAssets(releases, cfg.SortAssets, cfg.SortAssetsDesc)
 
-	successLog("\n%s Success! Saved %s releases to %s\n", icons["check"], bright(len(releases)), cyan(cfg.Output))
+	var groups []ReleaseGroup
+	var grouper *tagGrouper
+	if cfg.TagRegex != "" {
+		if grouper, err = newTagGrouper(cfg.TagRegex, cfg.TagGroup); err != nil {
+			return err
+		}
+		releases, groups = grouper.apply(releases)
+	}
 
 	if !cfg.Quiet {
-		dimLog(fmt.Sprintf("%s %s", icons["folder"], outPath))
+		infoLog("%s Found %s releases (%s total)\n", icons["info"], bright(len(releases)), bright(total))
+		if len(releases) > 0 {
+			latest := releases[0]
+			infoLog("%s Latest is %s published on %s\n", icons["sparkles"], magenta(latest.Version), latest.PublishedAt.Format("Jan 02, 2006"))
+		}
+		infoLog("%s Assets total %s\n", icons["info"], bright(formatBytes(totalAssetSize(releases))))
+	}
+	if cfg.FailIfEmpty && len(releases) == 0 {
+		return fmt.Errorf("no releases of %s left after filtering (--fail-if-empty)", target)
+	}
+
+	if cfg.WithAPIURLs {
+		addAPIURLs(releases, cfg.Owner, cfg.Repo)
+	}
+	if cfg.NullEmptyAssets {
+		nullEmptyAssets(releases)
+	}
+
+	output := newOutputFile(cfg.Owner, cfg.Repo, total, releases)
+	output.Metadata.FetchDurationMs = fetchDuration.Milliseconds()
+	output.Metadata.RequestCount = requestCount
+	output.Repository.setRepoMeta(result.Data.Repository)
+	output.Sources = sources
+	output.Groups = groups
+	output.Yanked = yanked
+
+	if cfg.DryRun {
+		return printDryRun(cfg, &output)
+	}
+	if cfg.TUI {
+		return runTUI(cfg, &output)
 	}
+	if cfg.Table {
+		if err := printTable(cfg, &output); err != nil {
+			return err
+		}
+		return finishRun(cfg, &output)
+	}
+
+	if cfg.Split {
+		for _, part := range splitOutput(&output) {
+			if grouper != nil {
+				part.Releases, part.Groups = grouper.apply(part.Releases)
+			}
+			name, err := expandOutputPath(splitOutputPath(cfg.Output, part.Repository), &part, time.Now())
+			if err != nil {
+				return err
+			}
+			if err := writeLockedOutput(cfg, &part, name, passphrase); err != nil {
+				return err
+			}
+		}
+		return finishRun(cfg, &output)
+	}
+	name, err := expandOutputPath(cfg.Output, &output, time.Now())
+	if err != nil {
+		return err
+	}
+	if err := writeLockedOutput(cfg, &output, name, passphrase); err != nil {
+		return err
+	}
+	return finishRun(cfg, &output)
+}
+
+// outputPathValues returns the placeholder values available to --output
+// for output: its repository, the date of the run and the tag of its first,
+// usually newest, release.
+func outputPathValues(output *OutputFile, now time.Time) map[string]string {
+	tag := "untagged"
+	if len(output.Releases) > 0 {
+		tag = output.Releases[0].Version
+	}
+	return map[string]string{
+		"owner": sanitizePathComponent(output.Repository.Owner),
+		"repo":  sanitizePathComponent(output.Repository.Repo),
+		"date":  now.Format("2006-01-02"),
+		"tag":   sanitizePathComponent(tag),
+	}
+}
+
+// expandOutputPath substitutes the placeholders in the --output name for
+// output. "-" is returned unchanged.
+func expandOutputPath(name string, output *OutputFile, now time.Time) (string, error) {
+	if name == "-" {
+		return name, nil
+	}
+	path, err := expandPlaceholders(name, outputPathValues(output, now))
+	if err != nil {
+		return "", fmt.Errorf("invalid --output: %w", err)
+	}
+	return path, nil
+}
+
+// writeLockedOutput writes output to name like writeOutput. With --append
+// it first merges in the releases already in name, holding the lock on name
+// so that concurrent runs do not drop each other's releases. Without the
+// lock, e.g. in a read-only directory, it goes on unlocked so the write can
+// still fail over to --rescue-on-write-error.
+func writeLockedOutput(cfg *Config, output *OutputFile, name, passphrase string) error {
+	if cfg.Append {
+		if unlock, err := lockOutput(name, cfg.Quiet); err != nil {
+			warningLog("%s %v; appending without it\n", icons["warning"], err)
+		} else {
+			defer unlock()
+		}
+		if err := appendToExisting(output, name); err != nil {
+			return err
+		}
+		if cfg.Sort == sortSemver {
+			sortSemverReleases(output.Releases)
+		}
+	}
+	return writeOutput(cfg, output, name, passphrase)
+}
+
+// writeOutput renders output in cfg.Format, encrypts it when --encrypt or
+// --encrypt-to is set and writes it to name, or to stdout when name is "-".
+// The file is replaced atomically, so a failed run leaves the previous one
+// intact. With --clipboard the rendered output is copied to the clipboard
+// as well.
+func writeOutput(cfg *Config, output *OutputFile, name, passphrase string) error {
+	render := outputFormats[cfg.Format]
+	if cfg.Template != "" {
+		render = renderTemplate
+	}
+	if cfg.Raw {
+		render = renderRaw
+	}
+	file, err := render(output, cfg)
+	if err != nil {
+		return err
+	}
+
+	toStdout := name == "-"
+	if cfg.Encrypt || len(cfg.EncryptTo) > 0 {
+		if file, err = encryptOutput(file, cfg, passphrase); err != nil {
+			return err
+		}
+		if !toStdout {
+			name = encryptedPath(name)
+		}
+	}
+
+	if toStdout {
+		if _, err := os.Stdout.Write(file); err != nil {
+			return fmt.Errorf("failed to write output to stdout: %w", err)
+		}
+		if !cfg.Quiet {
+			successLog("%s Wrote %s releases to stdout\n", icons["check"], bright(len(output.Releases)))
+		}
+	} else {
+		outPath, err := filepath.Abs(name)
+		if err != nil {
+			return fmt.Errorf("could not resolve path %q: %w", name, err)
+		}
+		if err := writeFileAtomic(outPath, file, 0644); err != nil {
+			if cfg.RescueOnWrite {
+				rescueOutput(file)
+			}
+			return fmt.Errorf("failed to write to file %s: %w", name, err)
+		}
+
+		successLog("\n%s Success! Saved %s releases to %s\n", icons["check"], bright(len(output.Releases)), cyan(name))
+
+		if !cfg.Quiet {
+			dimLog(fmt.Sprintf("%s %s", icons["folder"], outPath))
+		}
+	}
+
+	// --clipboard is rejected with --encrypt, so file is still plain text.
+	if cfg.Clipboard {
+		if err := copyToClipboard(file); err != nil {
+			return fmt.Errorf("failed to copy output to the clipboard: %w", err)
+		}
+		if !cfg.Quiet {
+			successLog("%s Copied %s releases to the clipboard\n", icons["check"], bright(len(output.Releases)))
+		}
+	}
+	return nil
+}
+
+// finishRun runs the steps that follow writing the output: the summary line,
+// the Google Sheets export, asset downloads and the yanked and asset checks.
+func finishRun(cfg *Config, output *OutputFile) error {
+	if cfg.SummaryJSON {
+		line, err := json.Marshal(newSummary(output))
+		if err != nil {
+			return fmt.Errorf("failed to marshal summary: %w", err)
+		}
+		fmt.Println(string(line))
+	}
+
+	if cfg.GSheet != "" {
+		if err := exportToSheet(context.Background(), output, cfg.GSheet, cfg.GSheetSheet, cfg.GSheetMode); err != nil {
+			return fmt.Errorf("failed to export to Google Sheets: %w", err)
+		}
+		successLog("%s Exported releases to Google Sheet %s (%s)\n", icons["check"], cyan(cfg.GSheet), cfg.GSheetSheet)
+	}
+
+	if cfg.Download {
+		if err := downloadReleases(context.Background(), cfg, output); err != nil {
+			return err
+		}
+	}
+
+	if cfg.FailOnYanked && len(output.Yanked) > 0 {
+		return fmt.Errorf("%d releases were yanked since %s", len(output.Yanked), cfg.DetectYanked)
+	}
+
+	if cfg.Check {
+		report := checkAssets(context.Background(), httpClient, checkTargets(output), cfg.Concurrency)
+		if err := printCheckReport(report, cfg.CheckJSON); err != nil {
+			return err
+		}
+		if report.Broken > 0 {
+			return fmt.Errorf("%d of %d assets are unavailable", report.Broken, report.Checked)
+		}
+	}
+
+	if status, ok := rateLimits.latest(); ok && !cfg.Quiet {
+		infoLog("%s %s\n", icons["info"], status.summary(time.Now()))
+	}
+	return nil
+}
+
+// printLatestNotes prints the Markdown body of the newest stable release and
+// nothing else, wrapping it to --wrap columns when stdout is a terminal.
+// With --include-body-html it prints the notes as rendered by GitHub
+// instead, unwrapped.
+func printLatestNotes(ctx context.Context, cfg *Config) error {
+	variables := map[string]interface{}{
+		"owner": cfg.Owner,
+		"repo":  cfg.Repo,
+	}
+	result, err := queryRepository(ctx, githubLatestReleaseQuery, variables, cfg.Token)
+	if err != nil {
+		return err
+	}
+
+	repoData := result.Data.Repository
+	if repoData.LatestRelease == nil {
+		if repoData.Releases.TotalCount == 0 {
+			return fmt.Errorf("%s/%s has no releases", cfg.Owner, cfg.Repo)
+		}
+		return fmt.Errorf("%s/%s has no published stable release (only drafts or prereleases)", cfg.Owner, cfg.Repo)
+	}
+
+	notes := repoData.LatestRelease.Description
+	if cfg.IncludeBodyHTML {
+		notes = repoData.LatestRelease.DescriptionHTML
+	}
+	if strings.TrimSpace(notes) == "" {
+		return fmt.Errorf("release %s of %s/%s has no release notes", repoData.LatestRelease.TagName, cfg.Owner, cfg.Repo)
+	}
+	if term.IsTerminal(int(os.Stdout.Fd())) && !cfg.IncludeBodyHTML {
+		notes = wrapText(notes, wrapWidth(cfg.Wrap))
+	}
+	fmt.Println(notes)
 	return nil
 }
 
+// Summary is the one-line overview printed by --summary-json-stdout.
+type Summary struct {
+	Latest string `json:"latest"`
+	Count  int    `json:"count"`
+	Total  int    `json:"total"`
+}
+
+func newSummary(output *OutputFile) Summary {
+	summary := Summary{Count: len(output.Releases), Total: output.Repository.TotalReleases}
+	if len(output.Releases) > 0 {
+		summary.Latest = output.Releases[0].Version
+	}
+	return summary
+}
+
+// rescueOutput dumps data to stdout as a last resort when the output file
+// could not be written, so a completed fetch is not thrown away.
+func rescueOutput(data []byte) {
+	fmt.Fprintln(os.Stderr, color.YellowString("%s Could not write the output file; printing it to stdout instead.", icons["warning"]))
+	if _, err := os.Stdout.Write(append(data, '\n')); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to write output to stdout: %v\n", err)
+	}
+}
+
 func main() {
 	if err := run(); err != nil {
-		errorLog("\n%s Error: %v\n", icons["error"], err)
-		os.Exit(1)
+		errorLog("\n%s Error: %s\n", icons["error"], redactKnownSecrets(err.Error()))
+		os.Exit(exitCode(err))
 	}
 }
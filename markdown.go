@@ -0,0 +1,53 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ANSI escapes used by styleMarkdown. Each style is switched off on its
+// own, so a bold word inside a heading does not end the heading's underline.
+const (
+	ansiBold         = "\x1b[1m"
+	ansiBoldOff      = "\x1b[22m"
+	ansiDim          = "\x1b[2m"
+	ansiUnderline    = "\x1b[4m"
+	ansiUnderlineOff = "\x1b[24m"
+)
+
+var (
+	markdownLink   = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	markdownStrong = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+)
+
+// styleMarkdown styles Markdown release notes for a color terminal:
+// headings lose their #s and are bold and underlined, **strong** text is
+// bold and links show their text underlined, followed by the dimmed URL.
+// Fenced code blocks are left as they are. Wrap text before rendering it;
+// the escapes would otherwise count towards the width.
+func styleMarkdown(text string) string {
+	lines := strings.Split(text, "\n")
+	inFence := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		lines[i] = styleMarkdownLine(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// styleMarkdownLine styles one line of Markdown outside a code block.
+func styleMarkdownLine(line string) string {
+	line = markdownLink.ReplaceAllString(line, ansiUnderline+"$1"+ansiUnderlineOff+ansiDim+" ($2)"+ansiBoldOff)
+	line = markdownStrong.ReplaceAllString(line, ansiBold+"$1$2"+ansiBoldOff)
+	if heading := strings.TrimLeft(strings.TrimSpace(line), "#"); heading != strings.TrimSpace(line) && strings.HasPrefix(heading, " ") {
+		return ansiBold + ansiUnderline + strings.TrimSpace(heading) + ansiUnderlineOff + ansiBoldOff
+	}
+	return line
+}
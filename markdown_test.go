@@ -0,0 +1,20 @@
+package main
+
+import "testing"
+
+func TestStyleMarkdown(t *testing.T) {
+	testCases := []struct {
+		text, want string
+	}{
+		{"## What's new", ansiBold + ansiUnderline + "What's new" + ansiUnderlineOff + ansiBoldOff},
+		{"- **Breaking:** drop Go 1.21", "- " + ansiBold + "Breaking:" + ansiBoldOff + " drop Go 1.21"},
+		{"See [the docs](https://example.com/docs).", "See " + ansiUnderline + "the docs" + ansiUnderlineOff + ansiDim + " (https://example.com/docs)" + ansiBoldOff + "."},
+		{"#42 fixed", "#42 fixed"},
+		{"```\n# not a heading **here**\n```", "```\n# not a heading **here**\n```"},
+	}
+	for _, tc := range testCases {
+		if got := styleMarkdown(tc.text); got != tc.want {
+			t.Errorf("styleMarkdown(%q) = %q, want %q", tc.text, got, tc.want)
+		}
+	}
+}
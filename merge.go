@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	mergePreferAssets   = "assets"
+	mergePreferPriority = "priority"
+)
+
+// repoRef identifies a repository as owner and name.
+type repoRef struct {
+	Owner string
+	Repo  string
+}
+
+func (r repoRef) String() string {
+	return r.Owner + "/" + r.Repo
+}
+
+// validateRepoRef checks owner and repo against the names GitHub allows,
+// the same ownerPattern and repoPattern serve uses, so a typo is reported
+// before any request instead of as an API error.
+func validateRepoRef(owner, repo string) error {
+	switch {
+	case owner == "":
+		return fmt.Errorf("repository owner must not be empty")
+	case !ownerPattern.MatchString(owner):
+		return fmt.Errorf("invalid repository owner %q: GitHub names use letters, digits and hyphens, up to 39 characters, and start with a letter or digit", owner)
+	case repo == "":
+		return fmt.Errorf("repository name must not be empty")
+	case repo == "." || repo == ".." || !repoPattern.MatchString(repo):
+		return fmt.Errorf("invalid repository name %q: GitHub names use letters, digits, '.', '-' and '_', up to 100 characters", repo)
+	}
+	return nil
+}
+
+// parseRepoSlug splits an "owner/repo" argument.
+func parseRepoSlug(slug string) (repoRef, error) {
+	owner, repo, ok := strings.Cut(strings.TrimSpace(slug), "/")
+	if !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+		return repoRef{}, fmt.Errorf("invalid repository %q (expected owner/repo)", slug)
+	}
+	if err := validateRepoRef(owner, repo); err != nil {
+		return repoRef{}, err
+	}
+	return repoRef{Owner: owner, Repo: repo}, nil
+}
+
+// parseRepoList parses a comma-separated list of owner/repo slugs.
+func parseRepoList(list string) ([]repoRef, error) {
+	var refs []repoRef
+	for _, slug := range strings.Split(list, ",") {
+		if strings.TrimSpace(slug) == "" {
+			continue
+		}
+		ref, err := parseRepoSlug(slug)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
+
+// mergeReleases unions the release lists of several repositories, given in
+// priority order, into one list sorted newest first. Releases sharing a tag
+// are deduplicated: with mergePreferPriority the release from the earliest
+// repository wins, with mergePreferAssets the one with the most assets wins
+// and ties go to the earlier repository.
+func mergeReleases(sets [][]NormalizedRelease, prefer string) []NormalizedRelease {
+	var merged []NormalizedRelease
+	index := make(map[string]int)
+
+	for _, set := range sets {
+		for _, r := range set {
+			i, seen := index[r.Version]
+			if !seen {
+				index[r.Version] = len(merged)
+				merged = append(merged, r)
+				continue
+			}
+			if prefer == mergePreferAssets && len(r.Assets) > len(merged[i].Assets) {
+				merged[i] = r
+			}
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].PublishedAt.After(merged[j].PublishedAt)
+	})
+	return merged
+}
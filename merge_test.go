@@ -0,0 +1,84 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRepoSlug(t *testing.T) {
+	testCases := []struct {
+		slug    string
+		want    repoRef
+		wantErr bool
+	}{
+		{"owner/repo", repoRef{Owner: "owner", Repo: "repo"}, false},
+		{" owner/repo ", repoRef{Owner: "owner", Repo: "repo"}, false},
+		{"owner", repoRef{}, true},
+		{"owner/", repoRef{}, true},
+		{"/repo", repoRef{}, true},
+		{"owner/repo/extra", repoRef{}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.slug, func(t *testing.T) {
+			got, err := parseRepoSlug(tc.slug)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("parseRepoSlug(%q) error = %v, wantErr %v", tc.slug, err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("parseRepoSlug(%q) = %v, want %v", tc.slug, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMergeReleases(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	release := func(tag, source string, published time.Time, assets int) NormalizedRelease {
+		return NormalizedRelease{Version: tag, Source: source, PublishedAt: published, Assets: make([]NormalizedAsset, assets)}
+	}
+
+	primary := []NormalizedRelease{
+		release("v3", "a/main", day(3), 1),
+		release("v1", "a/main", day(1), 2),
+	}
+	mirror := []NormalizedRelease{
+		release("v3", "b/mirror", day(3), 4),
+		release("v2", "b/mirror", day(2), 1),
+		release("v1", "b/mirror", day(1), 2),
+	}
+
+	type summary struct {
+		Tag    string
+		Source string
+	}
+	testCases := []struct {
+		name   string
+		prefer string
+		want   []summary
+	}{
+		{
+			name:   "Prefer more assets, ties keep first repo",
+			prefer: mergePreferAssets,
+			want:   []summary{{"v3", "b/mirror"}, {"v2", "b/mirror"}, {"v1", "a/main"}},
+		},
+		{
+			name:   "Prefer repo priority",
+			prefer: mergePreferPriority,
+			want:   []summary{{"v3", "a/main"}, {"v2", "b/mirror"}, {"v1", "a/main"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []summary
+			for _, r := range mergeReleases([][]NormalizedRelease{primary, mirror}, tc.prefer) {
+				got = append(got, summary{r.Version, r.Source})
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("mergeReleases() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
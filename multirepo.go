@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// fetchRepos fetches every repository in repos with at most concurrency
+// requests in flight. Responses are returned in the order of repos; the
+// first failure, in that same order, is returned instead.
+func fetchRepos(ctx context.Context, repos []repoRef, concurrency int, fetch func(context.Context, repoRef) (*GraphQLResponse, error)) ([]*GraphQLResponse, error) {
+	responses := make([]*GraphQLResponse, len(repos))
+	failures := make([]error, len(repos))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < concurrency && w < len(repos); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				responses[i], failures[i] = fetch(ctx, repos[i])
+			}
+		}()
+	}
+	for i := range repos {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i, ref := range repos {
+		if failures[i] != nil {
+			return nil, fmt.Errorf("%s: %w", ref, failures[i])
+		}
+	}
+	return responses, nil
+}
+
+// combineReleases concatenates the release lists of several repositories
+// into one list sorted newest first. Unlike mergeReleases, releases that
+// share a tag are all kept; their Source tells them apart.
+func combineReleases(sets [][]NormalizedRelease) []NormalizedRelease {
+	var combined []NormalizedRelease
+	for _, set := range sets {
+		combined = append(combined, set...)
+	}
+	sort.SliceStable(combined, func(i, j int) bool {
+		return combined[i].PublishedAt.After(combined[j].PublishedAt)
+	})
+	return combined
+}
+
+// dedupeKey identifies a release for --dedupe: its repository's owner and
+// name, lowercased because GitHub treats them case-insensitively, and its
+// tag exactly as published.
+func dedupeKey(r NormalizedRelease) string {
+	return strings.ToLower(r.Source) + "@" + r.Version
+}
+
+// dedupeReleases drops every release whose dedupeKey was already seen,
+// keeping the first, which in a combined list is the newest. It returns the
+// kept releases and how many were dropped.
+func dedupeReleases(releases []NormalizedRelease) ([]NormalizedRelease, int) {
+	seen := make(map[string]bool)
+	kept := releases[:0]
+	for _, r := range releases {
+		key := dedupeKey(r)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		kept = append(kept, r)
+	}
+	return kept, len(releases) - len(kept)
+}
+
+// splitOutput breaks a combined output into one file per entry in its
+// Sources, each holding that repository's releases with Source cleared.
+func splitOutput(output *OutputFile) []OutputFile {
+	files := make([]OutputFile, len(output.Sources))
+	index := make(map[string]int)
+	for i, info := range output.Sources {
+		files[i] = OutputFile{Metadata: output.Metadata, Repository: info}
+		files[i].Releases = []NormalizedRelease{}
+		index[info.Owner+"/"+info.Repo] = i
+	}
+	for _, r := range output.Releases {
+		i, ok := index[r.Source]
+		if !ok {
+			continue
+		}
+		r.Source = ""
+		files[i].Releases = append(files[i].Releases, r)
+	}
+	for i := range files {
+		files[i].Repository.FetchedReleases = len(files[i].Releases)
+		files[i].Repository.TotalSize = totalAssetSize(files[i].Releases)
+	}
+	return files
+}
+
+// splitOutputPath derives the per-repository file name for --split by
+// inserting owner-repo before the extension: releases.json becomes
+// releases-cli-gh.json.
+func splitOutputPath(output string, info RepoInfo) string {
+	ext := filepath.Ext(output)
+	return strings.TrimSuffix(output, ext) + "-" + sanitizePathComponent(info.Owner) + "-" + sanitizePathComponent(info.Repo) + ext
+}
+
+// checkTargets groups output's releases by the repository they came from,
+// in the order of Sources, so --check reports each repository separately.
+func checkTargets(output *OutputFile) []checkTarget {
+	primary := output.Repository.Owner + "/" + output.Repository.Repo
+	if len(output.Sources) == 0 {
+		return []checkTarget{{Repo: primary, Releases: output.Releases}}
+	}
+	targets := make([]checkTarget, len(output.Sources))
+	index := make(map[string]int)
+	for i, info := range output.Sources {
+		targets[i].Repo = info.Owner + "/" + info.Repo
+		index[targets[i].Repo] = i
+	}
+	for _, r := range output.Releases {
+		i, ok := index[r.Source]
+		if !ok {
+			i = index[primary]
+		}
+		targets[i].Releases = append(targets[i].Releases, r)
+	}
+	return targets
+}
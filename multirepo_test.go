@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFetchRepos(t *testing.T) {
+	repos := []repoRef{{"a", "one"}, {"b", "two"}, {"c", "three"}, {"d", "four"}, {"e", "five"}}
+	var inFlight, peak int32
+
+	responses, err := fetchRepos(context.Background(), repos, 2, func(_ context.Context, ref repoRef) (*GraphQLResponse, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		res := &GraphQLResponse{Data: &GraphQLData{Repository: &Repository{}}}
+		res.Data.Repository.Releases.TotalCount = len(ref.Repo)
+		return res, nil
+	})
+	if err != nil {
+		t.Fatalf("fetchRepos() error = %v", err)
+	}
+	if peak > 2 {
+		t.Errorf("fetchRepos() ran %d fetches at once, want at most 2", peak)
+	}
+	for i, res := range responses {
+		if got, want := res.Data.Repository.Releases.TotalCount, len(repos[i].Repo); got != want {
+			t.Errorf("responses[%d] total = %d, want %d", i, got, want)
+		}
+	}
+}
+
+func TestFetchReposError(t *testing.T) {
+	repos := []repoRef{{"a", "one"}, {"b", "two"}, {"c", "three"}}
+	_, err := fetchRepos(context.Background(), repos, 3, func(_ context.Context, ref repoRef) (*GraphQLResponse, error) {
+		if ref.Owner != "a" {
+			return nil, ErrRepoNotFound
+		}
+		return &GraphQLResponse{}, nil
+	})
+	if !errors.Is(err, ErrRepoNotFound) {
+		t.Fatalf("fetchRepos() error = %v, want ErrRepoNotFound", err)
+	}
+	if want := "b/two: " + ErrRepoNotFound.Error(); err.Error() != want {
+		t.Errorf("fetchRepos() error = %q, want %q", err, want)
+	}
+}
+
+func TestCombineReleases(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	sets := [][]NormalizedRelease{
+		{{Version: "v1.1", PublishedAt: day(3), Source: "a/one"}, {Version: "v1.0", PublishedAt: day(1), Source: "a/one"}},
+		{{Version: "v1.0", PublishedAt: day(2), Source: "b/two"}},
+	}
+
+	var got []string
+	for _, r := range combineReleases(sets) {
+		got = append(got, r.Source+"@"+r.Version)
+	}
+	want := []string{"a/one@v1.1", "b/two@v1.0", "a/one@v1.0"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("combineReleases() = %v, want %v", got, want)
+	}
+}
+
+func TestDedupeReleases(t *testing.T) {
+	releases := []NormalizedRelease{
+		{Version: "v1.1", Source: "a/one", Name: "first"},
+		{Version: "v1.1", Source: "b/two"},
+		{Version: "v1.1", Source: "A/One", Name: "repeat"},
+		{Version: "V1.1", Source: "a/one"},
+		{Version: "v1.0", Source: "a/one"},
+		{Version: "v1.0", Source: "a/one"},
+	}
+
+	kept, dropped := dedupeReleases(releases)
+	var got []string
+	for _, r := range kept {
+		got = append(got, r.Source+"@"+r.Version)
+	}
+	want := []string{"a/one@v1.1", "b/two@v1.1", "a/one@V1.1", "a/one@v1.0"}
+	if !reflect.DeepEqual(got, want) || dropped != 2 {
+		t.Errorf("dedupeReleases() = %v, %d dropped, want %v, 2 dropped", got, dropped, want)
+	}
+	if kept[0].Name != "first" {
+		t.Errorf("dedupeReleases() kept %q, want the first of the duplicates", kept[0].Name)
+	}
+}
+
+func TestSplitOutput(t *testing.T) {
+	output := OutputFile{
+		Repository: RepoInfo{Owner: "a", Repo: "one", TotalReleases: 5, FetchedReleases: 3},
+		Sources: []RepoInfo{
+			{Owner: "a", Repo: "one", TotalReleases: 2, FetchedReleases: 2},
+			{Owner: "b", Repo: "two", TotalReleases: 3, FetchedReleases: 1},
+		},
+		Releases: []NormalizedRelease{
+			{Version: "v2", Source: "a/one"},
+			{Version: "v9", Source: "b/two"},
+			{Version: "v1", Source: "a/one"},
+		},
+	}
+
+	files := splitOutput(&output)
+	if len(files) != 2 {
+		t.Fatalf("splitOutput() returned %d files, want 2", len(files))
+	}
+	testCases := []struct {
+		repo     RepoInfo
+		versions []string
+	}{
+		{RepoInfo{Owner: "a", Repo: "one", TotalReleases: 2, FetchedReleases: 2}, []string{"v2", "v1"}},
+		{RepoInfo{Owner: "b", Repo: "two", TotalReleases: 3, FetchedReleases: 1}, []string{"v9"}},
+	}
+	for i, tc := range testCases {
+		if files[i].Repository != tc.repo {
+			t.Errorf("files[%d].Repository = %+v, want %+v", i, files[i].Repository, tc.repo)
+		}
+		var versions []string
+		for _, r := range files[i].Releases {
+			if r.Source != "" {
+				t.Errorf("files[%d] release %s kept Source %q", i, r.Version, r.Source)
+			}
+			versions = append(versions, r.Version)
+		}
+		if !reflect.DeepEqual(versions, tc.versions) {
+			t.Errorf("files[%d] versions = %v, want %v", i, versions, tc.versions)
+		}
+	}
+}
+
+func TestSplitOutputPath(t *testing.T) {
+	testCases := []struct {
+		output   string
+		expected string
+	}{
+		{"releases.json", "releases-cli-gh.json"},
+		{"out/data.yaml", "out/data-cli-gh.yaml"},
+		{"releases", "releases-cli-gh"},
+	}
+
+	for _, tc := range testCases {
+		if got := splitOutputPath(tc.output, RepoInfo{Owner: "cli", Repo: "gh"}); got != tc.expected {
+			t.Errorf("splitOutputPath(%q) = %q, want %q", tc.output, got, tc.expected)
+		}
+	}
+}
+
+func TestCheckTargets(t *testing.T) {
+	output := OutputFile{
+		Repository: RepoInfo{Owner: "a", Repo: "one"},
+		Sources:    []RepoInfo{{Owner: "a", Repo: "one"}, {Owner: "b", Repo: "two"}},
+		Releases:   []NormalizedRelease{{Version: "v2", Source: "b/two"}, {Version: "v1", Source: "a/one"}},
+	}
+
+	targets := checkTargets(&output)
+	if len(targets) != 2 || targets[0].Repo != "a/one" || targets[1].Repo != "b/two" {
+		t.Fatalf("checkTargets() = %+v, want a/one and b/two", targets)
+	}
+	if len(targets[0].Releases) != 1 || targets[0].Releases[0].Version != "v1" {
+		t.Errorf("checkTargets()[0].Releases = %+v, want v1", targets[0].Releases)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+const progressBarWidth = 30
+
+// progressReader passes reads through to r and redraws a one-line progress
+// bar on w whenever the filled part of the bar grows.
+type progressReader struct {
+	r     io.Reader
+	w     io.Writer
+	name  string
+	total int64
+	done  int64
+	drawn int
+}
+
+func newProgressReader(r io.Reader, w io.Writer, name string, total int64) *progressReader {
+	p := &progressReader{r: r, w: w, name: name, total: total, drawn: -1}
+	p.draw()
+	return p
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.done += int64(n)
+	if filled := p.filled(); filled != p.drawn || err == io.EOF {
+		p.draw()
+	}
+	return n, err
+}
+
+// finish ends the bar's line so later log output starts on a fresh one.
+func (p *progressReader) finish() {
+	fmt.Fprintln(p.w)
+}
+
+func (p *progressReader) filled() int {
+	if p.total <= 0 {
+		return 0
+	}
+	filled := int(p.done * progressBarWidth / p.total)
+	if filled > progressBarWidth {
+		filled = progressBarWidth
+	}
+	return filled
+}
+
+func (p *progressReader) draw() {
+	p.drawn = p.filled()
+	bar := strings.Repeat("=", p.drawn) + strings.Repeat(" ", progressBarWidth-p.drawn)
+	fmt.Fprintf(p.w, "\r  %s [%s] %9s / %s", p.name, bar, formatBytes(p.done), formatBytes(p.total))
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestProgressReader(t *testing.T) {
+	data := strings.Repeat("x", 3000)
+	var out bytes.Buffer
+
+	p := newProgressReader(iotest.OneByteReader(strings.NewReader(data)), &out, "gale.tar.gz", int64(len(data)))
+	got, err := io.ReadAll(p)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	p.finish()
+
+	if string(got) != data {
+		t.Errorf("progressReader altered the data: got %d bytes, want %d", len(got), len(data))
+	}
+	// One draw up front, one per filled cell and one at EOF.
+	if draws := strings.Count(out.String(), "\r"); draws != progressBarWidth+2 {
+		t.Errorf("progressReader drew %d times, want %d", draws, progressBarWidth+2)
+	}
+	last := out.String()[strings.LastIndex(out.String(), "\r"):]
+	if want := "[" + strings.Repeat("=", progressBarWidth) + "]    2.9 KB / 2.9 KB\n"; !strings.HasSuffix(last, want) {
+		t.Errorf("final bar = %q, want suffix %q", last, want)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// prometheusMetric is one gauge of --format prometheus, with a value for
+// each repository that has one.
+type prometheusMetric struct {
+	name  string
+	help  string
+	value func(file OutputFile, now time.Time) (float64, bool)
+}
+
+var prometheusMetrics = []prometheusMetric{
+	{"gale_total_releases", "Number of releases the repository has on GitHub.", func(f OutputFile, _ time.Time) (float64, bool) {
+		return float64(f.Repository.TotalReleases), true
+	}},
+	{"gale_fetched_releases", "Number of releases gale fetched.", func(f OutputFile, _ time.Time) (float64, bool) {
+		return float64(f.Repository.FetchedReleases), true
+	}},
+	{"gale_total_size_bytes", "Total size of the assets of the fetched releases.", func(f OutputFile, _ time.Time) (float64, bool) {
+		return float64(f.Repository.TotalSize), true
+	}},
+	{"gale_latest_release_timestamp_seconds", "Unix time the newest fetched release was published.", func(f OutputFile, _ time.Time) (float64, bool) {
+		latest, ok := latestPublished(f.Releases)
+		return float64(latest.Unix()), ok
+	}},
+	{"gale_latest_release_age_seconds", "Seconds since the newest fetched release was published.", func(f OutputFile, now time.Time) (float64, bool) {
+		latest, ok := latestPublished(f.Releases)
+		return now.Sub(latest).Seconds(), ok
+	}},
+}
+
+// latestPublished returns when the newest non-draft release of releases
+// was published; ok is false when there is none.
+func latestPublished(releases []NormalizedRelease) (latest time.Time, ok bool) {
+	for _, r := range releases {
+		if !r.IsDraft && r.PublishedAt.After(latest) {
+			latest, ok = r.PublishedAt, true
+		}
+	}
+	return latest, ok
+}
+
+// prometheusLabel escapes a label value as the text exposition format
+// requires.
+func prometheusLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// renderPrometheusAt writes output as Prometheus gauges labelled with owner
+// and repo, one series per repository, measuring release age from now.
+func renderPrometheusAt(output *OutputFile, now time.Time) []byte {
+	files := []OutputFile{*output}
+	if len(output.Sources) > 0 {
+		files = splitOutput(output)
+	}
+	var b strings.Builder
+	for _, m := range prometheusMetrics {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n", m.name, m.help, m.name)
+		for _, f := range files {
+			if v, ok := m.value(f, now); ok {
+				fmt.Fprintf(&b, "%s{owner=\"%s\",repo=\"%s\"} %s\n", m.name, prometheusLabel(f.Repository.Owner), prometheusLabel(f.Repository.Repo), strconv.FormatFloat(v, 'f', -1, 64))
+			}
+		}
+	}
+	return []byte(b.String())
+}
+
+// renderPrometheus writes the Prometheus text exposition format, e.g. for
+// the node_exporter textfile collector.
+func renderPrometheus(output *OutputFile, cfg *Config) ([]byte, error) {
+	return renderPrometheusAt(output, time.Now()), nil
+}
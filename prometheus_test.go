@@ -0,0 +1,54 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderPrometheus(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	output := newOutputFile("cli", "gh", 42, []NormalizedRelease{
+		{Version: "v2.0.0-draft", IsDraft: true, PublishedAt: now.Add(-time.Minute)},
+		{Version: "v1.1.0", PublishedAt: now.Add(-time.Hour), Assets: []NormalizedAsset{{Size: 1024}}},
+		{Version: "v1.0.0", PublishedAt: now.Add(-48 * time.Hour)},
+	})
+
+	got := string(renderPrometheusAt(&output, now))
+	for _, want := range []string{
+		"# TYPE gale_total_releases gauge\n",
+		`gale_total_releases{owner="cli",repo="gh"} 42` + "\n",
+		`gale_fetched_releases{owner="cli",repo="gh"} 3` + "\n",
+		`gale_total_size_bytes{owner="cli",repo="gh"} 1024` + "\n",
+		`gale_latest_release_timestamp_seconds{owner="cli",repo="gh"} 1717196400` + "\n",
+		`gale_latest_release_age_seconds{owner="cli",repo="gh"} 3600` + "\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("renderPrometheusAt() = %s\nwant it to contain %q", got, want)
+		}
+	}
+
+	empty := newOutputFile("cli", "gh", 0, []NormalizedRelease{})
+	if got := string(renderPrometheusAt(&empty, now)); strings.Contains(got, "gale_latest_release_age_seconds{") {
+		t.Errorf("renderPrometheusAt() without releases = %s, want no age series", got)
+	}
+}
+
+func TestRenderPrometheusSources(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	output := newOutputFile("cli", "gh", 2, []NormalizedRelease{
+		{Version: "v1.0.0", Source: "cli/gh", PublishedAt: now.Add(-time.Hour)},
+		{Version: "v9.0.0", Source: `we"ird/repo`, PublishedAt: now.Add(-2 * time.Hour)},
+	})
+	output.Sources = []RepoInfo{{Owner: "cli", Repo: "gh", TotalReleases: 1}, {Owner: `we"ird`, Repo: "repo", TotalReleases: 1}}
+
+	got := string(renderPrometheusAt(&output, now))
+	for _, want := range []string{
+		`gale_latest_release_age_seconds{owner="cli",repo="gh"} 3600`,
+		`gale_latest_release_age_seconds{owner="we\"ird",repo="repo"} 7200`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("renderPrometheusAt() = %s\nwant it to contain %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+// flagShorthands maps short flag names to the long names used in query files.
+var flagShorthands = map[string]string{
+	"c": "count",
+	"o": "output",
+	"f": "format",
+	"t": "token",
+	"q": "quiet",
+	"V": "verbose",
+	"h": "help",
+	"v": "version",
+}
+
+// queryFileExcluded lists flags that never belong in a saved query, either
+// because they are secrets or because they are not part of a fetch.
+var queryFileExcluded = map[string]bool{
+	"token":        true,
+	"help":         true,
+	"version":      true,
+	"print-schema": true,
+	"query-file":   true,
+	"config":       true,
+	"check-update": true,
+	"self-update":  true,
+}
+
+// loadQueryFile reads a query file: a JSON object whose keys are "owner",
+// "repo" or long flag names, and whose values are strings, numbers or bools.
+func loadQueryFile(fs *flag.FlagSet, path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read query file: %w", err)
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse query file %s: %w", path, err)
+	}
+
+	query := make(map[string]string, len(raw))
+	for key, value := range raw {
+		if key != "owner" && key != "repo" {
+			if fs.Lookup(key) == nil || flagShorthands[key] != "" {
+				return nil, fmt.Errorf("query file %s: unknown parameter %q", path, key)
+			}
+			if queryFileExcluded[key] {
+				return nil, fmt.Errorf("query file %s: %q cannot be saved in a query file", path, key)
+			}
+		}
+		switch v := value.(type) {
+		case string:
+			query[key] = v
+		case bool, float64:
+			query[key] = fmt.Sprint(v)
+		default:
+			return nil, fmt.Errorf("query file %s: %q must be a string, number or boolean", path, key)
+		}
+	}
+	return query, nil
+}
+
+// applyQueryFile loads path and sets every flag in it that was not given on
+// the command line. The saved owner and repo fill in missing positional
+// arguments; the resulting positional arguments are returned.
+func applyQueryFile(fs *flag.FlagSet, path string, args []string) ([]string, error) {
+	query, err := loadQueryFile(fs, path)
+	if err != nil {
+		return nil, err
+	}
+
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[longFlagName(f.Name)] = true
+	})
+	for key, value := range query {
+		if key == "owner" || key == "repo" || explicit[key] {
+			continue
+		}
+		if err := fs.Set(key, value); err != nil {
+			return nil, fmt.Errorf("query file %s: invalid value %q for %q: %w", path, value, key, err)
+		}
+	}
+
+	if len(args) == 0 && query["owner"] != "" {
+		args = append(args, query["owner"])
+	}
+	if len(args) == 1 && query["repo"] != "" {
+		args = append(args, query["repo"])
+	}
+	return args, nil
+}
+
+func longFlagName(name string) string {
+	if long, ok := flagShorthands[name]; ok {
+		return long
+	}
+	return name
+}
+
+// runSaveQuery implements `gale save-query file.json [owner] [repo] [options]`.
+// It writes the owner, repo and every option that was set to file.json so
+// that `gale --query-file file.json` repeats the same fetch.
+func runSaveQuery(args []string) error {
+	fs := flag.NewFlagSet("save-query", flag.ContinueOnError)
+	cfg := &Config{}
+	registerFlags(fs, cfg)
+
+	rest, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) == 0 {
+		return errors.New("usage: gale save-query <file.json> [owner] [repo] [options]")
+	}
+	path, repoArgs := rest[0], rest[1:]
+	if cfg.QueryFile != "" {
+		if repoArgs, err = applyQueryFile(fs, cfg.QueryFile, repoArgs); err != nil {
+			return err
+		}
+	}
+	if cfg.Diff != "" {
+		if repoArgs, err = splitDiffArgs(cfg, repoArgs); err != nil {
+			return err
+		}
+	}
+	if len(repoArgs) > 2 {
+		return errors.New("usage: gale save-query <file.json> [owner] [repo] [options]")
+	}
+	if err := setRepoArgs(cfg, repoArgs); err != nil {
+		return err
+	}
+	if len(cfg.Repos) > 0 {
+		return errors.New("save-query stores a single repository")
+	}
+	if err := validateConfig(cfg); err != nil {
+		return err
+	}
+
+	query := map[string]interface{}{
+		"owner": cfg.Owner,
+		"repo":  cfg.Repo,
+	}
+	tokenSkipped := false
+	fs.Visit(func(f *flag.Flag) {
+		name := longFlagName(f.Name)
+		if queryFileExcluded[name] {
+			tokenSkipped = tokenSkipped || name == "token"
+			return
+		}
+		value := f.Value.(flag.Getter).Get()
+		if d, ok := value.(time.Duration); ok {
+			// Save "2m" rather than nanoseconds so the file reads back.
+			value = d.String()
+		}
+		query[name] = value
+	})
+	if cfg.Diff != "" {
+		// Both tags go into the flag so the query file is self-contained.
+		query["diff"] = cfg.Diff + ".." + cfg.DiffTo
+	}
+
+	data, err := json.MarshalIndent(query, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal query: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write query file: %w", err)
+	}
+
+	if tokenSkipped {
+		warningLog("%s Not saving --token; pass it again or set GITHUB_TOKEN when running the query\n", icons["warning"])
+	}
+	successLog("%s Saved query for %s/%s to %s\n", icons["check"], cfg.Owner, cfg.Repo, path)
+	return nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestApplyQueryFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "query.json")
+	query := `{"owner": "cli", "repo": "gh", "count": 20, "channel": "beta", "null-empty-assets": true}`
+	if err := os.WriteFile(path, []byte(query), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name      string
+		args      []string
+		wantArgs  []string
+		wantCount int
+		wantChan  string
+	}{
+		{"File values", []string{}, []string{"cli", "gh"}, 20, channelBeta},
+		{"Flags override file", []string{"-c", "5", "--channel", "stable"}, []string{"cli", "gh"}, 5, channelStable},
+		{"Positionals override file", []string{"microsoft"}, []string{"microsoft", "gh"}, 20, channelBeta},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fs := flag.NewFlagSet(tc.name, flag.ContinueOnError)
+			cfg := &Config{}
+			registerFlags(fs, cfg)
+			args, err := parseInterspersed(fs, tc.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			args, err = applyQueryFile(fs, path, args)
+			if err != nil {
+				t.Fatalf("applyQueryFile() error = %v", err)
+			}
+			if !reflect.DeepEqual(args, tc.wantArgs) {
+				t.Errorf("applyQueryFile() args = %v, want %v", args, tc.wantArgs)
+			}
+			if cfg.Count != tc.wantCount || cfg.Channel != tc.wantChan || !cfg.NullEmptyAssets {
+				t.Errorf("applyQueryFile() count = %d, channel = %q, null-empty-assets = %v; want %d, %q, true",
+					cfg.Count, cfg.Channel, cfg.NullEmptyAssets, tc.wantCount, tc.wantChan)
+			}
+		})
+	}
+}
+
+func TestLoadQueryFileRejectsInvalid(t *testing.T) {
+	testCases := []struct {
+		name  string
+		query string
+	}{
+		{"Unknown parameter", `{"bogus": 1}`},
+		{"Shorthand flag", `{"c": 1}`},
+		{"Token", `{"token": "secret"}`},
+		{"Nested value", `{"count": [1]}`},
+		{"Not an object", `[]`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "query.json")
+			if err := os.WriteFile(path, []byte(tc.query), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			fs := flag.NewFlagSet(tc.name, flag.ContinueOnError)
+			registerFlags(fs, &Config{})
+			if _, err := loadQueryFile(fs, path); err == nil {
+				t.Errorf("loadQueryFile(%s) succeeded, want error", tc.query)
+			}
+		})
+	}
+}
+
+func TestSaveQueryRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "query.json")
+	if err := runSaveQuery([]string{path, "cli", "gh", "-c", "20", "--tag-regex", `^v(?P<major>\d+)`}); err != nil {
+		t.Fatalf("runSaveQuery() error = %v", err)
+	}
+
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	cfg := &Config{}
+	registerFlags(fs, cfg)
+	args, err := applyQueryFile(fs, path, nil)
+	if err != nil {
+		t.Fatalf("applyQueryFile() error = %v", err)
+	}
+	if err := setRepoArgs(cfg, args); err != nil {
+		t.Fatalf("setRepoArgs() error = %v", err)
+	}
+
+	if cfg.Owner != "cli" || cfg.Repo != "gh" || cfg.Count != 20 || cfg.TagRegex != `^v(?P<major>\d+)` {
+		t.Errorf("round trip = %s/%s count %d tag-regex %q, want cli/gh count 20 tag-regex %q",
+			cfg.Owner, cfg.Repo, cfg.Count, cfg.TagRegex, `^v(?P<major>\d+)`)
+	}
+}
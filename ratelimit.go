@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is wrapped by every RateLimitError.
+var ErrRateLimited = errors.New("GitHub API rate limit exceeded")
+
+// RateLimitError reports a primary or secondary rate limit and when
+// requests may be sent again.
+type RateLimitError struct {
+	Reset time.Time
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%v; retry after %s", ErrRateLimited, e.Reset.Local().Format("15:04:05 MST"))
+}
+
+func (e *RateLimitError) Unwrap() error {
+	return ErrRateLimited
+}
+
+// waitOnRateLimit makes GitHub requests sleep until a rate limit resets and
+// retry instead of failing with a RateLimitError. Set from --wait-on-ratelimit.
+var waitOnRateLimit bool
+
+// secondaryRateLimitWait is how long to back off when GitHub signals a
+// secondary rate limit without saying for how long, as its docs recommend.
+const secondaryRateLimitWait = time.Minute
+
+// rateLimitFromResponse returns a RateLimitError when res is a rate limited
+// response, or nil. Retry-After takes precedence over X-RateLimit-Reset.
+// GraphQL reports exhausted limits with a 200 status, so the remaining count
+// is checked regardless of status when limited is set by the caller.
+func rateLimitFromResponse(res *http.Response, limited bool, now time.Time) *RateLimitError {
+	remaining := res.Header.Get("X-RateLimit-Remaining")
+	retryAfter := res.Header.Get("Retry-After")
+	switch {
+	case res.StatusCode == http.StatusTooManyRequests:
+	case res.StatusCode == http.StatusForbidden && (remaining == "0" || retryAfter != ""):
+	case limited:
+	default:
+		return nil
+	}
+
+	if secs, err := strconv.Atoi(retryAfter); err == nil {
+		return &RateLimitError{Reset: now.Add(time.Duration(secs) * time.Second)}
+	}
+	if remaining == "0" {
+		if unix, err := strconv.ParseInt(res.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return &RateLimitError{Reset: time.Unix(unix, 0)}
+		}
+	}
+	return &RateLimitError{Reset: now.Add(secondaryRateLimitWait)}
+}
+
+// withRateLimitWait calls fn and, when waitOnRateLimit is set, sleeps until
+// the reset time of every RateLimitError it returns before trying again.
+// The sleep ends early with ctx's error when ctx is cancelled.
+func withRateLimitWait[T any](ctx context.Context, fn func() (T, error)) (T, error) {
+	for {
+		result, err := fn()
+		var limited *RateLimitError
+		if !waitOnRateLimit || !errors.As(err, &limited) {
+			return result, err
+		}
+
+		warningLog("%s Rate limited by GitHub; waiting until %s\n", icons["warning"], limited.Reset.Local().Format("15:04:05 MST"))
+		timer := time.NewTimer(time.Until(limited.Reset))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			var zero T
+			return zero, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// RateLimitStatus is the budget GitHub reported in the X-RateLimit headers
+// of a response. Resource tells the GraphQL and REST budgets apart.
+type RateLimitStatus struct {
+	Resource  string
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+// rateLimitStatusFromHeader reads the X-RateLimit headers of h. Responses
+// without them, such as those of asset downloads, report false.
+func rateLimitStatusFromHeader(h http.Header) (RateLimitStatus, bool) {
+	limit, errLimit := strconv.Atoi(h.Get("X-RateLimit-Limit"))
+	remaining, errRemaining := strconv.Atoi(h.Get("X-RateLimit-Remaining"))
+	reset, errReset := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64)
+	if errLimit != nil || errRemaining != nil || errReset != nil {
+		return RateLimitStatus{}, false
+	}
+	return RateLimitStatus{
+		Resource:  h.Get("X-RateLimit-Resource"),
+		Limit:     limit,
+		Remaining: remaining,
+		Reset:     time.Unix(reset, 0),
+	}, true
+}
+
+// summary formats s as "API: 4987/5000 remaining, resets in 42m".
+func (s RateLimitStatus) summary(now time.Time) string {
+	resets := "resets now"
+	if d := s.Reset.Sub(now); d > 0 {
+		minutes := int((d + time.Minute - 1) / time.Minute)
+		resets = fmt.Sprintf("resets in %dm", minutes)
+		if minutes >= 60 {
+			resets = fmt.Sprintf("resets in %dh%02dm", minutes/60, minutes%60)
+		}
+	}
+	return fmt.Sprintf("API: %d/%d remaining, %s", s.Remaining, s.Limit, resets)
+}
+
+// rateLimitTracker keeps the most recent RateLimitStatus seen on any API
+// response and counts the responses. It is safe for concurrent use by
+// parallel fetches.
+type rateLimitTracker struct {
+	mu       sync.Mutex
+	status   RateLimitStatus
+	ok       bool
+	requests int
+}
+
+// rateLimits tracks the budget of every GraphQL and REST API request.
+var rateLimits rateLimitTracker
+
+// record counts an API response and stores the status carried by its
+// header h, if any.
+func (t *rateLimitTracker) record(h http.Header) {
+	status, ok := rateLimitStatusFromHeader(h)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.requests++
+	if ok {
+		t.status, t.ok = status, true
+	}
+}
+
+// requestCount returns how many API responses were recorded.
+func (t *rateLimitTracker) requestCount() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.requests
+}
+
+// latest returns the most recently recorded status, or false if no API
+// response carried one, as when every release came from the cache.
+func (t *rateLimitTracker) latest() (RateLimitStatus, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.status, t.ok
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimitFromResponse(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	reset := now.Add(15 * time.Minute)
+
+	testCases := []struct {
+		name    string
+		status  int
+		headers map[string]string
+		limited bool
+		want    time.Time
+	}{
+		{"OK", 200, map[string]string{"X-RateLimit-Remaining": "10"}, false, time.Time{}},
+		{"Forbidden, not rate limited", 403, map[string]string{"X-RateLimit-Remaining": "10"}, false, time.Time{}},
+		{"Primary limit", 403, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "1717244100"}, false, reset},
+		{"Retry-After wins", 403, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "1717244100", "Retry-After": "30"}, false, now.Add(30 * time.Second)},
+		{"Secondary limit without hints", 429, nil, false, now.Add(secondaryRateLimitWait)},
+		{"GraphQL RATE_LIMITED", 200, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "1717244100"}, true, reset},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := &http.Response{StatusCode: tc.status, Header: http.Header{}}
+			for k, v := range tc.headers {
+				res.Header.Set(k, v)
+			}
+			got := rateLimitFromResponse(res, tc.limited, now)
+			if tc.want.IsZero() {
+				if got != nil {
+					t.Errorf("rateLimitFromResponse() = %v, want nil", got)
+				}
+				return
+			}
+			if got == nil || !got.Reset.Equal(tc.want) {
+				t.Errorf("rateLimitFromResponse() = %v, want reset at %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFetchGraphQLRateLimit(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 || r.URL.Query().Get("always") != "" {
+			w.Header().Set("Retry-After", r.URL.Query().Get("after"))
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data": {}}`))
+	}))
+	defer srv.Close()
+
+	oldEndpoint, oldWait := graphqlEndpoint, waitOnRateLimit
+	defer func() { graphqlEndpoint, waitOnRateLimit = oldEndpoint, oldWait }()
+
+	t.Run("Typed error without waiting", func(t *testing.T) {
+		requests, waitOnRateLimit, graphqlEndpoint = 0, false, srv.URL+"?after=0"
+		_, err := fetchGraphQL(context.Background(), githubGraphQLQuery, nil, "")
+		var limited *RateLimitError
+		if !errors.As(err, &limited) || !errors.Is(err, ErrRateLimited) {
+			t.Errorf("fetchGraphQL() error = %v, want RateLimitError", err)
+		}
+	})
+
+	t.Run("Waits and retries", func(t *testing.T) {
+		requests, waitOnRateLimit, graphqlEndpoint = 0, true, srv.URL+"?after=0"
+		if _, err := fetchGraphQL(context.Background(), githubGraphQLQuery, nil, ""); err != nil {
+			t.Fatalf("fetchGraphQL() error = %v", err)
+		}
+		if requests != 2 {
+			t.Errorf("fetchGraphQL() made %d requests, want 2", requests)
+		}
+	})
+
+	t.Run("Wait honors cancellation", func(t *testing.T) {
+		requests, waitOnRateLimit, graphqlEndpoint = 0, true, srv.URL+"?after=3600&always=1"
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		if _, err := fetchGraphQL(ctx, githubGraphQLQuery, nil, ""); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("fetchGraphQL() error = %v, want context.DeadlineExceeded", err)
+		}
+	})
+}
+
+func TestRateLimitStatusSummary(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		headers  map[string]string
+		expected string
+	}{
+		{map[string]string{"X-RateLimit-Limit": "5000", "X-RateLimit-Remaining": "4987", "X-RateLimit-Reset": "1717245720"}, "API: 4987/5000 remaining, resets in 42m"},
+		{map[string]string{"X-RateLimit-Limit": "5000", "X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "1717248030"}, "API: 0/5000 remaining, resets in 1h21m"},
+		{map[string]string{"X-RateLimit-Limit": "60", "X-RateLimit-Remaining": "59", "X-RateLimit-Reset": "1717243200"}, "API: 59/60 remaining, resets now"},
+		{map[string]string{"X-RateLimit-Remaining": "59"}, ""},
+	}
+
+	for _, tc := range testCases {
+		h := http.Header{}
+		for k, v := range tc.headers {
+			h.Set(k, v)
+		}
+		status, ok := rateLimitStatusFromHeader(h)
+		got := ""
+		if ok {
+			got = status.summary(now)
+		}
+		if got != tc.expected {
+			t.Errorf("summary of %v = %q, want %q", tc.headers, got, tc.expected)
+		}
+	}
+}
+
+func TestRateLimitTrackerRecordsGraphQL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "4990")
+		w.Header().Set("X-RateLimit-Reset", "1717245720")
+		w.Header().Set("X-RateLimit-Resource", "graphql")
+		_, _ = w.Write([]byte(`{"data":{"repository":{}}}`))
+	}))
+	defer srv.Close()
+
+	oldEndpoint := graphqlEndpoint
+	graphqlEndpoint = srv.URL
+	defer func() { graphqlEndpoint = oldEndpoint }()
+	defer func() { rateLimits = rateLimitTracker{} }()
+
+	before := rateLimits.requestCount()
+	if _, err := fetchGraphQL(context.Background(), "query { viewer { login } }", nil, ""); err != nil {
+		t.Fatalf("fetchGraphQL() error = %v", err)
+	}
+	status, ok := rateLimits.latest()
+	if !ok || status.Remaining != 4990 || status.Limit != 5000 || status.Resource != "graphql" {
+		t.Errorf("rateLimits.latest() = %+v, %v, want 4990/5000 graphql", status, ok)
+	}
+	if got := rateLimits.requestCount() - before; got != 1 {
+		t.Errorf("rateLimits.requestCount() grew by %d, want 1", got)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sync"
+)
+
+// rawRecorder keeps the bodies of the GraphQL responses of a run for --raw.
+type rawRecorder struct {
+	mu     sync.Mutex
+	bodies []json.RawMessage
+}
+
+// rawResponses is set when --raw is on; fetchGraphQLOnce records into it.
+var rawResponses *rawRecorder
+
+// record keeps a copy of body. It does nothing on a nil recorder.
+func (r *rawRecorder) record(body []byte) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bodies = append(r.bodies, append(json.RawMessage(nil), body...))
+}
+
+// document returns the recorded response when there is exactly one, byte for
+// byte, or a JSON array of them in the order they arrived when the releases
+// took several requests, e.g. one per page or repository.
+func (r *rawRecorder) document() ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch len(r.bodies) {
+	case 0:
+		return nil, errors.New("no GraphQL response to write for --raw")
+	case 1:
+		return r.bodies[0], nil
+	}
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, body := range r.bodies {
+		if i > 0 {
+			buf.WriteString(",\n")
+		}
+		buf.Write(bytes.TrimSpace(body))
+	}
+	buf.WriteString("]\n")
+	return buf.Bytes(), nil
+}
+
+// renderRaw writes the recorded GraphQL responses in place of output.
+func renderRaw(output *OutputFile, cfg *Config) ([]byte, error) {
+	return rawResponses.document()
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRawResponses(t *testing.T) {
+	const body = `{"data":{"repository":{"releases":{"totalCount":1,"nodes":[{"tagName":"v1.0.0","futureField":"kept"}]}}}}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+	oldEndpoint := graphqlEndpoint
+	graphqlEndpoint = srv.URL
+	defer func() { graphqlEndpoint = oldEndpoint }()
+	defer func() { rawResponses = nil }()
+
+	rawResponses = &rawRecorder{}
+	if _, err := fetchRepository(context.Background(), "Typeflu", "gale", 1, ""); err != nil {
+		t.Fatalf("fetchRepository() error = %v", err)
+	}
+	got, err := renderRaw(nil, &Config{})
+	if err != nil {
+		t.Fatalf("renderRaw() error = %v", err)
+	}
+	if string(got) != body {
+		t.Errorf("renderRaw() = %s, want the response unmodified: %s", got, body)
+	}
+
+	if _, err := fetchRepository(context.Background(), "Typeflu", "gale", 1, ""); err != nil {
+		t.Fatalf("fetchRepository() error = %v", err)
+	}
+	got, err = renderRaw(nil, &Config{})
+	if err != nil {
+		t.Fatalf("renderRaw() error = %v", err)
+	}
+	var responses []json.RawMessage
+	if err := json.Unmarshal(got, &responses); err != nil || len(responses) != 2 || string(responses[1]) != body {
+		t.Errorf("renderRaw() after two requests = %s (%v), want an array of both responses", got, err)
+	}
+
+	if _, err := (&rawRecorder{}).document(); err == nil || !strings.Contains(err.Error(), "--raw") {
+		t.Errorf("document() without responses error = %v, want one naming --raw", err)
+	}
+}
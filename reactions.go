@@ -0,0 +1,56 @@
+package main
+
+// ReactionGroup is the number of one kind of reaction on a release.
+type ReactionGroup struct {
+	Content  string `json:"content"`
+	Reactors struct {
+		TotalCount int `json:"totalCount"`
+	} `json:"reactors"`
+}
+
+// reactionEmoji maps GraphQL ReactionContent values to the emoji used as
+// keys of NormalizedRelease.Reactions.
+var reactionEmoji = map[string]string{
+	"THUMBS_UP":   "👍",
+	"THUMBS_DOWN": "👎",
+	"LAUGH":       "😄",
+	"HOORAY":      "🎉",
+	"CONFUSED":    "😕",
+	"HEART":       "❤️",
+	"ROCKET":      "🚀",
+	"EYES":        "👀",
+}
+
+// restReactionContent maps the keys of a REST reactions rollup to their
+// GraphQL ReactionContent values.
+var restReactionContent = map[string]string{
+	"+1":       "THUMBS_UP",
+	"-1":       "THUMBS_DOWN",
+	"laugh":    "LAUGH",
+	"hooray":   "HOORAY",
+	"confused": "CONFUSED",
+	"heart":    "HEART",
+	"rocket":   "ROCKET",
+	"eyes":     "EYES",
+}
+
+// reactionCounts turns reaction groups into a map of emoji to count,
+// leaving out reactions nobody used. It returns nil when there are none, so
+// the field is omitted from the output.
+func reactionCounts(groups []ReactionGroup) map[string]int {
+	var counts map[string]int
+	for _, g := range groups {
+		if g.Reactors.TotalCount == 0 {
+			continue
+		}
+		emoji, ok := reactionEmoji[g.Content]
+		if !ok {
+			emoji = g.Content
+		}
+		if counts == nil {
+			counts = make(map[string]int)
+		}
+		counts[emoji] += g.Reactors.TotalCount
+	}
+	return counts
+}
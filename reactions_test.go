@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestReactionCounts(t *testing.T) {
+	var groups []ReactionGroup
+	data := `[
+		{"content": "THUMBS_UP", "reactors": {"totalCount": 12}},
+		{"content": "HOORAY", "reactors": {"totalCount": 3}},
+		{"content": "CONFUSED", "reactors": {"totalCount": 0}}
+	]`
+	if err := json.Unmarshal([]byte(data), &groups); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		groups   []ReactionGroup
+		expected map[string]int
+	}{
+		{groups, map[string]int{"👍": 12, "🎉": 3}},
+		{groups[2:], nil},
+		{nil, nil},
+	}
+
+	for _, tc := range testCases {
+		if got := reactionCounts(tc.groups); !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("reactionCounts(%v) = %v, want %v", tc.groups, got, tc.expected)
+		}
+	}
+}
+
+func TestRestReleaseNodeReactions(t *testing.T) {
+	var r restRelease
+	data := `{"tag_name": "v1.0.0", "reactions": {"url": "https://api.github.com/reactions", "total_count": 5, "+1": 2, "-1": 0, "rocket": 3}}`
+	if err := json.Unmarshal([]byte(data), &r); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { includeFields = releaseFieldOptions{} }()
+
+	includeFields = releaseFieldOptions{}
+	if node := r.releaseNode(); node.ReactionGroups != nil {
+		t.Errorf("releaseNode() without Reactions = %v, want none", node.ReactionGroups)
+	}
+
+	includeFields = releaseFieldOptions{Reactions: true}
+	got := normalizeData([]ReleaseNode{r.releaseNode()})[0].Reactions
+	if want := map[string]int{"👍": 2, "🚀": 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("reactions = %v, want %v", got, want)
+	}
+}
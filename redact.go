@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const redacted = "***"
+
+// sensitiveHeaders are replaced wholesale in logged requests.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+}
+
+// redactSecrets replaces every occurrence of the given secrets in s. Empty
+// secrets are ignored so that an unset token never matches everything.
+func redactSecrets(s string, secrets ...string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, redacted)
+		}
+	}
+	return s
+}
+
+// knownSecrets are the credentials of this run, registered as soon as they
+// are known so that main can scrub them from any error it prints.
+var (
+	knownSecretsMu sync.Mutex
+	knownSecrets   []string
+)
+
+// registerSecret adds secret to the credentials redactKnownSecrets removes.
+func registerSecret(secret string) {
+	if secret == "" {
+		return
+	}
+	knownSecretsMu.Lock()
+	defer knownSecretsMu.Unlock()
+	knownSecrets = append(knownSecrets, secret)
+}
+
+// redactKnownSecrets replaces every registered secret in s.
+func redactKnownSecrets(s string) string {
+	knownSecretsMu.Lock()
+	defer knownSecretsMu.Unlock()
+	return redactSecrets(s, knownSecrets...)
+}
+
+// jsonTokenField matches a JSON string field holding a credential, such as
+// the "token" of a GitHub App installation token or the "access_token" of
+// an OAuth token response.
+var jsonTokenField = regexp.MustCompile(`("(?:[a-z]+_)?token"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+
+// redactTokenFields masks the value of every credential field in a JSON
+// body, whether or not the credential is a registered secret yet.
+func redactTokenFields(body string) string {
+	return jsonTokenField.ReplaceAllString(body, `$1"`+redacted+`"`)
+}
+
+// loggingTransport writes one line per request and response to w, with the
+// time the response took. With bodies set it also dumps JSON response
+// bodies, such as GraphQL results, but never asset downloads or minted
+// installation tokens. Secrets are looked up as each line is written, so a
+// token registered after the client was built is redacted too; credential
+// headers and token fields in bodies never reach the log either.
+type loggingTransport struct {
+	next   http.RoundTripper
+	w      io.Writer
+	bodies bool
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	fmt.Fprintln(t.w, redactKnownSecrets(fmt.Sprintf("> %s %s %s", req.Method, req.URL, formatHeaders(req.Header))))
+
+	start := time.Now()
+	res, err := t.next.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		fmt.Fprintln(t.w, redactKnownSecrets(fmt.Sprintf("< %s %s failed after %s: %v", req.Method, req.URL, elapsed, err)))
+		return nil, err
+	}
+	fmt.Fprintln(t.w, redactKnownSecrets(fmt.Sprintf("< %s (%s) %s", res.Status, elapsed, formatHeaders(res.Header))))
+
+	if t.bodies && strings.Contains(res.Header.Get("Content-Type"), "json") && !strings.HasSuffix(req.URL.Path, "/access_tokens") {
+		body, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		res.Body = io.NopCloser(bytes.NewReader(body))
+		fmt.Fprintln(t.w, redactKnownSecrets(redactTokenFields(string(body))))
+	}
+	return res, nil
+}
+
+// formatHeaders renders h on one line in a stable order, masking the values
+// of sensitiveHeaders.
+func formatHeaders(h http.Header) string {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		value := strings.Join(h[name], ", ")
+		if sensitiveHeaders[http.CanonicalHeaderKey(name)] {
+			value = redacted
+		}
+		parts = append(parts, fmt.Sprintf("%s: %s", name, value))
+	}
+	return "[" + strings.Join(parts, "; ") + "]"
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestLoggingTransportRedactsToken(t *testing.T) {
+	const token = "ghp_supersecrettoken"
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Echo", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	defer func() { knownSecrets = nil }()
+	var log strings.Builder
+	client := &http.Client{Transport: &loggingTransport{next: http.DefaultTransport, w: &log}}
+	// Registered after the client is built, as an installation token is.
+	registerSecret(token)
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/releases?access_token="+token, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "bearer "+token)
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if strings.Contains(log.String(), token) {
+		t.Errorf("log contains the raw token:\n%s", log.String())
+	}
+	if !strings.Contains(log.String(), "Authorization: "+redacted) {
+		t.Errorf("log does not show a redacted Authorization header:\n%s", log.String())
+	}
+}
+
+func TestFetchGraphQLRedactsErrorBody(t *testing.T) {
+	const token = "ghp_supersecrettoken"
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad credentials: "+r.Header.Get("Authorization"), http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	oldEndpoint := graphqlEndpoint
+	graphqlEndpoint = srv.URL
+	defer func() { graphqlEndpoint = oldEndpoint }()
+
+	_, err := fetchGraphQL(context.Background(), githubGraphQLQuery, nil, token)
+	if err == nil {
+		t.Fatal("fetchGraphQL() succeeded, want error")
+	}
+	if strings.Contains(err.Error(), token) {
+		t.Errorf("fetchGraphQL() error contains the raw token: %v", err)
+	}
+}
+
+func TestErrorsNeverContainToken(t *testing.T) {
+	const token = "ghp_fakeTokenForRedaction"
+
+	testCases := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{
+			name: "graphql server error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "upstream saw "+r.Header.Get("Authorization"), http.StatusInternalServerError)
+			},
+		},
+		{
+			name: "graphql error message",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.WriteString(w, `{"errors":[{"message":"token `+token+` is not allowed here"}]}`)
+			},
+		},
+		{
+			name: "rest error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, `{"message":"bad token `+token+`"}`, http.StatusForbidden)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(tc.handler)
+			defer srv.Close()
+
+			oldEndpoint, oldREST := graphqlEndpoint, restAPIURL
+			graphqlEndpoint, restAPIURL = srv.URL, srv.URL
+			defer func() { graphqlEndpoint, restAPIURL = oldEndpoint, oldREST }()
+
+			for _, fetch := range []func(context.Context, string, string, int, string) (*GraphQLResponse, error){fetchRepository, fetchRepositoryREST} {
+				_, err := fetch(context.Background(), "cli", "gh", 1, token)
+				if err == nil {
+					t.Fatal("fetch succeeded, want error")
+				}
+				if strings.Contains(err.Error(), token) {
+					t.Errorf("error contains the raw token: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestRedactKnownSecrets(t *testing.T) {
+	defer func() { knownSecrets = nil }()
+	registerSecret("")
+	registerSecret("ghp_registered")
+
+	if got, want := redactKnownSecrets("auth failed for ghp_registered"), "auth failed for ***"; got != want {
+		t.Errorf("redactKnownSecrets() = %q, want %q", got, want)
+	}
+}
+
+func TestRedactSecrets(t *testing.T) {
+	testCases := []struct {
+		s       string
+		secrets []string
+		want    string
+	}{
+		{"token abc in abc", []string{"abc"}, "token *** in ***"},
+		{"nothing to hide", []string{""}, "nothing to hide"},
+		{"id=x1 key=y2", []string{"x1", "y2"}, "id=*** key=***"},
+	}
+
+	for _, tc := range testCases {
+		if got := redactSecrets(tc.s, tc.secrets...); got != tc.want {
+			t.Errorf("redactSecrets(%q, %q) = %q, want %q", tc.s, tc.secrets, got, tc.want)
+		}
+	}
+}
+
+func TestLoggingTransportSkipsInstallationToken(t *testing.T) {
+	const minted = "ghs_mintedinstallationtoken"
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"tok":"` + minted + `","expires_at":"2024-03-01T12:00:00Z"}`))
+	}))
+	defer srv.Close()
+
+	var log strings.Builder
+	client := &http.Client{Transport: &loggingTransport{next: http.DefaultTransport, w: &log, bodies: true}}
+	res, err := client.Post(srv.URL+"/app/installations/42/access_tokens", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+
+	if !strings.Contains(string(body), minted) {
+		t.Errorf("response body = %s, want the minted token", body)
+	}
+	if strings.Contains(log.String(), minted) {
+		t.Errorf("log shows the minted installation token:\n%s", log.String())
+	}
+}
+
+func TestRedactTokenFields(t *testing.T) {
+	testCases := []struct {
+		body string
+		want string
+	}{
+		{`{"token":"ghs_abc","expires_at":"2024-03-01T12:00:00Z"}`, `{"token":"***","expires_at":"2024-03-01T12:00:00Z"}`},
+		{`{"access_token": "ya29.a\"b", "token_type": "Bearer"}`, `{"access_token": "***", "token_type": "Bearer"}`},
+		{`{"refresh_token":"1//x","id_token":"eyJ"}`, `{"refresh_token":"***","id_token":"***"}`},
+		{`{"data":{"viewer":{"login":"octocat"}}}`, `{"data":{"viewer":{"login":"octocat"}}}`},
+	}
+
+	for _, tc := range testCases {
+		if got := redactTokenFields(tc.body); got != tc.want {
+			t.Errorf("redactTokenFields(%s) = %s, want %s", tc.body, got, tc.want)
+		}
+	}
+}
+
+func TestLoggingTransportDumpsJSONBodies(t *testing.T) {
+	const token = "ghp_supersecrettoken"
+	defer func() { knownSecrets = nil }()
+	registerSecret(token)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/asset.zip" {
+			w.Header().Set("Content-Type", "application/zip")
+			_, _ = w.Write([]byte("PK-binary"))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_, _ = w.Write([]byte(`{"data":{"viewer":{"login":"octocat"}},"echo":"` + token + `"}`))
+	}))
+	defer srv.Close()
+
+	testCases := []struct {
+		bodies   bool
+		path     string
+		wantBody bool
+	}{
+		{false, "/graphql", false},
+		{true, "/graphql", true},
+		{true, "/asset.zip", false},
+	}
+
+	for _, tc := range testCases {
+		var log strings.Builder
+		client := &http.Client{Transport: &loggingTransport{next: http.DefaultTransport, w: &log, bodies: tc.bodies}}
+		res, err := client.Get(srv.URL + tc.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+
+		if len(body) == 0 {
+			t.Errorf("bodies %v, %s: response body was consumed by the log", tc.bodies, tc.path)
+		}
+		if got := strings.Contains(log.String(), `"login":"octocat"`); got != tc.wantBody {
+			t.Errorf("bodies %v, %s: log shows body = %v, want %v:\n%s", tc.bodies, tc.path, got, tc.wantBody, log.String())
+		}
+		if strings.Contains(log.String(), "PK-binary") || strings.Contains(log.String(), token) {
+			t.Errorf("bodies %v, %s: log shows an asset body or the token:\n%s", tc.bodies, tc.path, log.String())
+		}
+		if !regexp.MustCompile(`< 200 OK \(\d+m?s\)`).MatchString(log.String()) {
+			t.Errorf("bodies %v, %s: log has no response timing:\n%s", tc.bodies, tc.path, log.String())
+		}
+	}
+}
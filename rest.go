@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var restAPIURL = defaultAPIURL
+
+// restRelease is the subset of a REST release that gale uses.
+type restRelease struct {
+	NodeID      string     `json:"node_id"`
+	Name        string     `json:"name"`
+	TagName     string     `json:"tag_name"`
+	PublishedAt *time.Time `json:"published_at"`
+	Prerelease  bool       `json:"prerelease"`
+	Draft       bool       `json:"draft"`
+	HTMLURL     string     `json:"html_url"`
+	Body        string     `json:"body"`
+	Author      *struct {
+		Login   string `json:"login"`
+		HTMLURL string `json:"html_url"`
+	} `json:"author"`
+	// Reactions also holds a url and total_count besides the counts.
+	Reactions map[string]json.RawMessage `json:"reactions"`
+	Assets    []struct {
+		NodeID             string `json:"node_id"`
+		Name               string `json:"name"`
+		Size               int64  `json:"size"`
+		BrowserDownloadURL string `json:"browser_download_url"`
+		ContentType        string `json:"content_type"`
+		DownloadCount      int    `json:"download_count"`
+	} `json:"assets"`
+}
+
+// releaseNode maps a REST release onto the GraphQL ReleaseNode, so both
+// APIs share normalizeData and produce the same output.
+func (r restRelease) releaseNode() ReleaseNode {
+	node := ReleaseNode{
+		ID:           r.NodeID,
+		Name:         r.Name,
+		TagName:      r.TagName,
+		IsPrerelease: r.Prerelease,
+		IsDraft:      r.Draft,
+		URL:          r.HTMLURL,
+		Description:  r.Body,
+	}
+	// REST only has target_commitish, the branch or commit a new tag is
+	// created from, so TagCommit stays unset.
+	if r.Author != nil {
+		// REST always sends the profile URL; keep it opt-in as with GraphQL.
+		node.Author = &Actor{Login: r.Author.Login}
+		if includeFields.Author {
+			node.Author.URL = r.Author.HTMLURL
+		}
+	}
+	if includeFields.Reactions {
+		for key, raw := range r.Reactions {
+			content, ok := restReactionContent[key]
+			if !ok {
+				continue
+			}
+			g := ReactionGroup{Content: content}
+			if json.Unmarshal(raw, &g.Reactors.TotalCount) == nil {
+				node.ReactionGroups = append(node.ReactionGroups, g)
+			}
+		}
+	}
+	if r.PublishedAt != nil {
+		node.PublishedAt = *r.PublishedAt
+	}
+	node.ReleaseAssets.TotalCount = len(r.Assets)
+	for _, a := range r.Assets {
+		node.ReleaseAssets.Nodes = append(node.ReleaseAssets.Nodes, AssetNode{
+			ID:            a.NodeID,
+			Name:          a.Name,
+			Size:          a.Size,
+			DownloadURL:   a.BrowserDownloadURL,
+			ContentType:   a.ContentType,
+			DownloadCount: a.DownloadCount,
+		})
+	}
+	return node
+}
+
+// fetchRepositoryREST is fetchRepository for GitHub instances without
+// GraphQL. The releases come from the REST API but are returned in the
+// GraphQL response shape.
+func fetchRepositoryREST(ctx context.Context, owner, repo string, count int, token string) (*GraphQLResponse, error) {
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/releases", restAPIURL, url.PathEscape(owner), url.PathEscape(repo))
+
+	if count == countAll {
+		count = math.MaxInt
+	}
+	var releases []restRelease
+	var res *http.Response
+	perPage := min(count, releasesPageSize)
+	for page := 1; len(releases) < count; page++ {
+		var batch []restRelease
+		pageRes, err := fetchREST(ctx, fmt.Sprintf("%s?per_page=%d&page=%d", endpoint, perPage, page), token, &batch)
+		if err != nil {
+			return nil, err
+		}
+		if res == nil {
+			res = pageRes
+		}
+		releases = append(releases, batch...)
+		if len(batch) < perPage {
+			break
+		}
+	}
+	releases = releases[:min(len(releases), count)]
+
+	// REST has no total count, but with one release per page the number of
+	// the last page is the number of releases.
+	var single []restRelease
+	countRes, err := fetchREST(ctx, endpoint+"?per_page=1", token, &single)
+	if err != nil {
+		return nil, err
+	}
+	total := lastPage(countRes.Header.Get("Link"))
+	if total == 0 {
+		total = len(single)
+	}
+
+	result := &GraphQLResponse{Data: &GraphQLData{Repository: &Repository{}}}
+	result.Data.Repository.Releases.TotalCount = total
+	for _, r := range releases {
+		result.Data.Repository.Releases.Nodes = append(result.Data.Repository.Releases.Nodes, r.releaseNode())
+	}
+	if expiry := res.Header.Get("GitHub-Authentication-Token-Expiration"); expiry != "" {
+		if t, err := parseTokenExpiration(expiry); err == nil {
+			result.TokenExpiresAt = t
+		}
+	}
+	return result, nil
+}
+
+// fetchREST GETs endpoint and decodes the JSON body into out. A 404 is
+// reported as ErrRepoNotFound, matching the GraphQL path.
+func fetchREST(ctx context.Context, endpoint, token string, out interface{}) (*http.Response, error) {
+	return withRateLimitWait(ctx, func() (*http.Response, error) {
+		return fetchRESTOnce(ctx, endpoint, token, out)
+	})
+}
+
+func fetchRESTOnce(ctx context.Context, endpoint, token string, out interface{}) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	req.Header.Set("User-Agent", userAgent)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNetwork, err)
+	}
+	defer func() {
+		if closeErr := res.Body.Close(); closeErr != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to close response body: %v\n", closeErr)
+		}
+	}()
+
+	rateLimits.record(res.Header)
+	if limited := rateLimitFromResponse(res, false, time.Now()); limited != nil {
+		return nil, limited
+	}
+	if res.StatusCode == http.StatusNotFound {
+		return nil, ErrRepoNotFound
+	}
+	if res.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("%w (status 401); check --token or GITHUB_TOKEN", ErrUnauthorized)
+	}
+	if res.StatusCode >= 400 {
+		resBody, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf("GitHub API responded with status %d: %s", res.StatusCode, redactSecrets(string(resBody), token))
+	}
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		return nil, fmt.Errorf("failed to decode GitHub API response: %w", err)
+	}
+	return res, nil
+}
+
+var lastPagePattern = regexp.MustCompile(`[?&]page=(\d+)[^>]*>;\s*rel="last"`)
+
+// lastPage extracts the page number of the rel="last" link, or 0 when the
+// response fits on one page.
+func lastPage(link string) int {
+	m := lastPagePattern.FindStringSubmatch(link)
+	if m == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(m[1])
+	return n
+}
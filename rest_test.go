@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFetchRepositoryREST(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/repos/Typeflu/missing/releases" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Query().Get("per_page") == "1" {
+			w.Header().Set("Link", fmt.Sprintf(`<%s%s?per_page=1&page=2>; rel="next", <%s%s?per_page=1&page=42>; rel="last"`, "http://x", r.URL.Path, "http://x", r.URL.Path))
+			_, _ = w.Write([]byte(`[{"tag_name": "v2.0.0"}]`))
+			return
+		}
+		_, _ = w.Write([]byte(`[
+			{"node_id": "RE_2", "name": "", "tag_name": "v2.0.0", "published_at": "2024-05-01T10:00:00Z",
+			 "html_url": "https://github.com/Typeflu/gale/releases/tag/v2.0.0", "body": "notes", "author": {"login": "Typeflu"},
+			 "assets": [{"node_id": "RA_1", "name": "gale.zip", "size": 2048, "browser_download_url": "https://example.com/gale.zip",
+			             "content_type": "application/zip", "download_count": 17}]},
+			{"node_id": "RE_1", "tag_name": "v2.1.0-rc", "draft": true, "prerelease": true, "published_at": null, "author": null, "assets": []}
+		]`))
+	}))
+	defer srv.Close()
+
+	oldURL := restAPIURL
+	restAPIURL = srv.URL
+	defer func() { restAPIURL = oldURL }()
+
+	res, err := fetchRepositoryREST(context.Background(), "Typeflu", "gale", 10, "")
+	if err != nil {
+		t.Fatalf("fetchRepositoryREST() error = %v", err)
+	}
+	if got := res.Data.Repository.Releases.TotalCount; got != 42 {
+		t.Errorf("TotalCount = %d, want 42", got)
+	}
+
+	releases := normalizeData(res.Data.Repository.Releases.Nodes)
+	if len(releases) != 2 {
+		t.Fatalf("got %d releases, want 2", len(releases))
+	}
+	r := releases[0]
+	if r.ID != "RE_2" || r.Name != "v2.0.0" || r.Author != "Typeflu" || !r.PublishedAt.Equal(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)) || r.AssetCount != 1 || r.DownloadCount != 17 {
+		t.Errorf("release = %+v", r)
+	}
+	a := r.Assets[0]
+	if a.DownloadCount != 17 || a.SizeFormatted != "2.0 KB" || a.DownloadURL != "https://example.com/gale.zip" {
+		t.Errorf("asset = %+v", a)
+	}
+	if d := releases[1]; !d.IsDraft || !d.IsPrerelease || !d.PublishedAt.IsZero() || d.Author != "" {
+		t.Errorf("draft release = %+v", d)
+	}
+
+	if _, err := fetchRepositoryREST(context.Background(), "Typeflu", "missing", 10, ""); !errors.Is(err, ErrRepoNotFound) {
+		t.Errorf("fetchRepositoryREST(missing) error = %v, want ErrRepoNotFound", err)
+	}
+}
+
+func TestLastPage(t *testing.T) {
+	testCases := []struct {
+		link string
+		want int
+	}{
+		{`<https://api.github.com/repositories/1/releases?per_page=1&page=2>; rel="next", <https://api.github.com/repositories/1/releases?per_page=1&page=214>; rel="last"`, 214},
+		{`<https://api.github.com/repositories/1/releases?page=1>; rel="prev"`, 0},
+		{"", 0},
+	}
+
+	for _, tc := range testCases {
+		if got := lastPage(tc.link); got != tc.want {
+			t.Errorf("lastPage(%q) = %d, want %d", tc.link, got, tc.want)
+		}
+	}
+}
+
+func TestAPIEndpoints(t *testing.T) {
+	testCases := []struct {
+		base        string
+		wantREST    string
+		wantGraphQL string
+		wantErr     bool
+	}{
+		{"", "https://api.github.com", "https://api.github.com/graphql", false},
+		{"https://api.github.com/", "https://api.github.com", "https://api.github.com/graphql", false},
+		{"https://github.mycorp.com/api/v3", "https://github.mycorp.com/api/v3", "https://github.mycorp.com/api/graphql", false},
+		{"https://github.mycorp.com/api/v3/", "https://github.mycorp.com/api/v3", "https://github.mycorp.com/api/graphql", false},
+		{"github.mycorp.com", "", "", true},
+		{"ftp://github.mycorp.com", "", "", true},
+	}
+
+	for _, tc := range testCases {
+		rest, graphql, err := apiEndpoints(tc.base)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("apiEndpoints(%q) error = %v, wantErr %v", tc.base, err, tc.wantErr)
+			continue
+		}
+		if rest != tc.wantREST || graphql != tc.wantGraphQL {
+			t.Errorf("apiEndpoints(%q) = %q, %q, want %q, %q", tc.base, rest, graphql, tc.wantREST, tc.wantGraphQL)
+		}
+	}
+}
+
+func TestRestReleaseNodeAuthor(t *testing.T) {
+	var r restRelease
+	if err := json.Unmarshal([]byte(`{"tag_name": "v1.0.0", "author": {"login": "octocat", "url": "https://api.github.com/users/octocat", "html_url": "https://github.com/octocat"}}`), &r); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { includeFields = releaseFieldOptions{} }()
+
+	testCases := []struct {
+		include bool
+		wantURL string
+	}{
+		{false, ""},
+		{true, "https://github.com/octocat"},
+	}
+
+	for _, tc := range testCases {
+		includeFields = releaseFieldOptions{Author: tc.include}
+		node := r.releaseNode()
+		if node.Author == nil || node.Author.Login != "octocat" || node.Author.URL != tc.wantURL {
+			t.Errorf("releaseNode() author with Author %v = %+v, want octocat at %q", tc.include, node.Author, tc.wantURL)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// jsonSchemaDraft is the JSON Schema version --print-schema targets.
+const jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaBuilder turns Go types into JSON Schema, following the json struct
+// tags the way encoding/json does. Every struct is described once under
+// $defs and referenced by name.
+type schemaBuilder struct {
+	defs map[string]interface{}
+}
+
+func (b *schemaBuilder) schema(t reflect.Type) map[string]interface{} {
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Pointer:
+		return b.schema(t.Elem())
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		// encoding/json writes a nil slice as null.
+		return map[string]interface{}{"type": []string{"array", "null"}, "items": b.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": b.schema(t.Elem())}
+	case reflect.Struct:
+		if _, ok := b.defs[t.Name()]; !ok {
+			b.defs[t.Name()] = nil // break cycles before recursing
+			b.defs[t.Name()] = b.object(t)
+		}
+		return map[string]interface{}{"$ref": "#/$defs/" + t.Name()}
+	}
+	return map[string]interface{}{}
+}
+
+// object describes the JSON object encoding/json writes for struct t.
+// Fields without omitempty are always present, so they are required.
+func (b *schemaBuilder) object(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	required := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		properties[name] = b.schema(f.Type)
+		if !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	}
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// outputSchema returns the JSON Schema of the document gale writes with
+// --format json, generated from the struct tags of OutputFile.
+func outputSchema() map[string]interface{} {
+	b := &schemaBuilder{defs: make(map[string]interface{})}
+	root := b.object(reflect.TypeOf(OutputFile{}))
+	root["$schema"] = jsonSchemaDraft
+	root["title"] = "gale output"
+	root["$defs"] = b.defs
+	return root
+}
+
+// printSchema writes outputSchema to stdout for --print-schema.
+func printSchema() error {
+	data, err := json.MarshalIndent(outputSchema(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal schema: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
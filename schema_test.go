@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestOutputSchema(t *testing.T) {
+	schema := outputSchema()
+	if schema["$schema"] != jsonSchemaDraft {
+		t.Errorf("$schema = %v, want %s", schema["$schema"], jsonSchemaDraft)
+	}
+	defs := schema["$defs"].(map[string]interface{})
+	release := defs["NormalizedRelease"].(map[string]interface{})
+	props := release["properties"].(map[string]interface{})
+	required := release["required"].([]string)
+
+	if !slices.Contains(required, "version") || slices.Contains(required, "commitSha") {
+		t.Errorf("NormalizedRelease required = %v, want version but not the omitempty commitSha", required)
+	}
+	if want := map[string]interface{}{"type": "string", "format": "date-time"}; !reflect.DeepEqual(props["publishedAt"], want) {
+		t.Errorf("publishedAt = %v, want %v", props["publishedAt"], want)
+	}
+	assets := props["assets"].(map[string]interface{})
+	if items := assets["items"].(map[string]interface{}); items["$ref"] != "#/$defs/NormalizedAsset" {
+		t.Errorf("assets items = %v, want a reference to NormalizedAsset", items)
+	}
+
+	// Every key gale writes must be described by the schema.
+	output := newOutputFile("cli", "gh", 1, []NormalizedRelease{{Version: "v1.0.0", PublishedAt: time.Now(), Assets: []NormalizedAsset{{Name: "gh.zip"}}}})
+	data, err := json.Marshal(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	checkKeys := func(name string, obj map[string]interface{}, def map[string]interface{}) {
+		props := def["properties"].(map[string]interface{})
+		for key := range obj {
+			if _, ok := props[key]; !ok {
+				t.Errorf("%s key %q is missing from the schema", name, key)
+			}
+		}
+	}
+	checkKeys("output", doc, schema)
+	checkKeys("release", doc["releases"].([]interface{})[0].(map[string]interface{}), release)
+}
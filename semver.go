@@ -0,0 +1,217 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	sortDate   = "date"
+	sortSemver = "semver"
+)
+
+// semVersion is a parsed semantic version. Build metadata is dropped since
+// it does not affect precedence.
+type semVersion struct {
+	Major, Minor, Patch uint64
+	Pre                 []string
+}
+
+// parseSemver parses tag as MAJOR.MINOR.PATCH[-PRERELEASE][+BUILD], with an
+// optional leading "v".
+func parseSemver(tag string) (semVersion, bool) {
+	s := strings.TrimPrefix(tag, "v")
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		s = s[:i]
+	}
+	var v semVersion
+	core, pre, hasPre := strings.Cut(s, "-")
+	if hasPre {
+		if pre == "" {
+			return semVersion{}, false
+		}
+		v.Pre = strings.Split(pre, ".")
+		for _, id := range v.Pre {
+			if id == "" {
+				return semVersion{}, false
+			}
+		}
+	}
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return semVersion{}, false
+	}
+	nums := [3]*uint64{&v.Major, &v.Minor, &v.Patch}
+	for i, p := range parts {
+		if p == "" || (len(p) > 1 && p[0] == '0') {
+			return semVersion{}, false
+		}
+		n, err := strconv.ParseUint(p, 10, 64)
+		if err != nil {
+			return semVersion{}, false
+		}
+		*nums[i] = n
+	}
+	return v, true
+}
+
+// compare returns -1, 0 or 1 following semver precedence: a prerelease
+// sorts before its release, numeric identifiers compare numerically and
+// before alphanumeric ones.
+func (v semVersion) compare(o semVersion) int {
+	for _, d := range [][2]uint64{{v.Major, o.Major}, {v.Minor, o.Minor}, {v.Patch, o.Patch}} {
+		if d[0] != d[1] {
+			if d[0] < d[1] {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case len(v.Pre) == 0 && len(o.Pre) == 0:
+		return 0
+	case len(v.Pre) == 0:
+		return 1
+	case len(o.Pre) == 0:
+		return -1
+	}
+	for i := 0; i < len(v.Pre) && i < len(o.Pre); i++ {
+		a, b := v.Pre[i], o.Pre[i]
+		na, errA := strconv.ParseUint(a, 10, 64)
+		nb, errB := strconv.ParseUint(b, 10, 64)
+		switch {
+		case errA == nil && errB == nil && na != nb:
+			if na < nb {
+				return -1
+			}
+			return 1
+		case errA == nil && errB != nil:
+			return -1
+		case errA != nil && errB == nil:
+			return 1
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+	}
+	switch {
+	case len(v.Pre) < len(o.Pre):
+		return -1
+	case len(v.Pre) > len(o.Pre):
+		return 1
+	}
+	return 0
+}
+
+// sortSemverReleases orders releases by semantic version, highest first.
+// Tags that are not semver keep their relative order after all the others.
+func sortSemverReleases(releases []NormalizedRelease) {
+	sort.SliceStable(releases, func(i, j int) bool {
+		a, okA := parseSemver(releases[i].Version)
+		b, okB := parseSemver(releases[j].Version)
+		if okA != okB {
+			return okA
+		}
+		return okA && a.compare(b) > 0
+	})
+}
+
+// dropNonSemver keeps only the releases whose tag parses as semver.
+func dropNonSemver(releases []NormalizedRelease) []NormalizedRelease {
+	kept := releases[:0]
+	for _, r := range releases {
+		if _, ok := parseSemver(r.Version); ok {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+// semverComparator is one condition of a range, such as ">=1.2.0".
+type semverComparator struct {
+	op      string
+	version semVersion
+}
+
+func (c semverComparator) matches(v semVersion) bool {
+	cmp := v.compare(c.version)
+	switch c.op {
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+	return cmp == 0
+}
+
+// semverRange is a set of alternatives joined by "||", each a list of
+// comparators that must all hold.
+type semverRange [][]semverComparator
+
+// parseSemverRange parses ranges like ">=1.2.0 <2.0.0" or
+// "<1.0.0 || >=2.0.0". A bare version means "=".
+func parseSemverRange(s string) (semverRange, error) {
+	var r semverRange
+	for _, alt := range strings.Split(s, "||") {
+		fields := strings.Fields(alt)
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("invalid --semver-range %q: empty condition", s)
+		}
+		var set []semverComparator
+		for _, f := range fields {
+			op := ""
+			for _, candidate := range []string{">=", "<=", ">", "<", "="} {
+				if strings.HasPrefix(f, candidate) {
+					op = candidate
+					break
+				}
+			}
+			v, ok := parseSemver(f[len(op):])
+			if !ok {
+				return nil, fmt.Errorf("invalid --semver-range %q: cannot parse %q", s, f)
+			}
+			set = append(set, semverComparator{op: op, version: v})
+		}
+		r = append(r, set)
+	}
+	return r, nil
+}
+
+// filter keeps the releases whose tag is semver and satisfies the range.
+func (r semverRange) filter(releases []NormalizedRelease) []NormalizedRelease {
+	kept := releases[:0]
+	for _, rel := range releases {
+		if v, ok := parseSemver(rel.Version); ok && r.matches(v) {
+			kept = append(kept, rel)
+		}
+	}
+	return kept
+}
+
+// matches reports whether v satisfies any alternative. As in npm, a
+// prerelease only satisfies an alternative that names a prerelease of the
+// same MAJOR.MINOR.PATCH, so ">=1.2.0 <2.0.0" leaves out 2.0.0-rc.1.
+func (r semverRange) matches(v semVersion) bool {
+	for _, set := range r {
+		ok := len(v.Pre) == 0
+		for _, c := range set {
+			if len(c.version.Pre) > 0 && c.version.Major == v.Major && c.version.Minor == v.Minor && c.version.Patch == v.Patch {
+				ok = true
+			}
+		}
+		for _, c := range set {
+			ok = ok && c.matches(v)
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
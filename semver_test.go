@@ -0,0 +1,100 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSemver(t *testing.T) {
+	testCases := []struct {
+		tag    string
+		wantOK bool
+	}{
+		{"v1.2.3", true},
+		{"1.2.3-rc.1+build.5", true},
+		{"v1.2", false},
+		{"v01.2.3", false},
+		{"v1.2.3-", false},
+		{"cli-v1.2.3", false},
+		{"nightly", false},
+	}
+
+	for _, tc := range testCases {
+		if _, ok := parseSemver(tc.tag); ok != tc.wantOK {
+			t.Errorf("parseSemver(%q) ok = %v, want %v", tc.tag, ok, tc.wantOK)
+		}
+	}
+}
+
+func TestSemverCompare(t *testing.T) {
+	// In ascending precedence, from the semver specification.
+	ordered := []string{
+		"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta",
+		"1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.1", "1.10.0", "2.0.0",
+	}
+	for i := 0; i+1 < len(ordered); i++ {
+		a, _ := parseSemver(ordered[i])
+		b, _ := parseSemver(ordered[i+1])
+		if a.compare(b) != -1 || b.compare(a) != 1 {
+			t.Errorf("compare(%s, %s) = %d, want -1", ordered[i], ordered[i+1], a.compare(b))
+		}
+	}
+}
+
+func TestSortSemverReleases(t *testing.T) {
+	releases := []NormalizedRelease{
+		{Version: "v1.9.1"}, {Version: "nightly"}, {Version: "v2.0.0"},
+		{Version: "v1.10.0"}, {Version: "v2.0.0-rc.1"}, {Version: "latest"},
+	}
+
+	sortSemverReleases(releases)
+	var got []string
+	for _, r := range releases {
+		got = append(got, r.Version)
+	}
+	want := []string{"v2.0.0", "v2.0.0-rc.1", "v1.10.0", "v1.9.1", "nightly", "latest"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sortSemverReleases() = %v, want %v", got, want)
+	}
+}
+
+func TestSemverRange(t *testing.T) {
+	tags := []string{"v0.9.0", "v1.2.0", "v1.5.3", "v2.0.0-rc.1", "v2.0.0", "v3.1.0", "nightly"}
+
+	testCases := []struct {
+		rng      string
+		expected []string
+	}{
+		{">=1.2.0 <2.0.0", []string{"v1.2.0", "v1.5.3"}},
+		{"<1.0.0 || >=3.0.0", []string{"v0.9.0", "v3.1.0"}},
+		{"2.0.0", []string{"v2.0.0"}},
+		{">1.5.3 <=v2.0.0", []string{"v2.0.0"}},
+		{">=2.0.0-rc.0", []string{"v2.0.0-rc.1", "v2.0.0", "v3.1.0"}},
+	}
+
+	for _, tc := range testCases {
+		rng, err := parseSemverRange(tc.rng)
+		if err != nil {
+			t.Fatalf("parseSemverRange(%q) error = %v", tc.rng, err)
+		}
+		var releases []NormalizedRelease
+		for _, tag := range tags {
+			releases = append(releases, NormalizedRelease{Version: tag})
+		}
+		var got []string
+		for _, r := range rng.filter(releases) {
+			got = append(got, r.Version)
+		}
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("range %q kept %v, want %v", tc.rng, got, tc.expected)
+		}
+	}
+}
+
+func TestParseSemverRangeErrors(t *testing.T) {
+	for _, rng := range []string{"", ">=1.2", "~1.2.0", ">=1.0.0 ||"} {
+		if _, err := parseSemverRange(rng); err == nil {
+			t.Errorf("parseSemverRange(%q) error = nil, want an error", rng)
+		}
+	}
+}
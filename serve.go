@@ -0,0 +1,353 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const maxServeCount = 100
+
+// maxServeCacheEntries caps the response cache of gale serve, so a server
+// asked for many distinct repositories does not grow without bound.
+const maxServeCacheEntries = 1000
+
+var (
+	ownerPattern = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9-]{0,38})$`)
+	repoPattern  = regexp.MustCompile(`^[A-Za-z0-9._-]{1,100}$`)
+)
+
+// releaseServer serves normalized release data over HTTP, caching each
+// owner/repo/count/channel combination in memory for ttl.
+type releaseServer struct {
+	ttl   time.Duration
+	fetch func(ctx context.Context, owner, repo string, count int) (*OutputFile, error)
+	now   func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]cachedOutput
+}
+
+type cachedOutput struct {
+	body    []byte
+	expires time.Time
+}
+
+// newReleaseServer returns a server that authenticates every fetch with the
+// token returned by token.
+func newReleaseServer(token func(ctx context.Context) (string, error), ttl time.Duration) *releaseServer {
+	return &releaseServer{
+		ttl: ttl,
+		fetch: func(ctx context.Context, owner, repo string, count int) (*OutputFile, error) {
+			t, err := token(ctx)
+			if err != nil {
+				return nil, err
+			}
+			result, err := fetchRepository(ctx, owner, repo, count, t)
+			if err != nil {
+				return nil, err
+			}
+			repoData := result.Data.Repository
+			output := newOutputFile(owner, repo, repoData.Releases.TotalCount, normalizeData(repoData.Releases.Nodes))
+			return &output, nil
+		},
+		now:   time.Now,
+		cache: make(map[string]cachedOutput),
+	}
+}
+
+func (s *releaseServer) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /releases", s.handleReleases)
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok", "version": version})
+	})
+	return mux
+}
+
+func (s *releaseServer) handleReleases(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	owner, repo, channel := q.Get("owner"), q.Get("repo"), q.Get("channel")
+
+	if !ownerPattern.MatchString(owner) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid or missing owner %q", owner))
+		return
+	}
+	if !repoPattern.MatchString(repo) || repo == "." || repo == ".." {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid or missing repo %q", repo))
+		return
+	}
+	count := 10
+	if v := q.Get("count"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxServeCount {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("count must be between 1 and %d", maxServeCount))
+			return
+		}
+		count = n
+	}
+	if err := validateChannel(channel); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	key := fmt.Sprintf("%s/%s/%d/%s", owner, repo, count, channel)
+	if body, ok := s.cached(key); ok {
+		w.Header().Set("X-Cache", "HIT")
+		writeBody(w, http.StatusOK, body)
+		return
+	}
+
+	output, err := s.fetch(r.Context(), owner, repo, count)
+	if err != nil {
+		writeFetchError(w, err, s.now())
+		return
+	}
+	output.Releases = applyChannels(output.Releases, newChannelRules(defaultAlphaSuffixes, defaultBetaSuffixes), channel)
+	output.Repository.FetchedReleases = len(output.Releases)
+
+	body, err := json.Marshal(output)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.store(key, body)
+	w.Header().Set("X-Cache", "MISS")
+	writeBody(w, http.StatusOK, body)
+}
+
+func (s *releaseServer) cached(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.cache[key]
+	if !ok || s.now().After(entry.expires) {
+		delete(s.cache, key)
+		return nil, false
+	}
+	return entry.body, true
+}
+
+// store caches body under key. Expired entries are swept first; if the
+// cache is still full, the entry closest to expiring makes room.
+func (s *releaseServer) store(key string, body []byte) {
+	if s.ttl <= 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	for k, entry := range s.cache {
+		if now.After(entry.expires) {
+			delete(s.cache, k)
+		}
+	}
+	if _, ok := s.cache[key]; !ok && len(s.cache) >= maxServeCacheEntries {
+		oldest := ""
+		for k, entry := range s.cache {
+			if oldest == "" || entry.expires.Before(s.cache[oldest].expires) {
+				oldest = k
+			}
+		}
+		delete(s.cache, oldest)
+	}
+	s.cache[key] = cachedOutput{body: body, expires: now.Add(s.ttl)}
+}
+
+// staticToken returns a token source that always returns token.
+func staticToken(token string) func(ctx context.Context) (string, error) {
+	return func(ctx context.Context) (string, error) {
+		return token, nil
+	}
+}
+
+// appTokenRefresh is how long gale serve reuses an installation token.
+// GitHub's expire after an hour; the margin covers requests in flight.
+const appTokenRefresh = 50 * time.Minute
+
+// appTokenSource mints GitHub App installation tokens for gale serve and
+// mints a new one before the current one expires. Minting happens outside
+// mu, so requests never wait on it while a valid token is at hand; only
+// one mint runs at a time and concurrent callers wait for its result.
+type appTokenSource struct {
+	appID          int64
+	keyPath        string
+	installationID int64
+	now            func() time.Time
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+	minting chan struct{} // closed when the mint in flight finishes
+}
+
+func (s *appTokenSource) get(ctx context.Context) (string, error) {
+	for {
+		s.mu.Lock()
+		if s.token != "" && s.now().Before(s.expires) {
+			token := s.token
+			s.mu.Unlock()
+			return token, nil
+		}
+		if minting := s.minting; minting != nil {
+			s.mu.Unlock()
+			select {
+			case <-minting:
+				continue
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
+		}
+		minting := make(chan struct{})
+		s.minting = minting
+		s.mu.Unlock()
+
+		token, err := installationToken(ctx, s.appID, s.keyPath, s.installationID)
+		if err == nil {
+			registerSecret(token)
+		}
+		s.mu.Lock()
+		if err == nil {
+			s.token, s.expires = token, s.now().Add(appTokenRefresh)
+		}
+		s.minting = nil
+		s.mu.Unlock()
+		close(minting)
+		return token, err
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeBody(w, status, body)
+}
+
+// writeError responds with err as JSON. Clients are not authenticated, so
+// the credentials of the server are scrubbed from it like from CLI errors.
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": redactKnownSecrets(err.Error())})
+}
+
+// writeFetchError responds with the status matching a failed fetch: 404
+// for a missing repository, 429 with Retry-After when GitHub rate limits
+// the server, and 502 for anything else GitHub or the network did.
+func writeFetchError(w http.ResponseWriter, err error, now time.Time) {
+	var limited *RateLimitError
+	switch {
+	case errors.Is(err, ErrRepoNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case errors.As(err, &limited):
+		w.Header().Set("Retry-After", strconv.Itoa(max(1, int(limited.Reset.Sub(now).Seconds()+0.5))))
+		writeError(w, http.StatusTooManyRequests, err)
+	case errors.Is(err, ErrRateLimited):
+		writeError(w, http.StatusTooManyRequests, err)
+	default:
+		writeError(w, http.StatusBadGateway, err)
+	}
+}
+
+func writeBody(w http.ResponseWriter, status int, body []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err := w.Write(body); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to write response: %v\n", err)
+	}
+}
+
+// runServe implements `gale serve`, exposing /releases and /healthz until
+// interrupted.
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("addr", ":8080", "Address to listen on")
+	ttl := fs.Duration("cache-ttl", 5*time.Minute, "How long to cache responses (0 disables caching)")
+	token := fs.String("token", os.Getenv("GITHUB_TOKEN"), "GitHub token")
+	fs.StringVar(token, "t", os.Getenv("GITHUB_TOKEN"), "GitHub token (shorthand)")
+	tokenFile := fs.String("token-file", os.Getenv("GITHUB_TOKEN_FILE"), "Read the GitHub token from this file")
+	appID := fs.Int64("app-id", 0, "Authenticate as this GitHub App")
+	appKey := fs.String("app-private-key", "", "PEM private key file of the GitHub App")
+	installationID := fs.Int64("installation-id", 0, "GitHub App installation to mint access tokens for")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	// The same sources as a normal run, in the same order: --token, then
+	// --token-file over GITHUB_TOKEN, then a GitHub App, then gh's token.
+	if *tokenFile != "" {
+		if flagSet(fs, "token") && flagSet(fs, "token-file") {
+			return errors.New("--token and --token-file are mutually exclusive")
+		}
+		if !flagSet(fs, "token") {
+			t, err := readTokenFile(*tokenFile)
+			if err != nil {
+				return err
+			}
+			*token = t
+		}
+	}
+	source := staticToken(*token)
+	if *appID != 0 || *appKey != "" || *installationID != 0 {
+		if *appID <= 0 || *appKey == "" || *installationID <= 0 {
+			return errors.New("GitHub App authentication needs --app-id, --app-private-key and --installation-id together")
+		}
+		if flagSet(fs, "token") || flagSet(fs, "token-file") {
+			return errors.New("--app-id cannot be combined with --token or --token-file")
+		}
+		apps := &appTokenSource{appID: *appID, keyPath: *appKey, installationID: *installationID, now: time.Now}
+		if _, err := apps.get(context.Background()); err != nil {
+			return err
+		}
+		source = apps.get
+	} else if *token == "" {
+		if t, err := ghToken(""); err == nil {
+			*token = t
+			source = staticToken(t)
+			infoLog("%s Using the token of the gh CLI for %s\n", icons["info"], ghHostname(""))
+		} else {
+			warningLog("%s No GitHub token provided. Rate limits may be lower.\n", icons["warning"])
+		}
+	}
+	registerSecret(*token)
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           newReleaseServer(source, *ttl).routes(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- srv.ListenAndServe()
+	}()
+	infoLog("%s Serving releases on %s (GET /releases?owner=&repo=&count=, GET /healthz)\n", icons["gear"], bright(*addr))
+
+	select {
+	case err := <-errChan:
+		return fmt.Errorf("server stopped: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to shut down server: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestReleaseServer(calls *int) *releaseServer {
+	s := newReleaseServer(staticToken(""), time.Minute)
+	s.fetch = func(ctx context.Context, owner, repo string, count int) (*OutputFile, error) {
+		*calls++
+		output := newOutputFile(owner, repo, 2, []NormalizedRelease{
+			{ID: "1", Version: "v2.0.0-rc.1", IsPrerelease: true},
+			{ID: "2", Version: "v1.0.0"},
+		})
+		return &output, nil
+	}
+	return s
+}
+
+func TestReleaseServerValidation(t *testing.T) {
+	calls := 0
+	handler := newTestReleaseServer(&calls).routes()
+
+	testCases := []struct {
+		name   string
+		url    string
+		status int
+	}{
+		{"Health", "/healthz", http.StatusOK},
+		{"Missing owner", "/releases?repo=gale", http.StatusBadRequest},
+		{"Bad owner", "/releases?owner=-bad&repo=gale", http.StatusBadRequest},
+		{"Bad repo", "/releases?owner=Typeflu&repo=..", http.StatusBadRequest},
+		{"Count too large", "/releases?owner=Typeflu&repo=gale&count=500", http.StatusBadRequest},
+		{"Bad channel", "/releases?owner=Typeflu&repo=gale&channel=edge", http.StatusBadRequest},
+		{"Wrong method", "/releases?owner=Typeflu&repo=gale", http.StatusMethodNotAllowed},
+		{"Valid", "/releases?owner=Typeflu&repo=gale&count=5", http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			method := http.MethodGet
+			if tc.name == "Wrong method" {
+				method = http.MethodPost
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(method, tc.url, nil))
+			if rec.Code != tc.status {
+				t.Errorf("%s %s = %d, want %d (%s)", method, tc.url, rec.Code, tc.status, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestReleaseServerCache(t *testing.T) {
+	calls := 0
+	s := newTestReleaseServer(&calls)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	handler := s.routes()
+
+	get := func(url string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+		return rec
+	}
+
+	first := get("/releases?owner=Typeflu&repo=gale&channel=stable")
+	if got := first.Header().Get("X-Cache"); got != "MISS" {
+		t.Errorf("first request X-Cache = %q, want MISS", got)
+	}
+	var output OutputFile
+	if err := json.Unmarshal(first.Body.Bytes(), &output); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(output.Releases) != 1 || output.Releases[0].Channel != channelStable {
+		t.Errorf("channel filter returned %+v, want only the stable release", output.Releases)
+	}
+
+	if got := get("/releases?owner=Typeflu&repo=gale&channel=stable").Header().Get("X-Cache"); got != "HIT" {
+		t.Errorf("second request X-Cache = %q, want HIT", got)
+	}
+	if calls != 1 {
+		t.Errorf("fetch called %d times, want 1", calls)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if got := get("/releases?owner=Typeflu&repo=gale&channel=stable").Header().Get("X-Cache"); got != "MISS" {
+		t.Errorf("request after TTL X-Cache = %q, want MISS", got)
+	}
+	if calls != 2 {
+		t.Errorf("fetch called %d times, want 2", calls)
+	}
+}
+
+func TestReleaseServerCacheBound(t *testing.T) {
+	calls := 0
+	s := newTestReleaseServer(&calls)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	s.store("expired", []byte("{}"))
+	now = now.Add(2 * time.Minute)
+	s.store("fresh", []byte("{}"))
+	if _, ok := s.cache["expired"]; ok {
+		t.Error("store() kept an expired entry")
+	}
+
+	for i := 0; i < maxServeCacheEntries+10; i++ {
+		now = now.Add(time.Millisecond)
+		s.store(fmt.Sprintf("Typeflu/repo%d/10/", i), []byte("{}"))
+	}
+	if len(s.cache) != maxServeCacheEntries {
+		t.Errorf("cache holds %d entries, want at most %d", len(s.cache), maxServeCacheEntries)
+	}
+	if _, ok := s.cache[fmt.Sprintf("Typeflu/repo%d/10/", maxServeCacheEntries+9)]; !ok {
+		t.Error("store() evicted the newest entry")
+	}
+}
+
+func TestReleaseServerFetchErrors(t *testing.T) {
+	defer func() { knownSecrets = nil }()
+	registerSecret("ghp_servertoken")
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name       string
+		err        error
+		status     int
+		retryAfter string
+	}{
+		{"Missing repository", fmt.Errorf("Typeflu/gale: %w", ErrRepoNotFound), http.StatusNotFound, ""},
+		{"Rate limited", &RateLimitError{Reset: now.Add(90 * time.Second)}, http.StatusTooManyRequests, "90"},
+		{"Other failure", errors.New("request with ghp_servertoken failed"), http.StatusBadGateway, ""},
+	}
+
+	for _, tc := range testCases {
+		s := newReleaseServer(staticToken(""), time.Minute)
+		s.now = func() time.Time { return now }
+		s.fetch = func(ctx context.Context, owner, repo string, count int) (*OutputFile, error) {
+			return nil, tc.err
+		}
+		rec := httptest.NewRecorder()
+		s.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/releases?owner=Typeflu&repo=gale", nil))
+		if rec.Code != tc.status {
+			t.Errorf("%s: status = %d, want %d", tc.name, rec.Code, tc.status)
+		}
+		if got := rec.Header().Get("Retry-After"); got != tc.retryAfter {
+			t.Errorf("%s: Retry-After = %q, want %q", tc.name, got, tc.retryAfter)
+		}
+		if strings.Contains(rec.Body.String(), "ghp_servertoken") {
+			t.Errorf("%s: response shows the server's token: %s", tc.name, rec.Body.String())
+		}
+	}
+}
+
+func TestAppTokenSource(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPath := filepath.Join(t.TempDir(), "app.pem")
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := os.WriteFile(keyPath, keyPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	minted := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		minted++
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"token":"ghs_%d"}`, minted)
+	}))
+	defer srv.Close()
+	oldREST := restAPIURL
+	restAPIURL = srv.URL
+	defer func() { restAPIURL = oldREST }()
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	source := &appTokenSource{appID: 7, keyPath: keyPath, installationID: 42, now: func() time.Time { return now }}
+	for i, want := range []string{"ghs_1", "ghs_1", "ghs_2"} {
+		if i == 2 {
+			now = now.Add(appTokenRefresh)
+		}
+		got, err := source.get(context.Background())
+		if err != nil || got != want {
+			t.Errorf("get() #%d = %q, %v, want %q", i+1, got, err, want)
+		}
+	}
+}
+
+func TestAppTokenSourceConcurrent(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPath := filepath.Join(t.TempDir(), "app.pem")
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := os.WriteFile(keyPath, keyPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var minted atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := minted.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"token":"ghs_%d"}`, n)
+	}))
+	defer srv.Close()
+	oldREST := restAPIURL
+	restAPIURL = srv.URL
+	defer func() { restAPIURL = oldREST }()
+
+	source := &appTokenSource{appID: 7, keyPath: keyPath, installationID: 42, now: time.Now}
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got, err := source.get(context.Background()); err != nil || got != "ghs_1" {
+				t.Errorf("get() = %q, %v, want ghs_1", got, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := minted.Load(); got != 1 {
+		t.Errorf("minted %d tokens for concurrent requests, want 1", got)
+	}
+}
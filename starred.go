@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/briandowns/spinner"
+)
+
+const githubStarredQuery = `
+query ($first: Int!, $after: String) {
+  viewer {
+    starredRepositories(first: $first, after: $after, orderBy: { field: STARRED_AT, direction: DESC }) {
+      pageInfo {
+        hasNextPage
+        endCursor
+      }
+      nodes {
+        name
+        owner {
+          login
+        }
+      }
+    }
+  }
+}`
+
+type Viewer struct {
+	StarredRepositories StarredRepositories `json:"starredRepositories"`
+}
+
+type StarredRepositories struct {
+	PageInfo PageInfo `json:"pageInfo"`
+	Nodes    []struct {
+		Name  string `json:"name"`
+		Owner Actor  `json:"owner"`
+	} `json:"nodes"`
+}
+
+// StarredFeed is the output of `gale starred`: the newest releases of every
+// starred repository, newest first.
+type StarredFeed struct {
+	Metadata Metadata            `json:"metadata"`
+	Sources  []RepoInfo          `json:"sources"`
+	Releases []NormalizedRelease `json:"releases"`
+}
+
+// fetchStarredRepos pages through the authenticated user's starred
+// repositories, most recently starred first. A positive limit stops early.
+func fetchStarredRepos(ctx context.Context, token string, limit int) ([]repoRef, error) {
+	var repos []repoRef
+	var after interface{}
+	for {
+		result, err := fetchGraphQL(ctx, githubStarredQuery, map[string]interface{}{"first": 100, "after": after}, token)
+		if err != nil {
+			return nil, err
+		}
+		if len(result.Errors) > 0 {
+			return nil, fmt.Errorf("GraphQL returned errors: %s", result.Errors[0].Message)
+		}
+		if result.Data == nil || result.Data.Viewer == nil {
+			return nil, errors.New("GitHub returned no viewer; is the token valid?")
+		}
+
+		starred := result.Data.Viewer.StarredRepositories
+		for _, n := range starred.Nodes {
+			repos = append(repos, repoRef{Owner: n.Owner.Login, Repo: n.Name})
+			if limit > 0 && len(repos) == limit {
+				return repos, nil
+			}
+		}
+		if !starred.PageInfo.HasNextPage {
+			return repos, nil
+		}
+		after = starred.PageInfo.EndCursor
+	}
+}
+
+// releaseFeed runs fetch for every repository with at most concurrency
+// requests in flight and returns all published releases, newest first, with
+// Source set. Repositories that fail are reported in errs and left out.
+func releaseFeed(ctx context.Context, repos []repoRef, concurrency int, fetch func(context.Context, repoRef) ([]NormalizedRelease, error)) (releases []NormalizedRelease, errs map[string]error) {
+	results := make([][]NormalizedRelease, len(repos))
+	failures := make([]error, len(repos))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i], failures[i] = fetch(ctx, repos[i])
+			}
+		}()
+	}
+	for i := range repos {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	errs = make(map[string]error)
+	for i, ref := range repos {
+		if failures[i] != nil {
+			errs[ref.String()] = failures[i]
+			continue
+		}
+		for _, r := range results[i] {
+			if r.IsDraft {
+				continue
+			}
+			r.Source = ref.String()
+			releases = append(releases, r)
+		}
+	}
+	sort.SliceStable(releases, func(i, j int) bool {
+		return releases[i].PublishedAt.After(releases[j].PublishedAt)
+	})
+	return releases, errs
+}
+
+// runStarred implements `gale starred`, a feed of what is new in the
+// repositories the token's user has starred.
+func runStarred(args []string) error {
+	fs := flag.NewFlagSet("starred", flag.ContinueOnError)
+	count := fs.Int("count", 3, "Releases to fetch per repository")
+	fs.IntVar(count, "c", 3, "Releases to fetch per repository (shorthand)")
+	limit := fs.Int("limit", 0, "Only look at this many most recently starred repositories (0 = all)")
+	concurrency := fs.Int("concurrency", 4, "Maximum number of repositories fetched at once")
+	output := fs.String("output", "starred.json", "Output file name")
+	fs.StringVar(output, "o", "starred.json", "Output file name (shorthand)")
+	token := fs.String("token", os.Getenv("GITHUB_TOKEN"), "GitHub token")
+	fs.StringVar(token, "t", os.Getenv("GITHUB_TOKEN"), "GitHub token (shorthand)")
+	quiet := fs.Bool("quiet", false, "Quiet mode (minimal output)")
+	fs.BoolVar(quiet, "q", false, "Quiet mode (shorthand)")
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	registerSecret(*token)
+	switch {
+	case len(positional) > 0:
+		return fmt.Errorf("usage: gale starred [--count 3] [--limit 0] [-o starred.json]")
+	case *token == "":
+		return fmt.Errorf("gale starred needs a token (--token or GITHUB_TOKEN) to know whose stars to read")
+	case *count < 1 || *count > 100:
+		return fmt.Errorf("--count must be between 1 and 100, got %d", *count)
+	case *concurrency < 1:
+		return fmt.Errorf("--concurrency must be at least 1, got %d", *concurrency)
+	}
+
+	ctx := context.Background()
+	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond, spinner.WithSuffix(" Fetching starred repositories..."))
+	if !*quiet {
+		s.Start()
+	}
+	repos, err := fetchStarredRepos(ctx, *token, *limit)
+	if err != nil {
+		s.Stop()
+		return fmt.Errorf("failed to list starred repositories: %w", err)
+	}
+
+	s.Suffix = fmt.Sprintf(" Fetching releases of %d starred repositories...", len(repos))
+	sources := make([]RepoInfo, len(repos))
+	index := make(map[repoRef]int, len(repos))
+	for i, ref := range repos {
+		index[ref] = i
+	}
+	releases, errs := releaseFeed(ctx, repos, *concurrency, func(ctx context.Context, ref repoRef) ([]NormalizedRelease, error) {
+		res, err := fetchRepository(ctx, ref.Owner, ref.Repo, *count, *token)
+		if err != nil {
+			return nil, err
+		}
+		releases := normalizeData(res.Data.Repository.Releases.Nodes)
+		sources[index[ref]] = newOutputFile(ref.Owner, ref.Repo, res.Data.Repository.Releases.TotalCount, releases).Repository
+		return releases, nil
+	})
+	s.Stop()
+
+	feed := StarredFeed{Metadata: newMetadata(), Releases: releases}
+	for i, ref := range repos {
+		if errs[ref.String()] != nil {
+			warningLog("%s Skipping %s: %v\n", icons["warning"], ref, errs[ref.String()])
+			continue
+		}
+		feed.Sources = append(feed.Sources, sources[i])
+	}
+
+	data, err := json.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal starred feed: %w", err)
+	}
+	outPath, err := filepath.Abs(*output)
+	if err != nil {
+		return fmt.Errorf("could not resolve path %q: %w", *output, err)
+	}
+	if err := os.WriteFile(outPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write to file %s: %w", *output, err)
+	}
+
+	successLog("%s Saved %s releases from %s starred repositories to %s\n", icons["check"], bright(len(releases)), bright(len(feed.Sources)), cyan(*output))
+	return nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReleaseFeed(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 0, 0, 0, 0, time.UTC) }
+	byRepo := map[string][]NormalizedRelease{
+		"a/one":   {{Version: "v2", PublishedAt: day(4)}, {Version: "v1", PublishedAt: day(1)}},
+		"b/two":   {{Version: "v9", PublishedAt: day(3)}, {Version: "draft", IsDraft: true}},
+		"c/three": nil,
+	}
+	repos := []repoRef{{"a", "one"}, {"b", "two"}, {"c", "three"}}
+
+	releases, errs := releaseFeed(context.Background(), repos, 2, func(ctx context.Context, ref repoRef) ([]NormalizedRelease, error) {
+		if ref.Owner == "c" {
+			return nil, ErrRepoNotFound
+		}
+		return byRepo[ref.String()], nil
+	})
+
+	var got []string
+	for _, r := range releases {
+		got = append(got, r.Source+"@"+r.Version)
+	}
+	want := []string{"a/one@v2", "b/two@v9", "a/one@v1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("releaseFeed() = %v, want %v", got, want)
+	}
+	if len(errs) != 1 || !errors.Is(errs["c/three"], ErrRepoNotFound) {
+		t.Errorf("releaseFeed() errs = %v, want only c/three", errs)
+	}
+}
+
+func TestFetchStarredReposPaginates(t *testing.T) {
+	pages := []string{
+		`{"data":{"viewer":{"starredRepositories":{"pageInfo":{"hasNextPage":true,"endCursor":"c1"},"nodes":[{"name":"one","owner":{"login":"a"}},{"name":"two","owner":{"login":"b"}}]}}}}`,
+		`{"data":{"viewer":{"starredRepositories":{"pageInfo":{"hasNextPage":false,"endCursor":"c2"},"nodes":[{"name":"three","owner":{"login":"c"}}]}}}}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Variables struct {
+				After *string `json:"after"`
+			} `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Error(err)
+		}
+		page := 0
+		if payload.Variables.After != nil {
+			page = 1
+		}
+		_, _ = w.Write([]byte(pages[page]))
+	}))
+	defer srv.Close()
+
+	oldEndpoint := graphqlEndpoint
+	graphqlEndpoint = srv.URL
+	defer func() { graphqlEndpoint = oldEndpoint }()
+
+	testCases := []struct {
+		limit int
+		want  []repoRef
+	}{
+		{0, []repoRef{{"a", "one"}, {"b", "two"}, {"c", "three"}}},
+		{2, []repoRef{{"a", "one"}, {"b", "two"}}},
+	}
+	for _, tc := range testCases {
+		got, err := fetchStarredRepos(context.Background(), "token", tc.limit)
+		if err != nil {
+			t.Fatalf("fetchStarredRepos() error = %v", err)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("fetchStarredRepos(limit %d) = %v, want %v", tc.limit, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"golang.org/x/term"
+)
+
+// tableHeader names the columns --table prints.
+var tableHeader = []string{"TAG", "PUBLISHED", "PRERELEASE", "ASSETS", "SIZE"}
+
+// minTagWidth is the narrowest the tag column is truncated to when the
+// table would not fit the terminal otherwise.
+const minTagWidth = 12
+
+// truncateCell shortens s to width runes, marking the cut with "…".
+func truncateCell(s string, width int) string {
+	r := []rune(s)
+	if len(r) <= width {
+		return s
+	}
+	if width <= 1 {
+		return string(r[:width])
+	}
+	return string(r[:width-1]) + "…"
+}
+
+// renderTable lays releases out as a text table with one row per release.
+// When maxWidth is positive, tags are truncated so rows fit in it. With
+// colored set the header is bold and prereleases are yellow; otherwise the
+// table is plain text.
+func renderTable(releases []NormalizedRelease, maxWidth int, colored bool) string {
+	rows := make([][]string, len(releases))
+	for i, r := range releases {
+		pre := "no"
+		if r.IsPrerelease {
+			pre = "yes"
+		}
+		rows[i] = []string{r.Version, r.PublishedAt.Format("2006-01-02"), pre, strconv.Itoa(len(r.Assets)), formatBytes(totalAssetSize([]NormalizedRelease{r}))}
+	}
+
+	widths := make([]int, len(tableHeader))
+	for _, row := range append([][]string{tableHeader}, rows...) {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len([]rune(cell)))
+		}
+	}
+	if maxWidth > 0 {
+		total := 2 * (len(widths) - 1)
+		for _, w := range widths {
+			total += w
+		}
+		if over := total - maxWidth; over > 0 {
+			widths[0] = max(widths[0]-over, minTagWidth, len(tableHeader[0]))
+		}
+	}
+
+	bold := color.New(color.Bold)
+	yellow := color.New(color.FgYellow)
+	bold.EnableColor()
+	yellow.EnableColor()
+
+	var b strings.Builder
+	writeRow := func(row []string, c *color.Color) {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cell = truncateCell(cell, widths[i])
+			pad := strings.Repeat(" ", widths[i]-len([]rune(cell)))
+			// Sizes and counts are right-aligned, the rest left-aligned.
+			if i >= 3 {
+				cell = pad + cell
+			} else if i < len(row)-1 {
+				cell += pad
+			}
+			cells[i] = cell
+		}
+		line := strings.Join(cells, "  ")
+		if colored && c != nil {
+			line = c.Sprint(line)
+		}
+		b.WriteString(line + "\n")
+	}
+
+	writeRow(tableHeader, bold)
+	for i, row := range rows {
+		var c *color.Color
+		if releases[i].IsPrerelease {
+			c = yellow
+		}
+		writeRow(row, c)
+	}
+	return b.String()
+}
+
+// notesIndent sets the release notes --notes shows apart from the rows.
+const notesIndent = "    "
+
+// withNotes inserts the notes of each release, indented, below its row of
+// table. The notes are wrapped to width, unless it is 0 or -1, and rendered
+// as Markdown when colored is set.
+func withNotes(table string, releases []NormalizedRelease, width int, colored bool) string {
+	lines := strings.SplitAfter(table, "\n")
+	var b strings.Builder
+	b.WriteString(lines[0]) // the header
+	for i, r := range releases {
+		b.WriteString(lines[i+1])
+		notes := strings.TrimSpace(strings.ReplaceAll(r.Description, "\r\n", "\n"))
+		if notes == "" {
+			continue
+		}
+		if width > 0 {
+			notes = wrapText(notes, max(width-len(notesIndent), 20))
+		}
+		if colored {
+			notes = styleMarkdown(notes)
+		}
+		for _, line := range strings.Split(notes, "\n") {
+			b.WriteString(strings.TrimRight(notesIndent+line, " ") + "\n")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// printTable writes output's releases to stdout as a table for --table,
+// fitting it to the terminal width and coloring it only when stdout is a
+// terminal and colors are enabled. With --notes each release is followed
+// by its release notes, wrapped as --wrap says.
+func printTable(cfg *Config, output *OutputFile) error {
+	width := 0
+	colored := colorEnabled(cfg.NoColor, os.Stdout)
+	if term.IsTerminal(int(os.Stdout.Fd())) {
+		width = terminalWidth()
+	}
+	table := renderTable(output.Releases, width, colored)
+	if cfg.Notes {
+		table = withNotes(table, output.Releases, wrapWidth(cfg.Wrap), colored)
+	}
+	if _, err := fmt.Fprint(os.Stdout, table); err != nil {
+		return fmt.Errorf("failed to write table to stdout: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderTable(t *testing.T) {
+	releases := []NormalizedRelease{
+		{Version: "v2.0.0", PublishedAt: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), Assets: []NormalizedAsset{{Size: 1024}, {Size: 1024}}},
+		{Version: "v2.0.0-rc.1-with-a-very-long-suffix", PublishedAt: time.Date(2024, 4, 20, 0, 0, 0, 0, time.UTC), IsPrerelease: true},
+	}
+
+	got := renderTable(releases, 0, false)
+	want := "TAG                                  PUBLISHED   PRERELEASE  ASSETS    SIZE\n" +
+		"v2.0.0                               2024-05-01  no               2  2.0 KB\n" +
+		"v2.0.0-rc.1-with-a-very-long-suffix  2024-04-20  yes              0     0 B\n"
+	if got != want {
+		t.Errorf("renderTable() =\n%s\nwant\n%s", got, want)
+	}
+	if strings.Contains(got, "\x1b[") {
+		t.Error("renderTable() without color contains ANSI escapes")
+	}
+
+	narrow := renderTable(releases, 60, false)
+	for _, line := range strings.Split(strings.TrimSuffix(narrow, "\n"), "\n") {
+		if n := len([]rune(line)); n > 60 {
+			t.Errorf("line %q is %d columns wide, want at most 60", line, n)
+		}
+	}
+	if !strings.Contains(narrow, "v2.0.0-rc.1-with-a-…") {
+		t.Errorf("renderTable() did not truncate the long tag:\n%s", narrow)
+	}
+
+	if colored := renderTable(releases, 0, true); !strings.Contains(colored, "\x1b[") {
+		t.Error("renderTable() with color contains no ANSI escapes")
+	}
+}
+
+func TestTruncateCell(t *testing.T) {
+	testCases := []struct {
+		s        string
+		width    int
+		expected string
+	}{
+		{"v1.0.0", 10, "v1.0.0"},
+		{"v1.0.0-beta", 8, "v1.0.0-…"},
+		{"héllo", 5, "héllo"},
+	}
+
+	for _, tc := range testCases {
+		if got := truncateCell(tc.s, tc.width); got != tc.expected {
+			t.Errorf("truncateCell(%q, %d) = %q, want %q", tc.s, tc.width, got, tc.expected)
+		}
+	}
+}
+
+func TestWithNotes(t *testing.T) {
+	releases := []NormalizedRelease{
+		{Version: "v2.0.0", Description: "## Changes\r\n\r\n- **Faster** fetches"},
+		{Version: "v1.0.0"},
+	}
+	table := renderTable(releases, 0, false)
+
+	got := withNotes(table, releases, 0, false)
+	lines := strings.Split(table, "\n")
+	want := lines[0] + "\n" + lines[1] + "\n    ## Changes\n\n    - **Faster** fetches\n\n" + lines[2] + "\n"
+	if got != want {
+		t.Errorf("withNotes() =\n%s\nwant\n%s", got, want)
+	}
+
+	wrapped := withNotes(table, []NormalizedRelease{{Version: "v2.0.0", Description: strings.Repeat("word ", 10)}}, 30, false)
+	if !strings.Contains(wrapped, "\n    word word word word word\n    word word word word word\n") {
+		t.Errorf("withNotes() at width 30 did not wrap the notes:\n%s", wrapped)
+	}
+	if unwrapped := withNotes(table, []NormalizedRelease{{Version: "v2.0.0", Description: strings.Repeat("word ", 10)}}, -1, false); !strings.Contains(unwrapped, "\n    "+strings.TrimSpace(strings.Repeat("word ", 10))+"\n") {
+		t.Errorf("withNotes() at width -1 wrapped the notes:\n%s", unwrapped)
+	}
+
+	if colored := withNotes(table, releases, 0, true); !strings.Contains(colored, ansiBold+"Faster"+ansiBoldOff) || strings.Contains(colored, "**") {
+		t.Errorf("withNotes() with color did not render the Markdown:\n%q", colored)
+	}
+}
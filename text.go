@@ -0,0 +1,124 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+const defaultTerminalWidth = 80
+
+// terminalWidth returns the width of the terminal attached to stdout, or
+// defaultTerminalWidth when stdout is not a terminal.
+func terminalWidth() int {
+	if w, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && w > 0 {
+		return w
+	}
+	return defaultTerminalWidth
+}
+
+// colorEnabled reports whether output written to f should be colored. It
+// is not when --no-color is given, NO_COLOR is set to any value, TERM is
+// "dumb" or f is not a terminal, so redirected logs stay free of ANSI codes.
+func colorEnabled(noColor bool, f *os.File) bool {
+	if noColor || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// wrapWidth resolves the --wrap value: 0 means the terminal width and a
+// negative value disables wrapping.
+func wrapWidth(n int) int {
+	if n == 0 {
+		return terminalWidth()
+	}
+	return n
+}
+
+// wrapText word-wraps Markdown text to width columns. Blank lines between
+// paragraphs, headings, tables and fenced code blocks are kept as they are;
+// running paragraph text and list items are reflowed, with list items
+// continuing under their first word. Words longer than width are left on a
+// line of their own rather than split.
+func wrapText(text string, width int) string {
+	if width <= 0 {
+		return text
+	}
+
+	var out, para []string
+	first, rest := "", ""
+	inFence := false
+
+	flush := func() {
+		if len(para) > 0 {
+			out = append(out, wrapWords(strings.Join(para, " "), width, first, rest)...)
+		}
+		para, first, rest = nil, "", ""
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			flush()
+			inFence = !inFence
+			out = append(out, line)
+			continue
+		}
+		if inFence || trimmed == "" || strings.HasPrefix(trimmed, "|") || strings.HasPrefix(trimmed, "#") {
+			flush()
+			out = append(out, line)
+			continue
+		}
+		if marker := listMarker(trimmed); marker != "" {
+			flush()
+			lead := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+			first, rest = lead+marker, lead+strings.Repeat(" ", len(marker))
+			trimmed = strings.TrimSpace(trimmed[len(marker):])
+		}
+		para = append(para, trimmed)
+	}
+	flush()
+
+	return strings.Join(out, "\n")
+}
+
+// listMarker returns the bullet, number or quote marker (with its trailing
+// space) that starts line, or "" for plain text.
+func listMarker(line string) string {
+	for _, m := range []string{"- ", "* ", "+ ", "> "} {
+		if strings.HasPrefix(line, m) {
+			return m
+		}
+	}
+	i := 0
+	for i < len(line) && line[i] >= '0' && line[i] <= '9' {
+		i++
+	}
+	if i > 0 && i+1 < len(line) && (line[i] == '.' || line[i] == ')') && line[i+1] == ' ' {
+		return line[:i+2]
+	}
+	return ""
+}
+
+// wrapWords fills words into lines of at most width runes, prefixing the
+// first line with first and every following line with rest.
+func wrapWords(text string, width int, first, rest string) []string {
+	var lines []string
+	prefix := first
+	line := prefix
+	for _, word := range strings.Fields(text) {
+		if line != prefix && len([]rune(line))+1+len([]rune(word)) > width {
+			lines = append(lines, line)
+			prefix = rest
+			line = prefix
+		}
+		if line != prefix {
+			line += " "
+		}
+		line += word
+	}
+	return append(lines, line)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWrapText(t *testing.T) {
+	testCases := []struct {
+		name     string
+		text     string
+		width    int
+		expected string
+	}{
+		{
+			name:     "Disabled",
+			text:     "a long line that would otherwise wrap",
+			width:    -1,
+			expected: "a long line that would otherwise wrap",
+		},
+		{
+			name:     "Width 10",
+			text:     "the quick brown fox jumps over the lazy dog",
+			width:    10,
+			expected: "the quick\nbrown fox\njumps over\nthe lazy\ndog",
+		},
+		{
+			name:     "Width 20",
+			text:     "the quick brown fox jumps over the lazy dog",
+			width:    20,
+			expected: "the quick brown fox\njumps over the lazy\ndog",
+		},
+		{
+			name:     "Reflows soft line breaks",
+			text:     "one two\nthree four",
+			width:    80,
+			expected: "one two three four",
+		},
+		{
+			name:     "Keeps paragraph breaks",
+			text:     "first paragraph here\n\nsecond paragraph here",
+			width:    12,
+			expected: "first\nparagraph\nhere\n\nsecond\nparagraph\nhere",
+		},
+		{
+			name:     "Hanging indent for list items",
+			text:     "## Changes\n- fixed a crash on startup\n- faster",
+			width:    16,
+			expected: "## Changes\n- fixed a crash\n  on startup\n- faster",
+		},
+		{
+			name:     "Leaves code blocks alone",
+			text:     "```\nsome very long code line\n```",
+			width:    5,
+			expected: "```\nsome very long code line\n```",
+		},
+		{
+			name:     "Long words are not split",
+			text:     "see https://example.com/a/very/long/url",
+			width:    10,
+			expected: "see\nhttps://example.com/a/very/long/url",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := wrapText(tc.text, tc.width); got != tc.expected {
+				t.Errorf("wrapText(%q, %d) = %q, want %q", tc.text, tc.width, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestWrapWidth(t *testing.T) {
+	if got := wrapWidth(-1); got != -1 {
+		t.Errorf("wrapWidth(-1) = %d, want -1", got)
+	}
+	if got := wrapWidth(40); got != 40 {
+		t.Errorf("wrapWidth(40) = %d, want 40", got)
+	}
+	if got := wrapWidth(0); got <= 0 {
+		t.Errorf("wrapWidth(0) = %d, want the terminal width", got)
+	}
+}
+
+func TestColorEnabledRedirected(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	f, err := os.Create(filepath.Join(t.TempDir(), "log.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if colorEnabled(false, f) {
+		t.Error("colorEnabled() = true for a regular file, want false")
+	}
+	if colorEnabled(true, os.Stdout) {
+		t.Error("colorEnabled(--no-color) = true, want false")
+	}
+	t.Setenv("NO_COLOR", "1")
+	if colorEnabled(false, os.Stdout) {
+		t.Error("colorEnabled() = true with NO_COLOR set, want false")
+	}
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// readTokenFile returns the token stored in the file at path for
+// --token-file and GITHUB_TOKEN_FILE, without surrounding whitespace.
+func readTokenFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read token file: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", errors.New("token file " + path + " is empty")
+	}
+	return token, nil
+}
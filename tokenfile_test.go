@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadTokenFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "token")
+	if err := os.WriteFile(path, []byte("  ghp_fromfile\n\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	empty := filepath.Join(dir, "empty")
+	if err := os.WriteFile(empty, []byte("\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		path     string
+		expected string
+		wantErr  bool
+	}{
+		{path, "ghp_fromfile", false},
+		{empty, "", true},
+		{filepath.Join(dir, "missing"), "", true},
+	}
+
+	for _, tc := range testCases {
+		got, err := readTokenFile(tc.path)
+		if (err != nil) != tc.wantErr || got != tc.expected {
+			t.Errorf("readTokenFile(%q) = %q, %v, want %q (error: %v)", tc.path, got, err, tc.expected, tc.wantErr)
+		}
+	}
+}
+
+func TestParseArgsTokenFile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir()) // keep a ~/.galerc out of the defaults
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+	oldFlagSet := flag.CommandLine
+	defer func() { flag.CommandLine = oldFlagSet }()
+
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("ghp_fromfile\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name     string
+		env      string
+		args     []string
+		expected string
+		wantErr  bool
+	}{
+		{"file wins over GITHUB_TOKEN", "", []string{"cmd", "--token-file", path}, "ghp_fromfile", false},
+		{"GITHUB_TOKEN_FILE", path, []string{"cmd"}, "ghp_fromfile", false},
+		{"explicit --token wins over GITHUB_TOKEN_FILE", path, []string{"cmd", "--token", "ghp_flag"}, "ghp_flag", false},
+		{"both flags", "", []string{"cmd", "--token", "ghp_flag", "--token-file", path}, "", true},
+		{"unreadable file", "", []string{"cmd", "--token-file", path + ".missing"}, "", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("GITHUB_TOKEN", "ghp_env")
+			t.Setenv("GITHUB_TOKEN_FILE", tc.env)
+			flag.CommandLine = flag.NewFlagSet("test", flag.ExitOnError)
+			os.Args = tc.args
+			cfg, err := parseArgs()
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("parseArgs() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if err == nil && cfg.Token != tc.expected {
+				t.Errorf("Token = %q, want %q", cfg.Token, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// tuiRow is one line of the --tui list: a release, one of the assets of an
+// expanded release when asset is not -1, or with --notes a line of its
+// release notes when note, counted from 1, is not 0.
+type tuiRow struct {
+	release int
+	asset   int
+	note    int
+}
+
+// tuiModel is the state of the --tui browser, kept apart from the terminal
+// so it can be driven by tests.
+type tuiModel struct {
+	releases []NormalizedRelease
+	notes    [][]string // wrapped release notes per release, with --notes
+	expanded map[int]bool
+	cursor   int
+	offset   int
+	status   string
+}
+
+func newTUIModel(releases []NormalizedRelease) *tuiModel {
+	return &tuiModel{releases: releases, expanded: make(map[int]bool)}
+}
+
+// showNotes wraps the release notes to width columns, or not at all when
+// width is -1, so expanded releases list them above their assets.
+func (m *tuiModel) showNotes(width int) {
+	m.notes = make([][]string, len(m.releases))
+	for i, r := range m.releases {
+		notes := strings.TrimSpace(strings.ReplaceAll(r.Description, "\r\n", "\n"))
+		if notes == "" {
+			continue
+		}
+		if width > 0 {
+			notes = wrapText(notes, max(width-len(notesIndent)-2, 20))
+		}
+		m.notes[i] = strings.Split(notes, "\n")
+	}
+}
+
+// rows lists the visible lines: every release, each followed by its
+// release notes, if shown, and assets when expanded.
+func (m *tuiModel) rows() []tuiRow {
+	var rows []tuiRow
+	for i, r := range m.releases {
+		rows = append(rows, tuiRow{release: i, asset: -1})
+		if m.expanded[i] {
+			if m.notes != nil {
+				for j := range m.notes[i] {
+					rows = append(rows, tuiRow{release: i, asset: -1, note: j + 1})
+				}
+			}
+			for j := range r.Assets {
+				rows = append(rows, tuiRow{release: i, asset: j})
+			}
+		}
+	}
+	return rows
+}
+
+// move shifts the cursor by delta rows, stopping at either end.
+func (m *tuiModel) move(delta int) {
+	n := len(m.rows())
+	m.cursor = max(0, min(m.cursor+delta, n-1))
+}
+
+// toggle expands or collapses the release under the cursor. On an asset or
+// notes row it collapses that release and moves the cursor back to it.
+func (m *tuiModel) toggle() {
+	rows := m.rows()
+	if len(rows) == 0 {
+		return
+	}
+	row := rows[m.cursor]
+	if row.asset >= 0 || row.note > 0 || m.expanded[row.release] {
+		delete(m.expanded, row.release)
+		for i, r := range m.rows() {
+			if r.release == row.release && r.asset < 0 && r.note == 0 {
+				m.cursor = i
+				break
+			}
+		}
+		return
+	}
+	m.expanded[row.release] = true
+}
+
+// selected returns the release and asset under the cursor; ok is false
+// when the cursor is on a release row.
+func (m *tuiModel) selected() (NormalizedRelease, NormalizedAsset, bool) {
+	rows := m.rows()
+	if len(rows) == 0 || rows[m.cursor].asset < 0 {
+		return NormalizedRelease{}, NormalizedAsset{}, false
+	}
+	row := rows[m.cursor]
+	r := m.releases[row.release]
+	return r, r.Assets[row.asset], true
+}
+
+// view renders the list to height lines of at most width columns: a
+// header, as many rows as fit, scrolled to keep the cursor in view, and a
+// status line. The selected row is marked with ">" and, when colored, shown
+// in reverse video; release notes are then rendered as Markdown.
+func (m *tuiModel) view(width, height int, colored bool) []string {
+	rows := m.rows()
+	visible := max(1, height-2)
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	} else if m.cursor >= m.offset+visible {
+		m.offset = m.cursor - visible + 1
+	}
+
+	lines := []string{truncateCell(fmt.Sprintf("%d releases · ↑/↓ move · enter expand · d download · q quit", len(m.releases)), width)}
+	for i := m.offset; i < len(rows) && i < m.offset+visible; i++ {
+		row := rows[i]
+		r := m.releases[row.release]
+		var text string
+		if row.note > 0 {
+			text = notesIndent + m.notes[row.release][row.note-1]
+		} else if row.asset < 0 {
+			marker := "+"
+			if m.expanded[row.release] {
+				marker = "-"
+			}
+			pre := ""
+			if r.IsPrerelease {
+				pre = " (prerelease)"
+			}
+			text = fmt.Sprintf("%s %s  %s  %d assets%s", marker, r.Version, r.PublishedAt.Format("2006-01-02"), len(r.Assets), pre)
+		} else {
+			a := r.Assets[row.asset]
+			text = fmt.Sprintf("    %s  %s", a.Name, formatBytes(a.Size))
+		}
+		prefix := "  "
+		if i == m.cursor {
+			prefix = "> "
+		}
+		text = truncateCell(prefix+text, width)
+		if row.note > 0 && colored {
+			text = styleMarkdownLine(text)
+		}
+		if i == m.cursor && colored {
+			text = "\x1b[7m" + text + "\x1b[0m"
+		}
+		lines = append(lines, text)
+	}
+	for len(lines) < height-1 {
+		lines = append(lines, "")
+	}
+	return append(lines, truncateCell(m.status, width))
+}
+
+// tuiKey maps the bytes of one key press to a --tui command.
+func tuiKey(b []byte) string {
+	switch string(b) {
+	case "\x1b[A", "k":
+		return "up"
+	case "\x1b[B", "j":
+		return "down"
+	case "\x1b[5~":
+		return "pgup"
+	case "\x1b[6~":
+		return "pgdown"
+	case "\r", "\n", " ":
+		return "toggle"
+	case "d":
+		return "download"
+	case "q", "\x1b", "\x03":
+		return "quit"
+	}
+	return ""
+}
+
+// checkTUITerminal returns an error unless stdin and stdout are both
+// terminals, so --tui fails before fetching instead of waiting on a pipe.
+func checkTUITerminal() error {
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return errors.New("--tui needs an interactive terminal on stdin and stdout")
+	}
+	return nil
+}
+
+// runTUI opens the --tui browser over output's releases.
+func runTUI(cfg *Config, output *OutputFile) error {
+	if err := checkTUITerminal(); err != nil {
+		return err
+	}
+	in, out := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	if len(output.Releases) == 0 {
+		return errors.New("no releases to browse")
+	}
+
+	state, err := term.MakeRaw(in)
+	if err != nil {
+		return fmt.Errorf("failed to switch the terminal to raw mode: %w", err)
+	}
+	// Use the alternate screen so the list does not scroll the shell away.
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	defer func() {
+		fmt.Print("\x1b[?25h\x1b[?1049l")
+		_ = term.Restore(in, state)
+	}()
+
+	m := newTUIModel(output.Releases)
+	colored := colorEnabled(cfg.NoColor, os.Stdout)
+	buf := make([]byte, 8)
+	notesWidth := 0
+	for {
+		width, height, err := term.GetSize(out)
+		if err != nil || width <= 0 || height <= 0 {
+			width, height = defaultTerminalWidth, 24
+		}
+		// --wrap 0 follows the window as it is resized.
+		wrap := cfg.Wrap
+		if wrap == 0 {
+			wrap = width
+		}
+		if cfg.Notes && wrap != notesWidth {
+			m.showNotes(wrap)
+			notesWidth = wrap
+			m.move(0) // rewrapping may have removed the row under the cursor
+		}
+		fmt.Print("\x1b[H\x1b[2J" + strings.Join(m.view(width, height, colored), "\r\n"))
+
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("failed to read from the terminal: %w", err)
+		}
+		switch tuiKey(buf[:n]) {
+		case "up":
+			m.move(-1)
+		case "down":
+			m.move(1)
+		case "pgup":
+			m.move(-(height - 2))
+		case "pgdown":
+			m.move(height - 2)
+		case "toggle":
+			m.toggle()
+		case "download":
+			m.status = tuiDownload(cfg, output, m)
+		case "quit":
+			return nil
+		}
+	}
+}
+
+// tuiDownload saves the asset under the cursor to its --download-name path
+// in --download-dir and returns the message for the status line.
+func tuiDownload(cfg *Config, output *OutputFile, m *tuiModel) string {
+	r, a, ok := m.selected()
+	if !ok {
+		return "Select an asset to download (enter expands a release)"
+	}
+	r.Assets = []NormalizedAsset{a}
+	plan, _, err := planDownloads(output.Repository.Owner, output.Repository.Repo, []NormalizedRelease{r}, cfg.DownloadName)
+	if err != nil {
+		return "Download failed: " + err.Error()
+	}
+	path := filepath.Join(cfg.DownloadDir, plan[0].Path)
+	if err := downloadAsset(context.Background(), downloadClient, a, path, cfg.Retries, nil); err != nil {
+		return "Download failed: " + err.Error()
+	}
+	return "Downloaded " + path
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTUIModel(t *testing.T) {
+	m := newTUIModel([]NormalizedRelease{
+		{Version: "v2.0.0", Assets: []NormalizedAsset{{Name: "gale-linux"}, {Name: "gale-darwin"}}},
+		{Version: "v1.0.0", Assets: []NormalizedAsset{{Name: "gale-old"}}},
+	})
+
+	if _, _, ok := m.selected(); ok {
+		t.Error("selected() on a release row reported an asset")
+	}
+	m.toggle()
+	if got := len(m.rows()); got != 4 {
+		t.Fatalf("rows() after expanding = %d, want 4", got)
+	}
+	m.move(2)
+	if _, a, ok := m.selected(); !ok || a.Name != "gale-darwin" {
+		t.Errorf("selected() = %q, %v, want gale-darwin", a.Name, ok)
+	}
+	m.move(10)
+	if m.cursor != 3 {
+		t.Errorf("cursor after moving past the end = %d, want 3", m.cursor)
+	}
+
+	m.move(-2)
+	m.toggle()
+	if m.cursor != 0 || len(m.rows()) != 2 {
+		t.Errorf("toggle() on an asset row left cursor %d and %d rows, want 0 and 2", m.cursor, len(m.rows()))
+	}
+	m.move(-5)
+	if m.cursor != 0 {
+		t.Errorf("cursor after moving before the start = %d, want 0", m.cursor)
+	}
+}
+
+func TestTUIModelView(t *testing.T) {
+	var releases []NormalizedRelease
+	for _, tag := range []string{"v5", "v4", "v3", "v2", "v1"} {
+		releases = append(releases, NormalizedRelease{Version: tag})
+	}
+	m := newTUIModel(releases)
+	m.move(4)
+	m.status = "Downloaded gale-linux"
+
+	lines := m.view(40, 5, false)
+	if len(lines) != 5 {
+		t.Fatalf("view() returned %d lines, want 5", len(lines))
+	}
+	if !strings.HasPrefix(lines[3], "> + v1") {
+		t.Errorf("view() did not scroll to the cursor: %q", lines)
+	}
+	if !strings.HasPrefix(lines[1], "  + v3") {
+		t.Errorf("view() first row = %q, want v3", lines[1])
+	}
+	if lines[4] != "Downloaded gale-linux" {
+		t.Errorf("view() status line = %q", lines[4])
+	}
+	for _, line := range lines {
+		if strings.Contains(line, "\x1b[") {
+			t.Errorf("view() without color contains ANSI escapes: %q", line)
+		}
+		if n := len([]rune(line)); n > 40 {
+			t.Errorf("line %q is %d columns wide, want at most 40", line, n)
+		}
+	}
+}
+
+func TestTUIKey(t *testing.T) {
+	testCases := []struct {
+		in       string
+		expected string
+	}{
+		{"\x1b[A", "up"},
+		{"j", "down"},
+		{"\r", "toggle"},
+		{"d", "download"},
+		{"q", "quit"},
+		{"\x03", "quit"},
+		{"x", ""},
+	}
+
+	for _, tc := range testCases {
+		if got := tuiKey([]byte(tc.in)); got != tc.expected {
+			t.Errorf("tuiKey(%q) = %q, want %q", tc.in, got, tc.expected)
+		}
+	}
+}
+
+func TestTUIModelNotes(t *testing.T) {
+	m := newTUIModel([]NormalizedRelease{
+		{Version: "v2.0.0", Description: "Fixes **everything**", Assets: []NormalizedAsset{{Name: "gale-linux"}}},
+		{Version: "v1.0.0"},
+	})
+	m.showNotes(80)
+	m.toggle()
+	if got := len(m.rows()); got != 4 {
+		t.Fatalf("rows() after expanding with notes = %d, want 4", got)
+	}
+	m.move(1)
+	if _, _, ok := m.selected(); ok {
+		t.Error("selected() on a notes row reported an asset")
+	}
+	if lines := m.view(80, 6, false); lines[2] != ">     Fixes **everything**" {
+		t.Errorf("view() notes row = %q", lines[2])
+	}
+
+	if lines := m.view(80, 6, true); !strings.Contains(lines[2], ansiBold+"everything"+ansiBoldOff) {
+		t.Errorf("view() with color did not render the notes: %q", lines[2])
+	}
+	m.toggle()
+	if m.cursor != 0 || len(m.rows()) != 2 {
+		t.Errorf("toggle() on a notes row left cursor %d and %d rows, want 0 and 2", m.cursor, len(m.rows()))
+	}
+}
+
+func TestTUIModelNotesWrap(t *testing.T) {
+	long := strings.TrimSpace(strings.Repeat("word ", 20))
+	m := newTUIModel([]NormalizedRelease{{Version: "v2.0.0", Description: long}})
+	m.showNotes(40)
+	if got := len(m.notes[0]); got < 2 {
+		t.Errorf("showNotes(40) kept %d lines, want the notes wrapped", got)
+	}
+	m.showNotes(-1)
+	if got := m.notes[0]; len(got) != 1 || got[0] != long {
+		t.Errorf("showNotes(-1) = %q, want the notes unwrapped", got)
+	}
+}
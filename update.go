@@ -0,0 +1,291 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// gale's own repository, which --check-update and --self-update read.
+const (
+	selfOwner = "Typeflu"
+	selfRepo  = "gale"
+)
+
+// updateAvailable reports whether latest is a higher semantic version than
+// current. Tags that are not semver never count as an update.
+func updateAvailable(current, latest string) bool {
+	cur, ok := parseSemver(current)
+	if !ok {
+		return false
+	}
+	lat, ok := parseSemver(latest)
+	return ok && lat.compare(cur) > 0
+}
+
+// archAliases are the names release assets commonly use for each GOARCH.
+var archAliases = map[string][]string{
+	"amd64": {"amd64", "x86_64", "x64"},
+	"arm64": {"arm64", "aarch64"},
+	"386":   {"386", "i386", "x86"},
+	"arm":   {"armv7", "armv6", "arm"},
+}
+
+// archPattern matches name as a token of an asset name, delimited by _, .
+// or - or the ends of the name, so arm does not match arm64.
+func archPattern(name string) *regexp.Regexp {
+	return regexp.MustCompile(`(^|[_.-])` + regexp.QuoteMeta(name) + `($|[_.-])`)
+}
+
+// archToken is one alias of archAliases with its pattern.
+type archToken struct {
+	goarch  string
+	alias   string
+	pattern *regexp.Regexp
+}
+
+// archTokens holds every alias in archAliases, longest first, so that
+// x86_64 is seen before the x86 inside it.
+var archTokens = func() []archToken {
+	var tokens []archToken
+	for goarch, aliases := range archAliases {
+		for _, alias := range aliases {
+			tokens = append(tokens, archToken{goarch, alias, archPattern(alias)})
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		if len(tokens[i].alias) != len(tokens[j].alias) {
+			return len(tokens[i].alias) > len(tokens[j].alias)
+		}
+		return tokens[i].alias < tokens[j].alias
+	})
+	return tokens
+}()
+
+// assetArch returns the GOARCH an asset name is built for, or "" if it
+// names none of archAliases.
+func assetArch(name string) string {
+	for _, t := range archTokens {
+		if t.pattern.MatchString(name) {
+			return t.goarch
+		}
+	}
+	return ""
+}
+
+// selfUpdateAsset picks the asset of a gale release built for goos and
+// goarch, skipping checksums and signatures.
+func selfUpdateAsset(assets []NormalizedAsset, goos, goarch string) (NormalizedAsset, bool) {
+	for _, a := range assets {
+		name := strings.ToLower(a.Name)
+		if !strings.Contains(name, goos) {
+			continue
+		}
+		switch filepath.Ext(name) {
+		case ".sha256", ".sha512", ".sig", ".asc", ".pem", ".txt", ".sbom", ".json":
+			continue
+		}
+		arch := assetArch(name)
+		if arch == goarch || arch == "" && archAliases[goarch] == nil && archPattern(goarch).MatchString(name) {
+			return a, true
+		}
+	}
+	return NormalizedAsset{}, false
+}
+
+// verifySelfUpdate checks the downloaded asset at path against the SHA-256
+// sum the release publishes for it, and refuses an asset without one.
+func verifySelfUpdate(ctx context.Context, client *http.Client, release NormalizedRelease, asset NormalizedAsset, path string) error {
+	sums, err := fetchChecksums(ctx, client, release)
+	if err != nil {
+		return err
+	}
+	want, ok := sums[asset.Name]
+	if !ok {
+		return fmt.Errorf("release %s publishes no checksum for %s; not installing it", release.Version, asset.Name)
+	}
+	got, err := fileSHA256(path)
+	if err != nil {
+		return fmt.Errorf("failed to hash %s: %w", asset.Name, err)
+	}
+	if got != want {
+		return fmt.Errorf("checksum mismatch for %s: want %s, got %s; not installing it", asset.Name, want, got)
+	}
+	return nil
+}
+
+// selfBinaryName is the name of the gale executable inside release archives.
+func selfBinaryName() string {
+	if runtime.GOOS == "windows" {
+		return "gale.exe"
+	}
+	return "gale"
+}
+
+// extractSelfBinary copies the gale executable out of the downloaded asset
+// at path into w. Assets that are not .tar.gz, .tgz or .zip archives are
+// taken to be the executable itself.
+func extractSelfBinary(path, name string, w io.Writer) error {
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		tr := tar.NewReader(gz)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", name, err)
+			}
+			if hdr.Typeflag == tar.TypeReg && filepath.Base(hdr.Name) == selfBinaryName() {
+				_, err := io.Copy(w, tr)
+				return err
+			}
+		}
+	case strings.HasSuffix(name, ".zip"):
+		zr, err := zip.OpenReader(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		defer zr.Close()
+		for _, f := range zr.File {
+			if filepath.Base(f.Name) != selfBinaryName() {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return err
+			}
+			defer rc.Close()
+			_, err = io.Copy(w, rc)
+			return err
+		}
+	default:
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(w, f)
+		return err
+	}
+	return fmt.Errorf("%s does not contain %s", name, selfBinaryName())
+}
+
+// replaceExecutable swaps the running executable for the one at newPath.
+// Windows cannot overwrite a running program, so the old one is moved
+// aside first.
+func replaceExecutable(exe, newPath string) error {
+	if runtime.GOOS == "windows" {
+		old := exe + ".old"
+		_ = os.Remove(old)
+		if err := os.Rename(exe, old); err != nil {
+			return err
+		}
+	}
+	return os.Rename(newPath, exe)
+}
+
+// runCheckUpdate looks up gale's latest stable release on github.com, says
+// whether it is newer than this build and, with --self-update, installs it
+// over the running executable. --timeout bounds the release lookup but not
+// the download.
+func runCheckUpdate(ctx context.Context, cfg *Config) error {
+	// gale is released on github.com; an Enterprise token is no use there.
+	token := cfg.Token
+	if ghHostname(cfg.APIURL) != "github.com" {
+		token = ""
+	}
+	if err := setAPIURL(defaultAPIURL); err != nil {
+		return err
+	}
+	lookupCtx := ctx
+	if cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		lookupCtx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+		defer cancel()
+	}
+	result, err := fetchLatestRelease(lookupCtx, selfOwner, selfRepo, false, token)
+	if err != nil {
+		return fmt.Errorf("failed to check for updates: %w", err)
+	}
+	releases := normalizeData(result.Data.Repository.Releases.Nodes)
+	if len(releases) == 0 {
+		return fmt.Errorf("%s/%s has no published stable release", selfOwner, selfRepo)
+	}
+	latest := releases[0]
+
+	if !updateAvailable(version, latest.Version) {
+		infoLog("%s gale v%s is up to date (latest: %s)\n", icons["success"], version, latest.Version)
+		return nil
+	}
+	infoLog("%s gale %s is available (you have v%s): %s\n", icons["info"], latest.Version, version, latest.URL)
+	if !cfg.SelfUpdate {
+		return nil
+	}
+
+	asset, ok := selfUpdateAsset(latest.Assets, runtime.GOOS, runtime.GOARCH)
+	if !ok {
+		return fmt.Errorf("release %s has no asset for %s/%s", latest.Version, runtime.GOOS, runtime.GOARCH)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("could not locate the gale executable: %w", err)
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return fmt.Errorf("could not locate the gale executable: %w", err)
+	}
+
+	tmp, err := os.MkdirTemp("", "gale-update-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	archive := filepath.Join(tmp, sanitizePathComponent(asset.Name))
+	if err := downloadAsset(ctx, downloadClient, asset, archive, cfg.Retries, nil); err != nil {
+		return err
+	}
+	if err := verifySelfUpdate(ctx, downloadClient, latest, asset, archive); err != nil {
+		return err
+	}
+
+	// Stage the new binary next to the old one so the final rename does not
+	// cross file systems.
+	staged := exe + ".new"
+	f, err := os.OpenFile(staged, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o755)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", staged, err)
+	}
+	err = extractSelfBinary(archive, strings.ToLower(asset.Name), f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = replaceExecutable(exe, staged)
+	}
+	if err != nil {
+		_ = os.Remove(staged)
+		return fmt.Errorf("failed to install %s: %w", latest.Version, err)
+	}
+	infoLog("%s Updated %s to %s\n", icons["success"], exe, latest.Version)
+	return nil
+}
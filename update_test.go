@@ -0,0 +1,162 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUpdateAvailable(t *testing.T) {
+	testCases := []struct {
+		current, latest string
+		expected        bool
+	}{
+		{"4.5.0", "v4.6.0", true},
+		{"4.5.0", "v4.5.0", false},
+		{"4.5.0", "v4.4.9", false},
+		{"4.5.0", "v4.5.1-rc.1", true},
+		{"4.5.0", "nightly", false},
+		{"dev", "v4.6.0", false},
+	}
+
+	for _, tc := range testCases {
+		if got := updateAvailable(tc.current, tc.latest); got != tc.expected {
+			t.Errorf("updateAvailable(%q, %q) = %v, want %v", tc.current, tc.latest, got, tc.expected)
+		}
+	}
+}
+
+func TestSelfUpdateAsset(t *testing.T) {
+	assets := []NormalizedAsset{
+		{Name: "checksums.txt"},
+		{Name: "gale_4.6.0_linux_x86_64.tar.gz.sha256"},
+		{Name: "gale_4.6.0_linux_x86_64.tar.gz"},
+		{Name: "gale_4.6.0_linux_arm64.tar.gz"},
+		{Name: "gale_4.6.0_Darwin_arm64.zip"},
+		{Name: "gale_4.6.0_windows_amd64.zip"},
+	}
+
+	testCases := []struct {
+		goos, goarch string
+		expected     string
+	}{
+		{"linux", "amd64", "gale_4.6.0_linux_x86_64.tar.gz"},
+		{"linux", "arm64", "gale_4.6.0_linux_arm64.tar.gz"},
+		{"darwin", "arm64", "gale_4.6.0_Darwin_arm64.zip"},
+		{"windows", "amd64", "gale_4.6.0_windows_amd64.zip"},
+		{"freebsd", "amd64", ""},
+	}
+
+	for _, tc := range testCases {
+		got, ok := selfUpdateAsset(assets, tc.goos, tc.goarch)
+		if got.Name != tc.expected || ok != (tc.expected != "") {
+			t.Errorf("selfUpdateAsset(%s/%s) = %q, %v, want %q", tc.goos, tc.goarch, got.Name, ok, tc.expected)
+		}
+	}
+}
+
+func TestSelfUpdateAssetArchTokens(t *testing.T) {
+	assets := []NormalizedAsset{
+		{Name: "gale_4.6.0_linux_arm64.tar.gz"},
+		{Name: "gale_4.6.0_linux_x86_64.tar.gz"},
+		{Name: "gale_4.6.0_linux_armv7.tar.gz"},
+		{Name: "gale_4.6.0_linux_x86.tar.gz"},
+	}
+
+	testCases := []struct {
+		goos, goarch string
+		expected     string
+	}{
+		{"linux", "arm", "gale_4.6.0_linux_armv7.tar.gz"},
+		{"linux", "386", "gale_4.6.0_linux_x86.tar.gz"},
+		{"linux", "amd64", "gale_4.6.0_linux_x86_64.tar.gz"},
+		{"linux", "arm64", "gale_4.6.0_linux_arm64.tar.gz"},
+		{"linux", "riscv64", ""},
+	}
+
+	for _, tc := range testCases {
+		got, ok := selfUpdateAsset(assets, tc.goos, tc.goarch)
+		if got.Name != tc.expected || ok != (tc.expected != "") {
+			t.Errorf("selfUpdateAsset(%s/%s) = %q, %v, want %q", tc.goos, tc.goarch, got.Name, ok, tc.expected)
+		}
+	}
+}
+
+func TestVerifySelfUpdate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "gale_linux_amd64.tar.gz")
+	if err := os.WriteFile(path, []byte("new gale"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte("new gale"))
+	good := hex.EncodeToString(sum[:])
+	bad := strings.Repeat("0", 64)
+
+	testCases := []struct {
+		name      string
+		checksums string
+		wantErr   bool
+	}{
+		{"Matching sum", good + "  gale_linux_amd64.tar.gz\n", false},
+		{"Mismatched sum", bad + "  gale_linux_amd64.tar.gz\n", true},
+		{"No sum for the asset", good + "  gale_linux_arm64.tar.gz\n", true},
+	}
+
+	for _, tc := range testCases {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(tc.checksums))
+		}))
+		asset := NormalizedAsset{Name: "gale_linux_amd64.tar.gz"}
+		release := NormalizedRelease{Version: "v4.6.0", Assets: []NormalizedAsset{asset, {Name: "checksums.txt", DownloadURL: srv.URL}}}
+		err := verifySelfUpdate(context.Background(), srv.Client(), release, asset, path)
+		srv.Close()
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: verifySelfUpdate() error = %v, want error %v", tc.name, err, tc.wantErr)
+		}
+	}
+}
+
+func TestExtractSelfBinary(t *testing.T) {
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	for name, body := range map[string]string{"gale_4.6.0/README.md": "readme", "gale_4.6.0/" + selfBinaryName(): "new binary"} {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o755, Size: int64(len(body)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "gale.tar.gz")
+	if err := os.WriteFile(path, archive.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := extractSelfBinary(path, "gale.tar.gz", &out); err != nil {
+		t.Fatalf("extractSelfBinary() error = %v", err)
+	}
+	if out.String() != "new binary" {
+		t.Errorf("extractSelfBinary() wrote %q, want %q", out.String(), "new binary")
+	}
+
+	if err := extractSelfBinary(filepath.Join(t.TempDir(), "missing"), "gale", &out); err == nil {
+		t.Error("extractSelfBinary() of a missing file succeeded, want error")
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// runWait implements `gale wait owner repo --tag v2.0.0`, polling until the
+// tag has been published or the timeout elapses.
+func runWait(args []string) error {
+	fs := flag.NewFlagSet("wait", flag.ContinueOnError)
+	tag := fs.String("tag", "", "Tag to wait for")
+	interval := fs.Duration("interval", 30*time.Second, "Time between polls")
+	timeout := fs.Duration("timeout", time.Hour, "Give up after this long (0 waits forever)")
+	token := fs.String("token", os.Getenv("GITHUB_TOKEN"), "GitHub token")
+	fs.StringVar(token, "t", os.Getenv("GITHUB_TOKEN"), "GitHub token (shorthand)")
+	quiet := fs.Bool("quiet", false, "Quiet mode (minimal output)")
+	fs.BoolVar(quiet, "q", false, "Quiet mode (shorthand)")
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	registerSecret(*token)
+	if len(positional) != 2 {
+		return fmt.Errorf("usage: gale wait <owner> <repo> --tag <tag> [--interval 30s] [--timeout 1h]")
+	}
+	if *tag == "" {
+		return fmt.Errorf("--tag is required")
+	}
+	if *interval <= 0 {
+		return fmt.Errorf("--interval must be positive, got %s", *interval)
+	}
+	owner, repo := positional[0], positional[1]
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	if !*quiet {
+		infoLog("%s Waiting for %s in %s", icons["gear"], magenta(*tag), bright(owner+"/"+repo))
+	}
+	release, err := pollForTag(ctx, owner, repo, *tag, *token, *interval, func(err error) {
+		if *quiet {
+			return
+		}
+		if err != nil {
+			warningLog("\n%s Poll failed, retrying: %v\n", icons["warning"], err)
+			return
+		}
+		fmt.Print(".")
+	})
+	if !*quiet {
+		fmt.Println()
+	}
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("timed out after %s waiting for %s", *timeout, *tag)
+		}
+		return err
+	}
+
+	successLog("%s %s was published on %s\n", icons["check"], magenta(release.TagName), release.PublishedAt.Format("Jan 02, 2006 15:04 MST"))
+	return nil
+}
+
+// pollForTag checks for tag every interval until it exists or ctx is done.
+// Transient errors are passed to progress and retried; a missing repository
+// ends the wait immediately.
+func pollForTag(ctx context.Context, owner, repo, tag, token string, interval time.Duration, progress func(error)) (*ReleaseNode, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		release, err := fetchReleaseByTag(ctx, owner, repo, tag, token)
+		switch {
+		case err == nil && release != nil && !release.IsDraft:
+			return release, nil
+		case errors.Is(err, ErrRepoNotFound):
+			return nil, err
+		case ctx.Err() != nil:
+			return nil, ctx.Err()
+		}
+		progress(err)
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
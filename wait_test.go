@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newWaitServer answers release-by-tag queries with the given bodies in
+// turn, repeating the last one.
+func newWaitServer(t *testing.T, bodies ...string) (*httptest.Server, *int) {
+	t.Helper()
+	polls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := bodies[min(polls, len(bodies)-1)]
+		polls++
+		_, _ = w.Write([]byte(body))
+	}))
+	oldEndpoint := graphqlEndpoint
+	graphqlEndpoint = srv.URL
+	t.Cleanup(func() {
+		graphqlEndpoint = oldEndpoint
+		srv.Close()
+	})
+	return srv, &polls
+}
+
+const (
+	waitMissing   = `{"data":{"repository":{"releases":{"totalCount":1},"release":null}}}`
+	waitPublished = `{"data":{"repository":{"releases":{"totalCount":2},"release":{"tagName":"v2.0.0","publishedAt":"2024-05-01T00:00:00Z"}}}}`
+	waitNotFound  = `{"data":{"repository":null},"errors":[{"type":"NOT_FOUND","message":"Could not resolve to a Repository"}]}`
+)
+
+func TestPollForTag(t *testing.T) {
+	_, polls := newWaitServer(t, waitMissing, `{"message":"oops"`, waitMissing, waitPublished)
+
+	var failures, misses int
+	release, err := pollForTag(context.Background(), "Typeflu", "gale", "v2.0.0", "", time.Millisecond, func(err error) {
+		if err != nil {
+			failures++
+		} else {
+			misses++
+		}
+	})
+	if err != nil {
+		t.Fatalf("pollForTag() error = %v", err)
+	}
+	if release.TagName != "v2.0.0" || *polls != 4 {
+		t.Errorf("pollForTag() = %s after %d polls, want v2.0.0 after 4", release.TagName, *polls)
+	}
+	if failures != 1 || misses != 2 {
+		t.Errorf("progress saw %d failures and %d misses, want 1 and 2", failures, misses)
+	}
+}
+
+func TestPollForTagRepoNotFound(t *testing.T) {
+	_, polls := newWaitServer(t, waitNotFound)
+
+	_, err := pollForTag(context.Background(), "Typeflu", "missing", "v2.0.0", "", time.Millisecond, func(error) {})
+	if !errors.Is(err, ErrRepoNotFound) {
+		t.Errorf("pollForTag() error = %v, want ErrRepoNotFound", err)
+	}
+	if *polls != 1 {
+		t.Errorf("pollForTag() polled %d times, want to stop after 1", *polls)
+	}
+}
+
+func TestRunWaitTimeout(t *testing.T) {
+	newWaitServer(t, waitMissing)
+
+	err := runWait([]string{"Typeflu", "gale", "--tag", "v2.0.0", "--interval", "10ms", "--timeout", "50ms", "--quiet"})
+	if err == nil || !strings.Contains(err.Error(), "timed out after 50ms waiting for v2.0.0") {
+		t.Errorf("runWait() error = %v, want the timeout error", err)
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"time"
+)
+
+const defaultWatchInterval = 5 * time.Minute
+
+// releaseWatcher remembers the tags seen for each repository so that only
+// releases published since watching started are reported.
+type releaseWatcher struct {
+	seen   map[string]map[string]bool
+	latest map[string]string
+}
+
+func newReleaseWatcher() *releaseWatcher {
+	return &releaseWatcher{seen: make(map[string]map[string]bool), latest: make(map[string]string)}
+}
+
+// update records releases, newest first, as the current state of source and
+// returns those not seen before, oldest first. The first update of a source
+// only records what is already there.
+func (w *releaseWatcher) update(source string, releases []NormalizedRelease) []NormalizedRelease {
+	seen, primed := w.seen[source]
+	if !primed {
+		seen = make(map[string]bool)
+		w.seen[source] = seen
+	}
+	var fresh []NormalizedRelease
+	for i := len(releases) - 1; i >= 0; i-- {
+		r := releases[i]
+		if r.IsDraft || seen[r.Version] {
+			continue
+		}
+		seen[r.Version] = true
+		if primed {
+			fresh = append(fresh, r)
+		}
+	}
+	for _, r := range releases {
+		if !r.IsDraft {
+			w.latest[source] = r.Version
+			break
+		}
+	}
+	return fresh
+}
+
+// watchReleases fetches repos every cfg.Interval until ctx is done and calls
+// notify for each release that was not there before. Failed polls are
+// reported and retried on the next tick; a missing repository stops the
+// watch. Cancelling ctx ends it without an error.
+func watchReleases(ctx context.Context, cfg *Config, repos []repoRef, notify func(repoRef, NormalizedRelease)) error {
+	fetch := fetchRepository
+	if cfg.REST {
+		fetch = fetchRepositoryREST
+	}
+	rules := newChannelRules(cfg.AlphaSuffixes, cfg.BetaSuffixes)
+	watcher := newReleaseWatcher()
+
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for first := true; ; first = false {
+		pollCtx, cancel := ctx, context.CancelFunc(func() {})
+		if cfg.Timeout > 0 {
+			pollCtx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+		}
+		responses, err := fetchRepos(pollCtx, repos, cfg.Concurrency, func(ctx context.Context, ref repoRef) (*GraphQLResponse, error) {
+			return fetch(ctx, ref.Owner, ref.Repo, cfg.Count, cfg.Token)
+		})
+		cancel()
+		switch {
+		case ctx.Err() != nil:
+			return nil
+		case errors.Is(err, ErrRepoNotFound):
+			return err
+		case err != nil:
+			warningLog("%s Poll failed, retrying in %s: %v\n", icons["warning"], cfg.Interval, err)
+		default:
+			for i, res := range responses {
+				releases := applyChannels(normalizeData(res.Data.Repository.Releases.Nodes), rules, cfg.Channel)
+				if cfg.TagFilter != "" {
+					releases = filterTags(releases, cfg.TagFilter)
+				}
+				source := repos[i].String()
+				for _, r := range watcher.update(source, releases) {
+					notify(repos[i], r)
+				}
+				if first && !cfg.Quiet {
+					latest := watcher.latest[source]
+					if latest == "" {
+						latest = "none yet"
+					}
+					infoLog("%s Watching %s every %s, latest is %s\n", icons["gear"], bright(source), cfg.Interval, magenta(latest))
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// runWatch implements --watch. It runs until interrupted with Ctrl-C.
+func runWatch(cfg *Config, repos []repoRef) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err := watchReleases(ctx, cfg, repos, func(ref repoRef, r NormalizedRelease) {
+		successLog("%s New release %s in %s published on %s\n", icons["sparkles"], magenta(r.Version), bright(ref.String()), r.PublishedAt.Format("Jan 02, 2006 15:04 MST"))
+	})
+	if err == nil && !cfg.Quiet {
+		infoLog("%s Stopped watching\n", icons["info"])
+	}
+	return err
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReleaseWatcherUpdate(t *testing.T) {
+	tags := func(releases []NormalizedRelease) []string {
+		var out []string
+		for _, r := range releases {
+			out = append(out, r.Version)
+		}
+		return out
+	}
+	w := newReleaseWatcher()
+
+	if got := w.update("cli/gh", []NormalizedRelease{{Version: "v1.1.0"}, {Version: "v1.0.0"}}); got != nil {
+		t.Errorf("first update() = %v, want nothing", tags(got))
+	}
+	if w.latest["cli/gh"] != "v1.1.0" {
+		t.Errorf("latest = %q, want v1.1.0", w.latest["cli/gh"])
+	}
+
+	got := w.update("cli/gh", []NormalizedRelease{{Version: "v1.3.0-draft", IsDraft: true}, {Version: "v1.2.1"}, {Version: "v1.2.0"}, {Version: "v1.1.0"}})
+	if want := []string{"v1.2.0", "v1.2.1"}; !reflect.DeepEqual(tags(got), want) {
+		t.Errorf("update() = %v, want %v", tags(got), want)
+	}
+	if w.latest["cli/gh"] != "v1.2.1" {
+		t.Errorf("latest = %q, want v1.2.1", w.latest["cli/gh"])
+	}
+
+	if got := w.update("cli/gh", []NormalizedRelease{{Version: "v1.2.1"}}); got != nil {
+		t.Errorf("update() without changes = %v, want nothing", tags(got))
+	}
+	if got := w.update("golang/go", []NormalizedRelease{{Version: "go1.22.0"}}); got != nil {
+		t.Errorf("first update() of another repo = %v, want nothing", tags(got))
+	}
+}
+
+func TestWatchReleases(t *testing.T) {
+	var polls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(polls.Add(1))
+		var releases Releases
+		for i := n; i >= 1; i-- {
+			releases.Nodes = append(releases.Nodes, ReleaseNode{TagName: fmt.Sprintf("v1.%d.0", i)})
+		}
+		releases.TotalCount = n
+		_ = json.NewEncoder(w).Encode(GraphQLResponse{Data: &GraphQLData{Repository: &Repository{Releases: releases}}})
+	}))
+	defer srv.Close()
+
+	oldEndpoint := graphqlEndpoint
+	graphqlEndpoint = srv.URL
+	defer func() { graphqlEndpoint = oldEndpoint }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cfg := &Config{Count: 10, Concurrency: 1, Interval: 10 * time.Millisecond, Quiet: true}
+	var seen []string
+	err := watchReleases(ctx, cfg, []repoRef{{Owner: "cli", Repo: "gh"}}, func(ref repoRef, r NormalizedRelease) {
+		seen = append(seen, r.Version)
+		if len(seen) == 2 {
+			cancel()
+		}
+	})
+	if err != nil {
+		t.Fatalf("watchReleases() error = %v, want nil after cancel", err)
+	}
+	if want := []string{"v1.2.0", "v1.3.0"}; !reflect.DeepEqual(seen, want) {
+		t.Errorf("watchReleases() notified %v, want %v", seen, want)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// loadOutputFile reads a file previously written by gale.
+func loadOutputFile(path string) (*OutputFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var output OutputFile
+	if err := json.Unmarshal(data, &output); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &output, nil
+}
+
+// detectYanked returns the tags of baseline releases that are missing from
+// current. When current does not hold every release of the repository, only
+// baseline releases published no earlier than the oldest current release are
+// considered, so that older releases outside the fetched window are not
+// mistaken for deletions.
+func detectYanked(baseline, current []NormalizedRelease, complete bool) []string {
+	present := make(map[string]bool, len(current))
+	for _, r := range current {
+		present[r.Version] = true
+	}
+
+	var oldest NormalizedRelease
+	if len(current) > 0 {
+		oldest = current[0]
+		for _, r := range current[1:] {
+			if r.PublishedAt.Before(oldest.PublishedAt) {
+				oldest = r
+			}
+		}
+	}
+
+	var yanked []string
+	for _, r := range baseline {
+		if present[r.Version] {
+			continue
+		}
+		if !complete && (len(current) == 0 || r.PublishedAt.Before(oldest.PublishedAt)) {
+			continue
+		}
+		yanked = append(yanked, r.Version)
+	}
+	return yanked
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDetectYanked(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 0, 0, 0, 0, time.UTC) }
+	baseline := []NormalizedRelease{
+		{Version: "v1.3.0", PublishedAt: day(20)},
+		{Version: "v1.2.1", PublishedAt: day(15)},
+		{Version: "v1.2.0", PublishedAt: day(10)},
+		{Version: "v1.0.0", PublishedAt: day(1)},
+	}
+	current := []NormalizedRelease{
+		{Version: "v1.4.0", PublishedAt: day(25)},
+		{Version: "v1.3.0", PublishedAt: day(20)},
+		{Version: "v1.2.0", PublishedAt: day(10)},
+	}
+
+	testCases := []struct {
+		name     string
+		current  []NormalizedRelease
+		complete bool
+		expected []string
+	}{
+		{"Partial window ignores older releases", current, false, []string{"v1.2.1"}},
+		{"Complete fetch compares everything", current, true, []string{"v1.2.1", "v1.0.0"}},
+		{"Nothing fetched and incomplete", nil, false, nil},
+		{"Everything deleted", nil, true, []string{"v1.3.0", "v1.2.1", "v1.2.0", "v1.0.0"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := detectYanked(baseline, tc.current, tc.complete); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("detectYanked() = %v, want %v", got, tc.expected)
+			}
+		})
+	}
+}